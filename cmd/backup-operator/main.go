@@ -24,24 +24,22 @@ import (
 	controller "github.com/coreos/etcd-operator/pkg/controller/backup-operator"
 	"github.com/coreos/etcd-operator/pkg/util/constants"
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
 	version "github.com/coreos/etcd-operator/version"
 
 	"github.com/sirupsen/logrus"
-	"k8s.io/api/core/v1"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/kubernetes/scheme"
-	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
-	"k8s.io/client-go/tools/record"
 )
 
 var (
-	createCRD bool
+	createCRD    bool
+	otlpEndpoint string
 )
 
 func init() {
 	flag.BoolVar(&createCRD, "create-crd", true, "The backup operator will not create the EtcdBackup CRD when this flag is set to false.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "", "The OTLP/HTTP collector endpoint, e.g. http://otel-collector:4318, to export traces to. Tracing is disabled when this flag is not set.")
 	flag.Parse()
 }
 
@@ -64,6 +62,8 @@ func main() {
 	logrus.Infof("etcd-backup-operator Version: %v", version.Version)
 	logrus.Infof("Git SHA: %s", version.GitSHA)
 
+	tracing.Init(otlpEndpoint, "etcd-backup-operator")
+
 	kubecli := k8sutil.MustNewKubeClient()
 	rl, err := resourcelock.New(
 		resourcelock.EndpointsResourceLock,
@@ -72,7 +72,7 @@ func main() {
 		kubecli.Core(),
 		resourcelock.ResourceLockConfig{
 			Identity:      id,
-			EventRecorder: createRecorder(kubecli, name, namespace),
+			EventRecorder: k8sutil.NewEventRecorder(kubecli, name),
 		},
 	)
 	if err != nil {
@@ -93,13 +93,6 @@ func main() {
 	})
 }
 
-func createRecorder(kubecli kubernetes.Interface, name, namespace string) record.EventRecorder {
-	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartLogging(logrus.Infof)
-	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: v1core.New(kubecli.Core().RESTClient()).Events(namespace)})
-	return eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: name})
-}
-
 func run(stop <-chan struct{}) {
 	c := controller.New(createCRD)
 	err := c.Start(context.TODO())
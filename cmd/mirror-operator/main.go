@@ -0,0 +1,105 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+
+	controller "github.com/coreos/etcd-operator/pkg/controller/mirror-operator"
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	version "github.com/coreos/etcd-operator/version"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+var (
+	namespace  string
+	listenAddr string
+	createCRD  bool
+)
+
+func init() {
+	flag.StringVar(&listenAddr, "listen-addr", "0.0.0.0:8080", "The address on which the HTTP server serving metrics will listen to")
+	flag.BoolVar(&createCRD, "create-crd", true, "The mirror operator will not create the EtcdMirror CRD when this flag is set to false.")
+	flag.Parse()
+}
+
+func main() {
+	namespace = os.Getenv(constants.EnvOperatorPodNamespace)
+	if len(namespace) == 0 {
+		logrus.Fatalf("must set env %s", constants.EnvOperatorPodNamespace)
+	}
+	name := os.Getenv(constants.EnvOperatorPodName)
+	if len(name) == 0 {
+		logrus.Fatalf("must set env %s", constants.EnvOperatorPodName)
+	}
+	id, err := os.Hostname()
+	if err != nil {
+		logrus.Fatalf("failed to get hostname: %v", err)
+	}
+
+	logrus.Infof("Go Version: %s", runtime.Version())
+	logrus.Infof("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH)
+	logrus.Infof("etcd-mirror-operator Version: %v", version.Version)
+	logrus.Infof("Git SHA: %s", version.GitSHA)
+
+	http.Handle("/metrics", prometheus.Handler())
+	go http.ListenAndServe(listenAddr, nil)
+
+	kubecli := k8sutil.MustNewKubeClient()
+	rl, err := resourcelock.New(
+		resourcelock.EndpointsResourceLock,
+		namespace,
+		"etcd-mirror-operator",
+		kubecli.Core(),
+		resourcelock.ResourceLockConfig{
+			Identity:      id,
+			EventRecorder: k8sutil.NewEventRecorder(kubecli, name),
+		},
+	)
+	if err != nil {
+		logrus.Fatalf("error creating lock: %v", err)
+	}
+
+	leaderelection.RunOrDie(leaderelection.LeaderElectionConfig{
+		Lock:          rl,
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: run,
+			OnStoppedLeading: func() {
+				logrus.Fatalf("leader election lost")
+			},
+		},
+	})
+}
+
+func run(stop <-chan struct{}) {
+	c := controller.New(createCRD, namespace)
+	err := c.Start(context.TODO())
+	if err != nil {
+		logrus.Fatalf("etcd mirror operator stopped with error: %v", err)
+	}
+}
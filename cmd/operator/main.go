@@ -24,6 +24,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/coreos/etcd-operator/pkg/admission"
 	"github.com/coreos/etcd-operator/pkg/chaos"
 	"github.com/coreos/etcd-operator/pkg/client"
 	"github.com/coreos/etcd-operator/pkg/controller"
@@ -32,20 +33,17 @@ import (
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 	"github.com/coreos/etcd-operator/pkg/util/probe"
 	"github.com/coreos/etcd-operator/pkg/util/retryutil"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
 	"github.com/coreos/etcd-operator/version"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/time/rate"
-	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/kubernetes/scheme"
-	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
-	"k8s.io/client-go/tools/record"
 )
 
 var (
@@ -59,6 +57,12 @@ var (
 	printVersion bool
 
 	createCRD bool
+
+	webhookListenAddr string
+	webhookCertFile   string
+	webhookKeyFile    string
+
+	otlpEndpoint string
 )
 
 func init() {
@@ -69,6 +73,10 @@ func init() {
 	flag.BoolVar(&printVersion, "version", false, "Show version and quit")
 	flag.BoolVar(&createCRD, "create-crd", true, "The operator will not create the EtcdCluster CRD when this flag is set to false.")
 	flag.DurationVar(&gcInterval, "gc-interval", 10*time.Minute, "GC interval")
+	flag.StringVar(&webhookListenAddr, "admission-webhook-listen-addr", "0.0.0.0:8443", "The address on which the admission webhook HTTPS server will listen to")
+	flag.StringVar(&webhookCertFile, "admission-webhook-tls-cert-file", "", "The TLS certificate of the admission webhook server. The webhook is disabled when this flag is not set.")
+	flag.StringVar(&webhookKeyFile, "admission-webhook-tls-key-file", "", "The TLS private key of the admission webhook server.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "", "The OTLP/HTTP collector endpoint, e.g. http://otel-collector:4318, to export traces to. Tracing is disabled when this flag is not set.")
 	flag.Parse()
 }
 
@@ -102,6 +110,8 @@ func main() {
 	logrus.Infof("Go Version: %s", runtime.Version())
 	logrus.Infof("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH)
 
+	tracing.Init(otlpEndpoint, "etcd-operator")
+
 	id, err := os.Hostname()
 	if err != nil {
 		logrus.Fatalf("failed to get hostname: %v", err)
@@ -113,13 +123,18 @@ func main() {
 	http.Handle("/metrics", prometheus.Handler())
 	go http.ListenAndServe(listenAddr, nil)
 
+	// The webhook serves admission requests regardless of leadership.
+	if len(webhookCertFile) != 0 {
+		go serveAdmissionWebhook()
+	}
+
 	rl, err := resourcelock.New(resourcelock.EndpointsResourceLock,
 		namespace,
 		"etcd-operator",
 		kubecli.CoreV1(),
 		resourcelock.ResourceLockConfig{
 			Identity:      id,
-			EventRecorder: createRecorder(kubecli, name, namespace),
+			EventRecorder: k8sutil.NewEventRecorder(kubecli, name),
 		})
 	if err != nil {
 		logrus.Fatalf("error creating lock: %v", err)
@@ -151,6 +166,14 @@ func run(stop <-chan struct{}) {
 	logrus.Fatalf("controller Start() failed: %v", err)
 }
 
+func serveAdmissionWebhook() {
+	mux := http.NewServeMux()
+	mux.Handle("/", admission.New())
+	logrus.Infof("admission webhook listening on %v", webhookListenAddr)
+	err := http.ListenAndServeTLS(webhookListenAddr, webhookCertFile, webhookKeyFile, mux)
+	logrus.Fatalf("admission webhook server stopped: %v", err)
+}
+
 func newControllerConfig() controller.Config {
 	kubecli := k8sutil.MustNewKubeClient()
 
@@ -166,6 +189,7 @@ func newControllerConfig() controller.Config {
 		KubeExtCli:     k8sutil.MustNewKubeExtClient(),
 		EtcdCRCli:      client.MustNewInCluster(),
 		CreateCRD:      createCRD,
+		EventRecorder:  k8sutil.NewEventRecorder(kubecli, name),
 	}
 
 	return cfg
@@ -221,10 +245,3 @@ func startChaos(ctx context.Context, kubecli kubernetes.Interface, ns string, ch
 	default:
 	}
 }
-
-func createRecorder(kubecli kubernetes.Interface, name, namespace string) record.EventRecorder {
-	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartLogging(logrus.Infof)
-	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: v1core.New(kubecli.Core().RESTClient()).Events(namespace)})
-	return eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: name})
-}
@@ -25,21 +25,20 @@ import (
 	controller "github.com/coreos/etcd-operator/pkg/controller/restore-operator"
 	"github.com/coreos/etcd-operator/pkg/util/constants"
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
 	version "github.com/coreos/etcd-operator/version"
 
 	"github.com/sirupsen/logrus"
-	"k8s.io/api/core/v1"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/kubernetes/scheme"
-	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
-	"k8s.io/client-go/tools/record"
 )
 
 var (
-	namespace string
-	createCRD bool
+	namespace    string
+	createCRD    bool
+	otlpEndpoint string
+
+	servingTLSSecret string
 )
 
 const (
@@ -49,6 +48,8 @@ const (
 
 func init() {
 	flag.BoolVar(&createCRD, "create-crd", true, "The restore operator will not create the EtcdRestore CRD when this flag is set to false.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "", "The OTLP/HTTP collector endpoint, e.g. http://otel-collector:4318, to export traces to. Tracing is disabled when this flag is not set.")
+	flag.StringVar(&servingTLSSecret, "serving-tls-secret", "", "The secret with the server.crt, server.key and server-ca.crt the restore operator serves backups over TLS with. Backups are served over plain HTTP when this flag is not set.")
 	flag.Parse()
 }
 
@@ -71,6 +72,8 @@ func main() {
 	logrus.Infof("etcd-restore-operator Version: %v", version.Version)
 	logrus.Infof("Git SHA: %s", version.GitSHA)
 
+	tracing.Init(otlpEndpoint, "etcd-restore-operator")
+
 	kubecli := k8sutil.MustNewKubeClient()
 
 	err = createServiceForMyself(kubecli, name, namespace)
@@ -85,7 +88,7 @@ func main() {
 		kubecli.Core(),
 		resourcelock.ResourceLockConfig{
 			Identity:      id,
-			EventRecorder: createRecorder(kubecli, name, namespace),
+			EventRecorder: k8sutil.NewEventRecorder(kubecli, name),
 		},
 	)
 	if err != nil {
@@ -106,15 +109,8 @@ func main() {
 	})
 }
 
-func createRecorder(kubecli kubernetes.Interface, name, namespace string) record.EventRecorder {
-	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartLogging(logrus.Infof)
-	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: v1core.New(kubecli.Core().RESTClient()).Events(namespace)})
-	return eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: name})
-}
-
 func run(stop <-chan struct{}) {
-	c := controller.New(createCRD, namespace, fmt.Sprintf("%s:%d", serviceNameForMyself, servicePortForMyself))
+	c := controller.New(createCRD, namespace, fmt.Sprintf("%s:%d", serviceNameForMyself, servicePortForMyself), servingTLSSecret)
 	err := c.Start(context.TODO())
 	if err != nil {
 		logrus.Fatalf("etcd restore operator stopped with error: %v", err)
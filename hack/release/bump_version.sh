@@ -12,8 +12,10 @@ sed -i.bak -e "s/${oldv}+git/${newv}/g" version/version.go
 sed -i.bak -e "s/${oldv}/${newv}/g" example/deployment.yaml
 sed -i.bak -e "s/${oldv}/${newv}/g" example/etcd-backup-operator/deployment.yaml
 sed -i.bak -e "s/${oldv}/${newv}/g" example/etcd-restore-operator/deployment.yaml
+sed -i.bak -e "s/${oldv}/${newv}/g" example/etcd-mirror-operator/deployment.yaml
 
 rm version/version.go.bak
 rm example/deployment.yaml.bak
 rm example/etcd-backup-operator/deployment.yaml.bak
 rm example/etcd-restore-operator/deployment.yaml.bak
+rm example/etcd-mirror-operator/deployment.yaml.bak
@@ -0,0 +1,150 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+
+	"github.com/sirupsen/logrus"
+	admissionv1alpha1 "k8s.io/api/admission/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Webhook is an external admission webhook for the etcd custom resources.
+// It rejects EtcdCluster, EtcdBackup and EtcdRestore objects that the operators
+// would otherwise only find invalid after they were accepted by the API server.
+type Webhook struct {
+	logger *logrus.Entry
+}
+
+// New creates an admission webhook.
+func New() *Webhook {
+	return &Webhook{
+		logger: logrus.WithField("pkg", "admission"),
+	}
+}
+
+func (wh *Webhook) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	review := &admissionv1alpha1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode admission review: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	review.Status = admissionv1alpha1.AdmissionReviewStatus{Allowed: true}
+	if err := admit(&review.Spec); err != nil {
+		wh.logger.Infof("rejected %s %s (%s/%s): %v", review.Spec.Operation, review.Spec.Kind.Kind, review.Spec.Namespace, review.Spec.Name, err)
+		review.Status.Allowed = false
+		review.Status.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: err.Error(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		wh.logger.Errorf("failed to write admission review response: %v", err)
+	}
+}
+
+// admit returns an error if the object under review must not be admitted.
+func admit(spec *admissionv1alpha1.AdmissionReviewSpec) error {
+	if spec.Operation != admissionv1alpha1.Create && spec.Operation != admissionv1alpha1.Update {
+		return nil
+	}
+
+	switch spec.Kind.Kind {
+	case api.EtcdClusterResourceKind:
+		cl := &api.EtcdCluster{}
+		if err := json.Unmarshal(spec.Object.Raw, cl); err != nil {
+			return fmt.Errorf("failed to decode EtcdCluster: %v", err)
+		}
+		var old *api.EtcdCluster
+		if spec.Operation == admissionv1alpha1.Update {
+			old = &api.EtcdCluster{}
+			if err := json.Unmarshal(spec.OldObject.Raw, old); err != nil {
+				return fmt.Errorf("failed to decode old EtcdCluster: %v", err)
+			}
+		}
+		return admitCluster(cl, old)
+	case api.EtcdBackupResourceKind:
+		eb := &api.EtcdBackup{}
+		if err := json.Unmarshal(spec.Object.Raw, eb); err != nil {
+			return fmt.Errorf("failed to decode EtcdBackup: %v", err)
+		}
+		return eb.Spec.Validate()
+	case api.EtcdRestoreResourceKind:
+		er := &api.EtcdRestore{}
+		if err := json.Unmarshal(spec.Object.Raw, er); err != nil {
+			return fmt.Errorf("failed to decode EtcdRestore: %v", err)
+		}
+		return admitRestore(er)
+	case api.EtcdMirrorResourceKind:
+		em := &api.EtcdMirror{}
+		if err := json.Unmarshal(spec.Object.Raw, em); err != nil {
+			return fmt.Errorf("failed to decode EtcdMirror: %v", err)
+		}
+		if err := em.Spec.Validate(); err != nil {
+			return err
+		}
+		if spec.Operation != admissionv1alpha1.Update {
+			return nil
+		}
+		old := &api.EtcdMirror{}
+		if err := json.Unmarshal(spec.OldObject.Raw, old); err != nil {
+			return fmt.Errorf("failed to decode old EtcdMirror: %v", err)
+		}
+		return em.Spec.ValidateUpdate(&old.Spec)
+	default:
+		return nil
+	}
+}
+
+// admitCluster validates the defaulted cluster spec.
+// old is nil on creation.
+func admitCluster(cl, old *api.EtcdCluster) error {
+	cl.SetDefaults()
+	if err := cl.Spec.Validate(); err != nil {
+		return err
+	}
+	if old == nil {
+		return nil
+	}
+	old.SetDefaults()
+	return cl.Spec.ValidateUpdate(&old.Spec)
+}
+
+func admitRestore(er *api.EtcdRestore) error {
+	if err := er.Spec.Validate(); err != nil {
+		return err
+	}
+	// The restore operator serves the backup by the EtcdRestore name that the
+	// seed member of the restored cluster asks for. A key restore creates no seed member.
+	if !er.Spec.IsKeyRestore() && er.Name != er.Spec.EtcdCluster.Name {
+		return fmt.Errorf("EtcdRestore name (%s) must be the same as EtcdCluster name (%s)", er.Name, er.Spec.EtcdCluster.Name)
+	}
+	return nil
+}
@@ -0,0 +1,447 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"testing"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAdmitClusterUpdate(t *testing.T) {
+	tests := []struct {
+		update func(*api.EtcdCluster)
+		wErr   bool
+	}{{
+		update: func(cl *api.EtcdCluster) { cl.Spec.Size = 5 },
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Version = "3.2.15" },
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Version = "3.3.1" },
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Version = "v3.3.1" },
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Version = "3.2.0" },
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Version = "3.1.11" },
+		wErr:   true,
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.SelfHosted = &api.SelfHostedPolicy{} },
+		wErr:   true,
+	}, {
+		update: func(cl *api.EtcdCluster) {
+			cl.Spec.TLS = &api.TLSPolicy{Static: &api.StaticTLS{OperatorSecret: "op", Member: &api.MemberSecret{ServerSecret: "server"}}}
+		},
+		wErr: true,
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Pod = &api.PodPolicy{} },
+	}, {
+		update: func(cl *api.EtcdCluster) {
+			cl.Spec.Pod = &api.PodPolicy{Resources: v1.ResourceRequirements{
+				Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+			}}
+		},
+		wErr: true,
+	}, {
+		update: func(cl *api.EtcdCluster) {
+			cl.Spec.Pod = &api.PodPolicy{PersistentVolumeClaimSpec: &v1.PersistentVolumeClaimSpec{}}
+		},
+		wErr: true,
+	}, {
+		update: func(cl *api.EtcdCluster) {
+			cl.Spec.ClientService = &api.ClientServicePolicy{
+				Type:                     v1.ServiceTypeLoadBalancer,
+				LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+				ExternalTrafficPolicy:    v1.ServiceExternalTrafficPolicyTypeLocal,
+			}
+		},
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Proxy = &api.ProxyPolicy{Replicas: 2, NamespacePrefix: "/app/"} },
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Proxy = &api.ProxyPolicy{Replicas: -1} },
+		wErr:   true,
+	}, {
+		update: func(cl *api.EtcdCluster) {
+			cl.Spec.MigrateFrom = &api.MigrationPolicy{ClientEndpoints: []string{"http://10.0.0.1:2379"}}
+		},
+		wErr: true,
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Hibernate = true },
+		wErr:   true,
+	}, {
+		update: func(cl *api.EtcdCluster) {
+			cl.Spec.ClientService = &api.ClientServicePolicy{Type: v1.ServiceTypeExternalName}
+		},
+		wErr: true,
+	}, {
+		update: func(cl *api.EtcdCluster) {
+			cl.Spec.ClientService = &api.ClientServicePolicy{LoadBalancerSourceRanges: []string{"10.0.0.0/8"}}
+		},
+		wErr: true,
+	}, {
+		update: func(cl *api.EtcdCluster) {
+			cl.Spec.ClientService = &api.ClientServicePolicy{ExternalTrafficPolicy: v1.ServiceExternalTrafficPolicyTypeLocal}
+		},
+		wErr: true,
+	}}
+
+	for i, tt := range tests {
+		old := &api.EtcdCluster{Spec: api.ClusterSpec{Size: 3, Version: "3.2.13"}}
+		cl := old.DeepCopy()
+		tt.update(cl)
+		err := admitCluster(cl, old)
+		if tt.wErr && err == nil {
+			t.Errorf("#%d: expect error, get nil", i)
+		}
+		if !tt.wErr && err != nil {
+			t.Errorf("#%d: expect no error, get %v", i, err)
+		}
+	}
+}
+
+func TestAdmitClusterPVCUpdate(t *testing.T) {
+	pvcSpec := func(storage string) *v1.PersistentVolumeClaimSpec {
+		return &v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(storage)},
+			},
+		}
+	}
+	tests := []struct {
+		update    func(*v1.PersistentVolumeClaimSpec)
+		hibernate bool
+		wErr      bool
+	}{{
+		update: func(s *v1.PersistentVolumeClaimSpec) { *s = *pvcSpec("1Gi") },
+	}, {
+		update: func(s *v1.PersistentVolumeClaimSpec) { *s = *pvcSpec("2Gi") },
+	}, {
+		update:    func(s *v1.PersistentVolumeClaimSpec) { *s = *pvcSpec("2Gi") },
+		hibernate: true,
+	}, {
+		update: func(s *v1.PersistentVolumeClaimSpec) { *s = *pvcSpec("512Mi") },
+		wErr:   true,
+	}, {
+		update: func(s *v1.PersistentVolumeClaimSpec) {
+			*s = *pvcSpec("2Gi")
+			class := "fast"
+			s.StorageClassName = &class
+		},
+		wErr: true,
+	}}
+
+	for i, tt := range tests {
+		old := &api.EtcdCluster{Spec: api.ClusterSpec{Size: 3, Version: "3.2.13", Pod: &api.PodPolicy{PersistentVolumeClaimSpec: pvcSpec("1Gi")}}}
+		cl := old.DeepCopy()
+		tt.update(cl.Spec.Pod.PersistentVolumeClaimSpec)
+		cl.Spec.Hibernate = tt.hibernate
+		err := admitCluster(cl, old)
+		if tt.wErr && err == nil {
+			t.Errorf("#%d: expect error, get nil", i)
+		}
+		if !tt.wErr && err != nil {
+			t.Errorf("#%d: expect no error, get %v", i, err)
+		}
+	}
+}
+
+func TestAdmitClusterAuthUpdate(t *testing.T) {
+	tests := []struct {
+		update func(*api.EtcdCluster)
+		old    func(*api.EtcdCluster)
+		wErr   bool
+	}{{
+		update: func(cl *api.EtcdCluster) {
+			cl.Spec.Auth.Roles = append(cl.Spec.Auth.Roles, api.AuthRole{
+				Name:        "writer",
+				Permissions: []api.AuthPermission{{Type: api.AuthPermissionReadWrite, Key: "/app/", Prefix: true}},
+			})
+		},
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Auth.Users[0].PasswordSecret = "app-password-2" },
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Auth = nil },
+		wErr:   true,
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Auth = &api.AuthPolicy{RootSecret: "root"} },
+		old:    func(cl *api.EtcdCluster) { cl.Spec.Auth = nil },
+		wErr:   true,
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Auth.RootSecret = "root-2" },
+		wErr:   true,
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Auth.Users[0].Roles = []string{"writer"} },
+		wErr:   true,
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Auth.Users[0].Name = api.AuthRootUser },
+		wErr:   true,
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Auth.Roles = append(cl.Spec.Auth.Roles, cl.Spec.Auth.Roles[0]) },
+		wErr:   true,
+	}, {
+		update: func(cl *api.EtcdCluster) { cl.Spec.Auth.Roles[0].Permissions[0].Type = "admin" },
+		wErr:   true,
+	}}
+
+	for i, tt := range tests {
+		old := &api.EtcdCluster{Spec: api.ClusterSpec{Size: 3, Version: "3.2.13", Auth: &api.AuthPolicy{
+			RootSecret: "root",
+			Roles: []api.AuthRole{{
+				Name:        "reader",
+				Permissions: []api.AuthPermission{{Type: api.AuthPermissionRead, Key: "/app/", Prefix: true}},
+			}},
+			Users: []api.AuthUser{{Name: "app", PasswordSecret: "app-password", Roles: []string{"reader"}}},
+		}}}
+		if tt.old != nil {
+			tt.old(old)
+		}
+		cl := old.DeepCopy()
+		tt.update(cl)
+		err := admitCluster(cl, old)
+		if tt.wErr && err == nil {
+			t.Errorf("#%d: expect error, get nil", i)
+		}
+		if !tt.wErr && err != nil {
+			t.Errorf("#%d: expect no error, get %v", i, err)
+		}
+	}
+}
+
+func TestAdmitClusterMetrics(t *testing.T) {
+	tests := []struct {
+		version string
+		metrics *api.MetricsPolicy
+		wErr    bool
+	}{
+		{"3.3.1", &api.MetricsPolicy{}, false},
+		{"3.3.1", &api.MetricsPolicy{Port: 9100, ServiceMonitorLabels: map[string]string{"prometheus": "k8s"}}, false},
+		{"3.2.13", &api.MetricsPolicy{}, true},
+		{"3.3.1", &api.MetricsPolicy{Port: 2379}, true},
+		{"3.3.1", &api.MetricsPolicy{Port: 70000}, true},
+	}
+	for i, tt := range tests {
+		cl := &api.EtcdCluster{Spec: api.ClusterSpec{Size: 3, Version: tt.version, Metrics: tt.metrics}}
+		err := admitCluster(cl, nil)
+		if tt.wErr && err == nil {
+			t.Errorf("#%d: expect error, get nil", i)
+		}
+		if !tt.wErr && err != nil {
+			t.Errorf("#%d: expect no error, get %v", i, err)
+		}
+	}
+
+	old := &api.EtcdCluster{Spec: api.ClusterSpec{Size: 3, Version: "3.3.1", Metrics: &api.MetricsPolicy{}}}
+	cl := old.DeepCopy()
+	cl.Spec.Metrics.Port = 9100
+	if err := admitCluster(cl, old); err == nil {
+		t.Error("expect error on metrics update, get nil")
+	}
+}
+
+func TestAdmitBackup(t *testing.T) {
+	tests := []struct {
+		spec api.BackupSpec
+		wErr bool
+	}{{
+		spec: api.BackupSpec{
+			EtcdEndpoints: []string{"http://example-client:2379"},
+			StorageType:   api.BackupStorageTypeS3,
+			BackupSource:  api.BackupSource{S3: &api.S3BackupSource{Path: "bucket/etcd.backup", AWSSecret: "aws"}},
+		},
+	}, {
+		spec: api.BackupSpec{
+			EtcdEndpoints: []string{"http://example-client:2379"},
+			StorageType:   api.BackupStorageTypeS3,
+		},
+		wErr: true,
+	}, {
+		spec: api.BackupSpec{
+			EtcdEndpoints: []string{"http://example-client:2379"},
+			StorageType:   api.BackupStorageTypeS3,
+			BackupSource:  api.BackupSource{S3: &api.S3BackupSource{AWSSecret: "aws"}},
+		},
+		wErr: true,
+	}, {
+		spec: api.BackupSpec{
+			StorageType:  api.BackupStorageTypeS3,
+			BackupSource: api.BackupSource{S3: &api.S3BackupSource{Path: "bucket/etcd.backup", AWSSecret: "aws"}},
+		},
+		wErr: true,
+	}, {
+		spec: api.BackupSpec{
+			EtcdEndpoints: []string{"http://example-client:2379"},
+			StorageType:   "PV",
+		},
+		wErr: true,
+	}, {
+		spec: api.BackupSpec{
+			EtcdEndpoints: []string{"http://example-client:2379"},
+			StorageType:   api.BackupStorageTypeS3,
+			BackupSource:  api.BackupSource{S3: &api.S3BackupSource{Path: "bucket/backups", AWSSecret: "aws"}},
+			Incremental:   &api.IncrementalBackupPolicy{SegmentIntervalInSecond: 30},
+		},
+	}, {
+		spec: api.BackupSpec{
+			EtcdEndpoints: []string{"http://example-client:2379"},
+			StorageType:   api.BackupStorageTypeS3,
+			BackupSource:  api.BackupSource{S3: &api.S3BackupSource{Path: "bucket/backups", AWSSecret: "aws"}},
+			Incremental:   &api.IncrementalBackupPolicy{FullBackupIntervalInSecond: 60, SegmentIntervalInSecond: 60},
+		},
+		wErr: true,
+	}, {
+		spec: api.BackupSpec{
+			EtcdEndpoints: []string{"http://example-client:2379"},
+			StorageType:   api.BackupStorageTypeS3,
+			BackupSource:  api.BackupSource{S3: &api.S3BackupSource{Path: "bucket/backups", AWSSecret: "aws"}},
+			Incremental:   &api.IncrementalBackupPolicy{SegmentIntervalInSecond: -1},
+		},
+		wErr: true,
+	}}
+
+	for i, tt := range tests {
+		err := tt.spec.Validate()
+		if tt.wErr && err == nil {
+			t.Errorf("#%d: expect error, get nil", i)
+		}
+		if !tt.wErr && err != nil {
+			t.Errorf("#%d: expect no error, get %v", i, err)
+		}
+	}
+}
+
+func TestAdmitRestore(t *testing.T) {
+	source := api.RestoreSource{S3: &api.S3RestoreSource{Path: "bucket/backups", AWSSecret: "aws"}}
+	keys := &api.KeyRestorePolicy{Prefixes: []string{"/registry/configmaps/team-a/"}}
+	tests := []struct {
+		name string
+		spec api.RestoreSpec
+		wErr bool
+	}{{
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, TargetRevision: 42},
+	}, {
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, TargetTime: &metav1.Time{Time: time.Now()}},
+	}, {
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, TargetRevision: -1},
+		wErr: true,
+	}, {
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, TargetRevision: 42, TargetTime: &metav1.Time{Time: time.Now()}},
+		wErr: true,
+	}, {
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3},
+		wErr: true,
+	}, {
+		name: "example-team-a",
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, Mode: api.RestoreModeKeys, Keys: keys, DryRun: true},
+	}, {
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, Mode: api.RestoreModeKeys,
+			Keys: &api.KeyRestorePolicy{Prefixes: keys.Prefixes, ConflictPolicy: api.KeyConflictSkipExisting}},
+	}, {
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, Mode: api.RestoreModeKeys,
+			Keys: &api.KeyRestorePolicy{Prefixes: keys.Prefixes, ConflictPolicy: "merge"}},
+		wErr: true,
+	}, {
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, Mode: api.RestoreModeKeys},
+		wErr: true,
+	}, {
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, Mode: api.RestoreModeKeys, Keys: &api.KeyRestorePolicy{Prefixes: []string{""}}},
+		wErr: true,
+	}, {
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, Keys: keys},
+		wErr: true,
+	}, {
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source, DryRun: true},
+	}, {
+		name: "example-team-a",
+		spec: api.RestoreSpec{BackupStorageType: api.BackupStorageTypeS3, RestoreSource: source},
+		wErr: true,
+	}}
+
+	for i, tt := range tests {
+		name := tt.name
+		if len(name) == 0 {
+			name = "example"
+		}
+		er := &api.EtcdRestore{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: tt.spec}
+		er.Spec.EtcdCluster.Name = "example"
+		err := admitRestore(er)
+		if tt.wErr && err == nil {
+			t.Errorf("#%d: expect error, get nil", i)
+		}
+		if !tt.wErr && err != nil {
+			t.Errorf("#%d: expect no error, get %v", i, err)
+		}
+	}
+}
+
+func TestAdmitMirror(t *testing.T) {
+	tests := []struct {
+		spec api.MirrorSpec
+		wErr bool
+	}{{
+		spec: api.MirrorSpec{
+			Source:      api.MirrorEndpoint{EtcdCluster: "example"},
+			Destination: api.MirrorEndpoint{EtcdEndpoints: []string{"https://replica-client:2379"}, ClientTLSSecret: "replica-tls"},
+			Prefix:      "/app/",
+		},
+	}, {
+		spec: api.MirrorSpec{
+			Source:            api.MirrorEndpoint{EtcdCluster: "example"},
+			Destination:       api.MirrorEndpoint{EtcdCluster: "example"},
+			Prefix:            "/app/",
+			DestinationPrefix: "/app-copy/",
+		},
+	}, {
+		spec: api.MirrorSpec{
+			Source:            api.MirrorEndpoint{EtcdCluster: "example"},
+			Destination:       api.MirrorEndpoint{EtcdCluster: "example"},
+			Prefix:            "/app/",
+			DestinationPrefix: "/app/copy/",
+		},
+		wErr: true,
+	}, {
+		spec: api.MirrorSpec{
+			Source:      api.MirrorEndpoint{EtcdEndpoints: []string{"http://example-client:2379"}},
+			Destination: api.MirrorEndpoint{EtcdEndpoints: []string{"http://example-client:2379"}},
+		},
+		wErr: true,
+	}, {
+		spec: api.MirrorSpec{
+			Source:      api.MirrorEndpoint{EtcdCluster: "example", EtcdEndpoints: []string{"http://example-client:2379"}},
+			Destination: api.MirrorEndpoint{EtcdCluster: "replica"},
+		},
+		wErr: true,
+	}, {
+		spec: api.MirrorSpec{
+			Source: api.MirrorEndpoint{EtcdCluster: "example"},
+		},
+		wErr: true,
+	}}
+
+	for i, tt := range tests {
+		err := tt.spec.Validate()
+		if tt.wErr && err == nil {
+			t.Errorf("#%d: expect error, get nil", i)
+		}
+		if !tt.wErr && err != nil {
+			t.Errorf("#%d: expect no error, get %v", i, err)
+		}
+	}
+}
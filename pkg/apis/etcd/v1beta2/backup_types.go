@@ -14,7 +14,21 @@
 
 package v1beta2
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Phases a backup goes through, besides Pending, Completed and Failed.
+const (
+	BackupPhaseSavingSnapshot OperationPhase = "SavingSnapshot"
+	BackupPhaseVerifying      OperationPhase = "Verifying"
+	// BackupPhaseStreaming is the phase of an incremental backup while it saves changes.
+	BackupPhaseStreaming OperationPhase = "Streaming"
+)
 
 const (
 	BackupStorageTypeS3 BackupStorageType = "S3"
@@ -65,6 +79,85 @@ type BackupSpec struct {
 	//    "etcd-client.key": <pem-encoded-key>
 	//    "etcd-client-ca.crt": <pem-encoded-ca-cert>
 	ClientTLSSecret string `json:"clientTLSSecret,omitempty"`
+	// ClientCredentialsSecret is the secret containing the credentials of an etcd
+	// user allowed to take snapshots, for clusters with authentication enabled.
+	// It must contain the following data items:
+	// data:
+	//    "username": <user-name>
+	//    "password": <password>
+	ClientCredentialsSecret string `json:"clientCredentialsSecret,omitempty"`
+	// Incremental makes the backup continuous. The backup operator saves full snapshots
+	// and the changes made between them under the path of the backup source, until the
+	// EtcdBackup is deleted.
+	Incremental *IncrementalBackupPolicy `json:"incremental,omitempty"`
+	// Verify makes the backup operator read back each uploaded snapshot and check
+	// that etcd can restore it. A backup that fails the check is marked failed.
+	Verify bool `json:"verify,omitempty"`
+}
+
+// IncrementalBackupPolicy defines how often a continuous backup saves full snapshots
+// and the changes made since the last snapshot.
+type IncrementalBackupPolicy struct {
+	// FullBackupIntervalInSecond is how often a full snapshot is saved.
+	// Each full snapshot starts a new chain of segments. Defaults to 3600.
+	FullBackupIntervalInSecond int `json:"fullBackupIntervalInSecond,omitempty"`
+	// SegmentIntervalInSecond is how often the changes watched since the last
+	// segment are saved as a new segment. Defaults to 60.
+	SegmentIntervalInSecond int `json:"segmentIntervalInSecond,omitempty"`
+}
+
+const (
+	defaultFullBackupInterval = time.Hour
+	defaultSegmentInterval    = time.Minute
+)
+
+// FullBackupInterval returns how often a full snapshot is saved.
+func (ip *IncrementalBackupPolicy) FullBackupInterval() time.Duration {
+	if ip.FullBackupIntervalInSecond == 0 {
+		return defaultFullBackupInterval
+	}
+	return time.Duration(ip.FullBackupIntervalInSecond) * time.Second
+}
+
+// SegmentInterval returns how often a segment is saved.
+func (ip *IncrementalBackupPolicy) SegmentInterval() time.Duration {
+	if ip.SegmentIntervalInSecond == 0 {
+		return defaultSegmentInterval
+	}
+	return time.Duration(ip.SegmentIntervalInSecond) * time.Second
+}
+
+func (ip *IncrementalBackupPolicy) validate() error {
+	if ip.FullBackupIntervalInSecond < 0 || ip.SegmentIntervalInSecond < 0 {
+		return errors.New("spec: incremental intervals must not be negative")
+	}
+	if ip.SegmentInterval() >= ip.FullBackupInterval() {
+		return errors.New("spec: incremental.segmentIntervalInSecond must be less than incremental.fullBackupIntervalInSecond")
+	}
+	return nil
+}
+
+// Validate checks that the backup spec names an etcd cluster and a complete
+// storage source for its storage type.
+func (bs *BackupSpec) Validate() error {
+	if len(bs.EtcdEndpoints) == 0 {
+		return errors.New("spec: etcdEndpoints must be set")
+	}
+	switch bs.StorageType {
+	case BackupStorageTypeS3:
+		if bs.S3 == nil {
+			return errors.New("spec: s3 must be set for storage type S3")
+		}
+		if len(bs.S3.Path) == 0 || len(bs.S3.AWSSecret) == 0 {
+			return errors.New("spec: s3 path and awsSecret must be set")
+		}
+	default:
+		return fmt.Errorf("spec: unknown storageType (%s)", bs.StorageType)
+	}
+	if bs.Incremental != nil {
+		return bs.Incremental.validate()
+	}
+	return nil
 }
 
 // BackupSource contains the supported backup sources.
@@ -75,6 +168,8 @@ type BackupSource struct {
 
 // BackupStatus represents the status of the EtcdBackup Custom Resource.
 type BackupStatus struct {
+	OperationStatus `json:",inline"`
+
 	// Succeeded indicates if the backup has Succeeded.
 	Succeeded bool `json:"succeeded"`
 	// Reason indicates the reason for any backup related failures.
@@ -82,7 +177,41 @@ type BackupStatus struct {
 	// EtcdVersion is the version of the backup etcd server.
 	EtcdVersion string `json:"etcdVersion,omitempty"`
 	// EtcdRevision is the revision of etcd's KV store where the backup is performed on.
+	// For an incremental backup, it is the revision of the last full snapshot.
 	EtcdRevision int64 `json:"etcdRevision,omitempty"`
+	// LastSegmentRevision is the revision up to which the changes of an incremental
+	// backup are saved. The backup operator resumes watching after this revision.
+	LastSegmentRevision int64 `json:"lastSegmentRevision,omitempty"`
+	// ChainPath and ChainEtcdEndpoints are the spec.s3.path and spec.etcdEndpoints
+	// the changes up to LastSegmentRevision were saved with. The backup operator
+	// only resumes after LastSegmentRevision if the spec still has them.
+	ChainPath          string   `json:"chainPath,omitempty"`
+	ChainEtcdEndpoints []string `json:"chainEtcdEndpoints,omitempty"`
+	// Verification is the result of verifying the last saved snapshot.
+	// It is only set if spec.verify is true.
+	Verification *BackupVerification `json:"verification,omitempty"`
+}
+
+// ShouldRun returns true if the one-shot backup hasn't run yet, or failed and the
+// retry annotation was changed since.
+func (eb *EtcdBackup) ShouldRun() bool {
+	return ShouldRun(&eb.Status.OperationStatus, eb.Status.Succeeded, eb.Status.Reason, eb.Annotations[RetryAnnotation])
+}
+
+// BackupVerification is the result of checking that an uploaded snapshot can be restored.
+type BackupVerification struct {
+	// Verified indicates if the snapshot passed the check.
+	Verified bool `json:"verified"`
+	// Reason indicates why the snapshot failed the check.
+	Reason string `json:"reason,omitempty"`
+	// Hash is the hash of the keys and values of the snapshot, as `etcdctl snapshot status` reports it.
+	Hash uint32 `json:"hash,omitempty"`
+	// Revision is the kv store revision of the snapshot.
+	Revision int64 `json:"revision,omitempty"`
+	// TotalKey is the number of keys in the snapshot's backend database.
+	TotalKey int `json:"totalKey,omitempty"`
+	// VerifyTime is when the snapshot was verified.
+	VerifyTime metav1.Time `json:"verifyTime,omitempty"`
 }
 
 // S3BackupSource provides the spec how to store backups on S3.
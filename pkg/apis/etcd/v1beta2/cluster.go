@@ -16,15 +16,22 @@ package v1beta2
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	defaultRepository  = "quay.io/coreos/etcd"
 	DefaultEtcdVersion = "3.2.13"
+
+	// DefaultMetricsPort is the default port of MetricsPolicy.
+	DefaultMetricsPort = 2381
 )
 
 var (
@@ -92,6 +99,14 @@ type ClusterSpec struct {
 	// Paused is to pause the control of the operator for the etcd cluster.
 	Paused bool `json:"paused,omitempty"`
 
+	// Hibernate deletes all etcd pods while keeping their PVCs, and sets the
+	// cluster phase to Hibernated. Clearing Hibernate recreates the pods on their
+	// PVCs, and the cluster resumes with the same data.
+	// If BackupPolicy is set, a backup is saved before the cluster hibernates.
+	//
+	// Hibernate requires pod.persistentVolumeClaimSpec.
+	Hibernate bool `json:"hibernate,omitempty"`
+
 	// Pod defines the policy to create pod for the etcd pod.
 	//
 	// Updating Pod does not take effect on any existing etcd pods.
@@ -103,8 +118,154 @@ type ClusterSpec struct {
 	// SelfHosted is a cluster initialization configuration. It cannot be updated.
 	SelfHosted *SelfHostedPolicy `json:"selfHosted,omitempty"`
 
+	// MigrateFrom adopts an existing etcd cluster running outside Kubernetes.
+	// Instead of creating a new cluster, the operator adds its members to the
+	// external cluster, waits for them to catch up, and then removes the external
+	// members one by one.
+	//
+	// MigrateFrom is a cluster initialization configuration. It cannot be updated.
+	MigrateFrom *MigrationPolicy `json:"migrateFrom,omitempty"`
+
 	// etcd cluster TLS configuration
 	TLS *TLSPolicy `json:"TLS,omitempty"`
+
+	// Auth enables etcd authentication and defines the etcd users and roles.
+	// Auth cannot be removed once it is set.
+	Auth *AuthPolicy `json:"auth,omitempty"`
+
+	// BackupPolicy defines where the operator saves the backups it takes of the
+	// cluster by itself. If it is set, the operator saves a backup before upgrading
+	// the cluster to a new version.
+	BackupPolicy *BackupPolicy `json:"backupPolicy,omitempty"`
+
+	// UpgradePolicy defines how the operator upgrades the cluster to a new version.
+	UpgradePolicy *UpgradePolicy `json:"upgradePolicy,omitempty"`
+
+	// PodDisruptionBudget defines the PodDisruptionBudget the operator creates
+	// for the etcd pods. If not set, at most one member can be disrupted at a time.
+	PodDisruptionBudget *PodDisruptionBudgetPolicy `json:"podDisruptionBudget,omitempty"`
+
+	// ClientService defines the Service "<cluster-name>-client" etcd clients use
+	// to access the cluster. If not set, it is a ClusterIP Service.
+	// Updating ClientService updates the existing Service.
+	ClientService *ClientServicePolicy `json:"clientService,omitempty"`
+
+	// Proxy deploys etcd gRPC proxies in front of the members. Clients that connect
+	// to the Service "<cluster-name>-proxy" share the proxies' watches and connections
+	// to the members. Removing Proxy deletes the proxies.
+	Proxy *ProxyPolicy `json:"proxy,omitempty"`
+
+	// Metrics runs the members with a plain HTTP listener for /metrics and /health,
+	// which Prometheus can scrape without the client certificates of TLS clusters.
+	// It requires etcd 3.3 or later.
+	//
+	// Metrics is a cluster initialization configuration. It cannot be updated.
+	Metrics *MetricsPolicy `json:"metrics,omitempty"`
+}
+
+// MetricsPolicy defines how the metrics of the etcd members are exposed.
+// The client Service gets a "metrics" port. If the Prometheus Operator is
+// installed, the operator creates the ServiceMonitor "<cluster-name>" for the
+// client Service. Otherwise, the client Service gets the prometheus.io scrape
+// annotations.
+type MetricsPolicy struct {
+	// Port is the port of the metrics listener. Default is 2381.
+	Port int `json:"port,omitempty"`
+
+	// ServiceMonitorLabels are the labels of the ServiceMonitor, e.g. the labels
+	// the serviceMonitorSelector of a Prometheus matches.
+	ServiceMonitorLabels map[string]string `json:"serviceMonitorLabels,omitempty"`
+}
+
+// ProxyPolicy defines the etcd gRPC proxies of an etcd cluster.
+type ProxyPolicy struct {
+	// Replicas is the number of proxy pods. If not set or 0, it is 1.
+	Replicas int `json:"replicas,omitempty"`
+
+	// Resources is the resource requirements of the proxy container.
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NamespacePrefix is prepended to the keys of all requests through the proxies,
+	// so that their clients only see the keys under it.
+	NamespacePrefix string `json:"namespacePrefix,omitempty"`
+}
+
+// MigrationPolicy defines the external etcd cluster to migrate from.
+type MigrationPolicy struct {
+	// ClientEndpoints are the client URLs of members of the external cluster.
+	ClientEndpoints []string `json:"clientEndpoints"`
+
+	// ClientTLSSecret is the secret containing the TLS client certs of the
+	// external cluster and must contain the following data items:
+	// data:
+	//    "etcd-client.crt": <pem-encoded-cert>
+	//    "etcd-client.key": <pem-encoded-key>
+	//    "etcd-client-ca.crt": <pem-encoded-ca-cert>
+	ClientTLSSecret string `json:"clientTLSSecret,omitempty"`
+}
+
+// ClientServicePolicy defines the client Service of an etcd cluster.
+type ClientServicePolicy struct {
+	// Type is the type of the Service: ClusterIP, NodePort or LoadBalancer.
+	// If not set, it is ClusterIP.
+	Type v1.ServiceType `json:"type,omitempty"`
+
+	// Annotations are added to the Service, e.g. to configure a cloud load balancer.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// LoadBalancerSourceRanges restricts the client IPs that can access a
+	// LoadBalancer Service.
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+
+	// ExternalTrafficPolicy is the external traffic policy of a NodePort or
+	// LoadBalancer Service: Cluster or Local.
+	ExternalTrafficPolicy v1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+}
+
+func (sp *ClientServicePolicy) Validate() error {
+	switch sp.Type {
+	case "", v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer:
+	default:
+		return fmt.Errorf("spec: clientService type %q is not supported", sp.Type)
+	}
+	if len(sp.LoadBalancerSourceRanges) != 0 && sp.Type != v1.ServiceTypeLoadBalancer {
+		return errors.New("spec: clientService loadBalancerSourceRanges requires type LoadBalancer")
+	}
+	switch sp.ExternalTrafficPolicy {
+	case "":
+	case v1.ServiceExternalTrafficPolicyTypeCluster, v1.ServiceExternalTrafficPolicyTypeLocal:
+		if sp.Type != v1.ServiceTypeNodePort && sp.Type != v1.ServiceTypeLoadBalancer {
+			return errors.New("spec: clientService externalTrafficPolicy requires type NodePort or LoadBalancer")
+		}
+	default:
+		return fmt.Errorf("spec: clientService externalTrafficPolicy %q is not supported", sp.ExternalTrafficPolicy)
+	}
+	return nil
+}
+
+// PodDisruptionBudgetPolicy defines the PodDisruptionBudget of an etcd cluster.
+type PodDisruptionBudgetPolicy struct {
+	// Disabled stops the operator from creating a PodDisruptionBudget for the
+	// cluster. A PodDisruptionBudget the operator created before is deleted.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// MaxUnavailable is the number of etcd pods that can be disrupted at the same time.
+	// It is lowered to the number of members the cluster can lose without losing
+	// quorum if the cluster is too small for it.
+	// If not set or 0, it is 1.
+	MaxUnavailable int `json:"maxUnavailable,omitempty"`
+}
+
+// UpgradePolicy defines how the operator upgrades the members of an etcd cluster.
+type UpgradePolicy struct {
+	// MemberTimeoutInSecond is the maximal time the operator waits for an upgraded
+	// member to become ready and catch up with the leader before the upgrade fails.
+	// If not set or 0, the timeout is 300 seconds.
+	MemberTimeoutInSecond int `json:"memberTimeoutInSecond,omitempty"`
+
+	// Rollback determines if the operator patches a member that failed to
+	// upgrade back to its previous version.
+	Rollback bool `json:"rollback,omitempty"`
 }
 
 // PodPolicy defines the policy to create pod for the etcd container.
@@ -141,8 +302,11 @@ type PodPolicy struct {
 
 	// PersistentVolumeClaimSpec is the spec to describe PVC for the etcd container
 	// This field is optional. If no PVC spec, etcd container will use emptyDir as volume
-	// Note. This feature is in alpha stage. It is currently only used as non-stable storage,
-	// not the stable storage. Future work need to make it used as stable storage.
+	// Note. This feature is in alpha stage. If the pod of a member dies while its PVC is
+	// intact, the pod is recreated on the PVC and the member keeps its name, ID and data.
+	// A member whose PVC is lost is replaced by a new member.
+	// Only the storage request can be updated, and only increased. The PVCs of
+	// existing members are then expanded one member at a time.
 	PersistentVolumeClaimSpec *v1.PersistentVolumeClaimSpec `json:"persistentVolumeClaimSpec,omitempty"`
 }
 
@@ -153,6 +317,56 @@ func (c *ClusterSpec) Validate() error {
 		}
 	}
 
+	if c.Auth != nil {
+		if err := c.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.BackupPolicy != nil {
+		if err := c.BackupPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if pdb := c.PodDisruptionBudget; pdb != nil && pdb.MaxUnavailable < 0 {
+		return errors.New("spec: podDisruptionBudget maxUnavailable must not be negative")
+	}
+
+	if c.ClientService != nil {
+		if err := c.ClientService.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Proxy != nil && c.Proxy.Replicas < 0 {
+		return errors.New("spec: proxy replicas must not be negative")
+	}
+
+	if c.MigrateFrom != nil {
+		if c.SelfHosted != nil {
+			return errors.New("spec: migrateFrom is not supported for self hosted clusters")
+		}
+		if len(c.MigrateFrom.ClientEndpoints) == 0 {
+			return errors.New("spec: migrateFrom clientEndpoints must be set")
+		}
+	}
+
+	if c.Metrics != nil {
+		if err := c.validateMetrics(); err != nil {
+			return err
+		}
+	}
+
+	if c.Hibernate {
+		if c.SelfHosted != nil {
+			return errors.New("spec: hibernate is not supported for self hosted clusters")
+		}
+		if c.Pod.pvcSpec() == nil {
+			return errors.New("spec: hibernate requires pod persistentVolumeClaimSpec")
+		}
+	}
+
 	if c.Pod != nil {
 		for k := range c.Pod.Labels {
 			if k == "app" || strings.HasPrefix(k, "etcd_") {
@@ -163,6 +377,125 @@ func (c *ClusterSpec) Validate() error {
 	return nil
 }
 
+// ValidateUpdate checks that the transition from old to c only changes
+// fields the operator is able to act on.
+// Both specs are expected to have been defaulted.
+func (c *ClusterSpec) ValidateUpdate(old *ClusterSpec) error {
+	if !reflect.DeepEqual(c.SelfHosted, old.SelfHosted) {
+		return errors.New("spec: selfHosted cannot be updated")
+	}
+	if !reflect.DeepEqual(c.TLS, old.TLS) {
+		return errors.New("spec: TLS cannot be updated")
+	}
+	if !reflect.DeepEqual(c.MigrateFrom, old.MigrateFrom) {
+		return errors.New("spec: migrateFrom cannot be updated")
+	}
+	if !reflect.DeepEqual(c.Metrics, old.Metrics) {
+		return errors.New("spec: metrics cannot be updated")
+	}
+	if old.Auth != nil && c.Auth == nil {
+		return errors.New("spec: auth cannot be removed")
+	}
+	if old.Auth == nil && c.Auth != nil {
+		// The probes of the existing members could not authenticate.
+		return errors.New("spec: auth cannot be added to an existing cluster")
+	}
+	if old.Auth != nil && c.Auth.RootSecret != old.Auth.RootSecret {
+		return errors.New("spec: auth rootSecret cannot be updated")
+	}
+	if !equality.Semantic.DeepEqual(c.Pod.resources(), old.Pod.resources()) {
+		return errors.New("spec: pod resources cannot be updated")
+	}
+	if err := validatePVCSpecUpdate(c.Pod.pvcSpec(), old.Pod.pvcSpec()); err != nil {
+		return err
+	}
+	if c.Version != old.Version {
+		oldMajor, oldMinor, err := majorMinor(old.Version)
+		if err != nil {
+			return fmt.Errorf("spec: invalid current version (%s): %v", old.Version, err)
+		}
+		major, minor, err := majorMinor(c.Version)
+		if err != nil {
+			return fmt.Errorf("spec: invalid version (%s): %v", c.Version, err)
+		}
+		if major < oldMajor || (major == oldMajor && minor < oldMinor) {
+			return fmt.Errorf("spec: downgrade from %s to %s is not supported", old.Version, c.Version)
+		}
+	}
+	return nil
+}
+
+func (c *ClusterSpec) validateMetrics() error {
+	if c.SelfHosted != nil {
+		return errors.New("spec: metrics is not supported for self hosted clusters")
+	}
+	if p := c.Metrics.Port; p < 1 || p > 65535 || p == 2379 || p == 2380 {
+		return fmt.Errorf("spec: invalid metrics port %d", p)
+	}
+	major, minor, err := majorMinor(c.Version)
+	if err != nil {
+		return fmt.Errorf("spec: invalid version (%s): %v", c.Version, err)
+	}
+	if major < 3 || (major == 3 && minor < 3) {
+		return errors.New("spec: metrics requires etcd 3.3 or later")
+	}
+	return nil
+}
+
+// validatePVCSpecUpdate only allows the storage request of the PVC spec to increase.
+func validatePVCSpecUpdate(spec, old *v1.PersistentVolumeClaimSpec) error {
+	if spec == nil || old == nil {
+		if spec != old {
+			return errors.New("spec: pod persistentVolumeClaimSpec cannot be added or removed")
+		}
+		return nil
+	}
+
+	size, oldSize := spec.Resources.Requests[v1.ResourceStorage], old.Resources.Requests[v1.ResourceStorage]
+	if size.Cmp(oldSize) < 0 {
+		return fmt.Errorf("spec: pod persistentVolumeClaimSpec storage request cannot be decreased from %s to %s", oldSize.String(), size.String())
+	}
+	s := spec.DeepCopy()
+	if _, ok := old.Resources.Requests[v1.ResourceStorage]; ok {
+		s.Resources.Requests[v1.ResourceStorage] = oldSize
+	}
+	if !equality.Semantic.DeepEqual(s, old) {
+		return errors.New("spec: only the storage request of pod persistentVolumeClaimSpec can be updated")
+	}
+	return nil
+}
+
+func (p *PodPolicy) resources() v1.ResourceRequirements {
+	if p == nil {
+		return v1.ResourceRequirements{}
+	}
+	return p.Resources
+}
+
+func (p *PodPolicy) pvcSpec() *v1.PersistentVolumeClaimSpec {
+	if p == nil {
+		return nil
+	}
+	return p.PersistentVolumeClaimSpec
+}
+
+// majorMinor returns the major and minor number of a "x.y.z" version.
+func majorMinor(version string) (int, int, error) {
+	toks := strings.SplitN(version, ".", 3)
+	if len(toks) < 2 {
+		return 0, 0, errors.New("version must be of the form x.y.z")
+	}
+	major, err := strconv.Atoi(toks[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	minor, err := strconv.Atoi(toks[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return major, minor, nil
+}
+
 // SetDefaults cleans up user passed spec, e.g. defaulting, transforming fields.
 // TODO: move this to admission controller
 func (e *EtcdCluster) SetDefaults() {
@@ -177,6 +510,10 @@ func (e *EtcdCluster) SetDefaults() {
 
 	c.Version = strings.TrimLeft(c.Version, "v")
 
+	if c.Metrics != nil && c.Metrics.Port == 0 {
+		c.Metrics.Port = DefaultMetricsPort
+	}
+
 	// convert PodPolicy.AntiAffinity to Pod.Affinity.PodAntiAffinity
 	// TODO: Remove this once PodPolicy.AntiAffinity is removed
 	if c.Pod != nil && c.Pod.AntiAffinity && c.Pod.Affinity == nil {
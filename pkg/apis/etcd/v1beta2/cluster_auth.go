@@ -0,0 +1,135 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta2
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// AuthSecretUsernameKey is the key of the etcd user name in a credentials secret.
+	AuthSecretUsernameKey = "username"
+	// AuthSecretPasswordKey is the key of the etcd user password in a credentials secret.
+	AuthSecretPasswordKey = "password"
+
+	// AuthRootUser is the etcd root user.
+	AuthRootUser = "root"
+)
+
+// AuthPermissionType is the type of access an etcd role permission grants.
+type AuthPermissionType string
+
+const (
+	AuthPermissionRead      AuthPermissionType = "read"
+	AuthPermissionWrite     AuthPermissionType = "write"
+	AuthPermissionReadWrite AuthPermissionType = "readwrite"
+)
+
+// AuthPolicy defines the etcd authentication of an etcd cluster.
+// The operator creates the root user, enables authentication, and keeps the
+// given roles and users in sync. Roles and users that are not listed are left alone.
+type AuthPolicy struct {
+	// RootSecret is the secret containing the password of the etcd root user:
+	// data:
+	//    "password": <root-password>
+	// The operator authenticates to the cluster as root.
+	// Changing the password in the secret does not change the root password in etcd.
+	RootSecret string `json:"rootSecret"`
+
+	// Roles are the etcd roles the operator creates and keeps in sync.
+	Roles []AuthRole `json:"roles,omitempty"`
+
+	// Users are the etcd users the operator creates and keeps in sync.
+	Users []AuthUser `json:"users,omitempty"`
+}
+
+// AuthRole is an etcd role.
+type AuthRole struct {
+	// Name is the name of the role.
+	Name string `json:"name"`
+	// Permissions are the permissions the role grants.
+	// Permissions of the role that are not listed are revoked.
+	Permissions []AuthPermission `json:"permissions,omitempty"`
+}
+
+// AuthPermission grants access to a key or a key prefix.
+type AuthPermission struct {
+	// Type is the type of access: read, write or readwrite.
+	Type AuthPermissionType `json:"type"`
+	// Key is the key the permission applies to.
+	Key string `json:"key"`
+	// Prefix applies the permission to all keys starting with Key.
+	Prefix bool `json:"prefix,omitempty"`
+}
+
+// AuthUser is an etcd user.
+type AuthUser struct {
+	// Name is the name of the user.
+	Name string `json:"name"`
+	// PasswordSecret is the secret containing the password of the user:
+	// data:
+	//    "password": <password>
+	// The password in etcd is updated when the secret changes.
+	PasswordSecret string `json:"passwordSecret"`
+	// Roles are the names of the roles granted to the user.
+	// Roles of the user that are not listed are revoked.
+	Roles []string `json:"roles,omitempty"`
+}
+
+func (ap *AuthPolicy) Validate() error {
+	if len(ap.RootSecret) == 0 {
+		return errors.New("spec: auth rootSecret must be set")
+	}
+	roles := map[string]bool{AuthRootUser: true}
+	for _, r := range ap.Roles {
+		if len(r.Name) == 0 || r.Name == AuthRootUser {
+			return fmt.Errorf("spec: auth role name %q is not allowed", r.Name)
+		}
+		if roles[r.Name] {
+			return fmt.Errorf("spec: auth role %q is defined more than once", r.Name)
+		}
+		roles[r.Name] = true
+		for _, p := range r.Permissions {
+			switch p.Type {
+			case AuthPermissionRead, AuthPermissionWrite, AuthPermissionReadWrite:
+			default:
+				return fmt.Errorf("spec: auth role %q has unknown permission type %q", r.Name, p.Type)
+			}
+			if len(p.Key) == 0 {
+				return fmt.Errorf("spec: auth role %q has a permission without key", r.Name)
+			}
+		}
+	}
+	users := map[string]bool{}
+	for _, u := range ap.Users {
+		if len(u.Name) == 0 || u.Name == AuthRootUser {
+			return fmt.Errorf("spec: auth user name %q is not allowed", u.Name)
+		}
+		if users[u.Name] {
+			return fmt.Errorf("spec: auth user %q is defined more than once", u.Name)
+		}
+		users[u.Name] = true
+		if len(u.PasswordSecret) == 0 {
+			return fmt.Errorf("spec: auth user %q passwordSecret must be set", u.Name)
+		}
+		for _, r := range u.Roles {
+			if !roles[r] {
+				return fmt.Errorf("spec: auth user %q has undefined role %q", u.Name, r)
+			}
+		}
+	}
+	return nil
+}
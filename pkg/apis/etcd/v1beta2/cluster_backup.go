@@ -0,0 +1,41 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta2
+
+import "errors"
+
+// BackupPolicy defines where the etcd operator saves the backups it takes of
+// an etcd cluster by itself, e.g. before upgrading the cluster to a new version.
+type BackupPolicy struct {
+	// StorageType is the etcd backup storage type.
+	StorageType BackupStorageType `json:"storageType"`
+
+	// S3 defines where on S3 the backups are saved.
+	// S3.Path is a prefix of the form "<s3-bucket-name>/<prefix>", e.g "mybucket/etcd".
+	// Backups of the cluster are saved under "<s3-bucket-name>/<prefix>/v1/<namespace>/<cluster-name>/".
+	S3 *S3BackupSource `json:"s3,omitempty"`
+}
+
+func (bp *BackupPolicy) Validate() error {
+	switch bp.StorageType {
+	case BackupStorageTypeS3:
+		if bp.S3 == nil || len(bp.S3.Path) == 0 || len(bp.S3.AWSSecret) == 0 {
+			return errors.New("spec: backup policy s3 path and awsSecret must be set")
+		}
+	default:
+		return errors.New("spec: backup policy has unknown storageType")
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta2
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type MirrorPhase string
+
+const (
+	// MirrorPhaseCopying means the keys of the source are being copied to the destination.
+	MirrorPhaseCopying MirrorPhase = "Copying"
+	// MirrorPhaseMirroring means the changes of the source are being applied to the destination.
+	MirrorPhaseMirroring MirrorPhase = "Mirroring"
+	// MirrorPhaseFailed means the last attempt to mirror failed. It is retried.
+	MirrorPhaseFailed MirrorPhase = "Failed"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EtcdMirrorList is a list of EtcdMirror.
+type EtcdMirrorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []EtcdMirror `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EtcdMirror represents a Kubernetes EtcdMirror Custom Resource.
+// The mirror operator continuously copies the keys under a prefix from a
+// source etcd cluster to a destination etcd cluster.
+type EtcdMirror struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              MirrorSpec   `json:"spec"`
+	Status            MirrorStatus `json:"status,omitempty"`
+}
+
+// MirrorSpec defines what to mirror from where to where.
+// The spec cannot be updated.
+type MirrorSpec struct {
+	// Source is the etcd cluster the keys are read from.
+	Source MirrorEndpoint `json:"source"`
+	// Destination is the etcd cluster the keys are written to.
+	// When the source is copied, the keys of the destination under the destination
+	// prefix that are not in the source are deleted.
+	Destination MirrorEndpoint `json:"destination"`
+	// Prefix is the prefix of the keys to mirror. If not set, all keys are mirrored.
+	Prefix string `json:"prefix,omitempty"`
+	// DestinationPrefix replaces Prefix in the keys written to the destination.
+	// If not set, the keys are written unchanged.
+	DestinationPrefix string `json:"destinationPrefix,omitempty"`
+}
+
+// MirrorEndpoint is an etcd cluster to mirror from or to.
+// Exactly one of EtcdCluster and EtcdEndpoints must be set.
+type MirrorEndpoint struct {
+	// EtcdCluster is the name of an EtcdCluster in the namespace of the mirror operator.
+	// Its client service is used. Its operator TLS secret and root credentials are
+	// used unless ClientTLSSecret and ClientCredentialsSecret are set.
+	EtcdCluster string `json:"etcdCluster,omitempty"`
+	// EtcdEndpoints are the endpoints of an etcd cluster.
+	EtcdEndpoints []string `json:"etcdEndpoints,omitempty"`
+	// ClientTLSSecret is the secret containing the etcd TLS client certs and
+	// must contain the following data items:
+	// data:
+	//    "etcd-client.crt": <pem-encoded-cert>
+	//    "etcd-client.key": <pem-encoded-key>
+	//    "etcd-client-ca.crt": <pem-encoded-ca-cert>
+	ClientTLSSecret string `json:"clientTLSSecret,omitempty"`
+	// ClientCredentialsSecret is the secret containing the credentials of an etcd user:
+	// data:
+	//    "username": <user-name>
+	//    "password": <password>
+	ClientCredentialsSecret string `json:"clientCredentialsSecret,omitempty"`
+}
+
+// MirrorStatus reports the progress of a mirror.
+type MirrorStatus struct {
+	// Phase is the mirror phase: Copying, Mirroring or Failed.
+	Phase MirrorPhase `json:"phase,omitempty"`
+	// Reason indicates the reason of the last failure.
+	Reason string `json:"reason,omitempty"`
+	// Revision is the last revision of the source that has been applied to the
+	// destination. The mirror resumes from it after the mirror operator restarts.
+	Revision int64 `json:"revision,omitempty"`
+	// LastSyncTime is the time Revision was last updated.
+	LastSyncTime string `json:"lastSyncTime,omitempty"`
+}
+
+// Validate checks that the mirror spec references a source and a destination
+// that the mirror does not feed back into.
+func (ms *MirrorSpec) Validate() error {
+	if err := ms.Source.validate("source"); err != nil {
+		return err
+	}
+	if err := ms.Destination.validate("destination"); err != nil {
+		return err
+	}
+	if len(ms.Source.EtcdCluster) != 0 && ms.Source.EtcdCluster == ms.Destination.EtcdCluster ||
+		reflect.DeepEqual(ms.Source.EtcdEndpoints, ms.Destination.EtcdEndpoints) && len(ms.Source.EtcdEndpoints) != 0 {
+		dst := ms.DestinationPrefix
+		if len(dst) == 0 {
+			dst = ms.Prefix
+		}
+		if strings.HasPrefix(dst, ms.Prefix) || strings.HasPrefix(ms.Prefix, dst) {
+			return errors.New("spec: destinationPrefix must not overlap with prefix when mirroring within a cluster")
+		}
+	}
+	return nil
+}
+
+// ValidateUpdate checks that the mirror spec is not updated.
+func (ms *MirrorSpec) ValidateUpdate(old *MirrorSpec) error {
+	if !reflect.DeepEqual(ms, old) {
+		return errors.New("spec: mirror spec cannot be updated")
+	}
+	return nil
+}
+
+func (me *MirrorEndpoint) validate(field string) error {
+	if (len(me.EtcdCluster) == 0) == (len(me.EtcdEndpoints) == 0) {
+		return fmt.Errorf("spec: exactly one of %s etcdCluster and etcdEndpoints must be set", field)
+	}
+	return nil
+}
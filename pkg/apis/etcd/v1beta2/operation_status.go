@@ -0,0 +1,131 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta2
+
+import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RetryAnnotation is the annotation that retries a failed EtcdBackup or EtcdRestore
+// when its value changes, e.g. to the current time.
+const RetryAnnotation = "etcd.database.coreos.com/retry"
+
+// OperationPhase is a step of a backup or restore.
+type OperationPhase string
+
+const (
+	OperationPhasePending   OperationPhase = "Pending"
+	OperationPhaseCompleted OperationPhase = "Completed"
+	OperationPhaseFailed    OperationPhase = "Failed"
+)
+
+// OperationStatus is the progress of a backup or restore.
+type OperationStatus struct {
+	// Phase is the step the backup or restore is in.
+	Phase OperationPhase `json:"phase,omitempty"`
+	// StartTime is when the backup or restore started.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is when the backup or restore completed or failed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Conditions are the phases the backup or restore went through.
+	Conditions []OperationCondition `json:"conditions,omitempty"`
+	// BytesTransferred is the size of the snapshot saved or fetched.
+	BytesTransferred int64 `json:"bytesTransferred,omitempty"`
+	// ObservedRetry is the value of the retry annotation this status is for.
+	ObservedRetry string `json:"observedRetry,omitempty"`
+}
+
+// OperationCondition describes a phase a backup or restore went through.
+type OperationCondition struct {
+	// Type is the phase.
+	Type OperationPhase `json:"type"`
+	// Status is Unknown while the phase is in progress, True once it is done,
+	// and False if it failed.
+	Status v1.ConditionStatus `json:"status"`
+	// LastTransitionTime is when the phase started, or ended once it is done or failed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// Message is a human readable message about the phase.
+	Message string `json:"message,omitempty"`
+}
+
+// SetPhase marks the current phase done and starts p.
+func (s *OperationStatus) SetPhase(p OperationPhase) {
+	s.setPhase(p, "")
+}
+
+// SetCompleted marks the current phase done and the operation completed.
+func (s *OperationStatus) SetCompleted() {
+	s.setPhase(OperationPhaseCompleted, "")
+}
+
+// SetFailed marks the current phase and the operation failed with the given reason.
+func (s *OperationStatus) SetFailed(reason string) {
+	s.setPhase(OperationPhaseFailed, reason)
+}
+
+// InProgress returns true if the operation started and has not completed or failed.
+func (s *OperationStatus) InProgress() bool {
+	return len(s.Phase) != 0 && !s.terminal()
+}
+
+func (s *OperationStatus) terminal() bool {
+	return s.Phase == OperationPhaseCompleted || s.Phase == OperationPhaseFailed
+}
+
+func (s *OperationStatus) setPhase(next OperationPhase, reason string) {
+	now := metav1.Now()
+	if s.StartTime == nil {
+		s.StartTime = &now
+	}
+	if c := s.condition(s.Phase); c != nil && c.Status == v1.ConditionUnknown {
+		c.Status, c.LastTransitionTime = v1.ConditionTrue, now
+		if next == OperationPhaseFailed {
+			c.Status, c.Message = v1.ConditionFalse, reason
+		}
+	}
+	s.Phase = next
+	if s.terminal() {
+		s.CompletionTime = &now
+		return
+	}
+	// An incremental backup goes on after it failed.
+	s.CompletionTime = nil
+	c := OperationCondition{Type: next, Status: v1.ConditionUnknown, LastTransitionTime: now}
+	if cp := s.condition(next); cp != nil {
+		*cp = c
+	} else {
+		s.Conditions = append(s.Conditions, c)
+	}
+}
+
+func (s *OperationStatus) condition(p OperationPhase) *OperationCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == p {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// ShouldRun returns true if an operation with the given status and retry
+// annotation value has not run yet, or failed and was asked to retry.
+// A status without a phase that has a result was set before phases were tracked.
+func ShouldRun(s *OperationStatus, succeeded bool, reason, retry string) bool {
+	if s.Phase == OperationPhaseFailed || (len(s.Phase) == 0 && !succeeded && len(reason) != 0) {
+		return retry != s.ObservedRetry
+	}
+	return len(s.Phase) == 0 && !succeeded
+}
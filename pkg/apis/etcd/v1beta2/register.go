@@ -30,6 +30,9 @@ const (
 
 	EtcdRestoreResourceKind   = "EtcdRestore"
 	EtcdRestoreResourcePlural = "etcdrestores"
+
+	EtcdMirrorResourceKind   = "EtcdMirror"
+	EtcdMirrorResourcePlural = "etcdmirrors"
 )
 
 var (
@@ -40,6 +43,7 @@ var (
 	EtcdClusterCRDName = EtcdClusterResourcePlural + "." + groupName
 	EtcdBackupCRDName  = EtcdBackupResourcePlural + "." + groupName
 	EtcdRestoreCRDName = EtcdRestoreResourcePlural + "." + groupName
+	EtcdMirrorCRDName  = EtcdMirrorResourcePlural + "." + groupName
 )
 
 // Resource gets an EtcdCluster GroupResource for a specified resource
@@ -56,6 +60,8 @@ func addKnownTypes(s *runtime.Scheme) error {
 		&EtcdBackupList{},
 		&EtcdRestore{},
 		&EtcdRestoreList{},
+		&EtcdMirror{},
+		&EtcdMirrorList{},
 	)
 	metav1.AddToGroupVersion(s, SchemeGroupVersion)
 	return nil
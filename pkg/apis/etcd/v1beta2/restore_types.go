@@ -14,7 +14,22 @@
 
 package v1beta2
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Phases a restore goes through, besides Pending, Completed and Failed.
+const (
+	RestorePhaseFetchingSnapshot OperationPhase = "FetchingSnapshot"
+	RestorePhaseDeletingCluster  OperationPhase = "DeletingCluster"
+	RestorePhaseSeedingMember    OperationPhase = "SeedingMember"
+	RestorePhaseReplaying        OperationPhase = "Replaying"
+	RestorePhaseScaling          OperationPhase = "Scaling"
+)
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -48,6 +63,54 @@ type RestoreSpec struct {
 	// This reference EtcdCluster CR and all its resources will be deleted before the
 	// restored EtcdCluster CR is created.
 	EtcdCluster EtcdClusterRef `json:"etcdCluster"`
+
+	// TargetRevision restores the newest backup taken at or before this etcd revision.
+	// When TargetRevision or TargetTime is set, the path of the restore source is the prefix
+	// under which the backups are saved, e.g. the prefix of spec.backupPolicy of the cluster,
+	// and the backups must be named by the etcd version and revision they were taken at.
+	// The changes saved by an incremental backup under the same path after the restored
+	// backup are replayed up to the target.
+	TargetRevision int64 `json:"targetRevision,omitempty"`
+	// TargetTime restores the newest backup saved at or before this time.
+	// It can't be set together with TargetRevision.
+	TargetTime *metav1.Time `json:"targetTime,omitempty"`
+
+	// Mode is what the restore replaces. Defaults to "full".
+	// With "full", the reference EtcdCluster is replaced by a cluster restored from the backup.
+	// With "keys", the keys under spec.keys.prefixes are copied from the backup into
+	// the running reference EtcdCluster, which is otherwise left alone.
+	Mode RestoreMode `json:"mode,omitempty"`
+	// Keys selects the keys to restore and how, for mode "keys".
+	Keys *KeyRestorePolicy `json:"keys,omitempty"`
+	// DryRun makes the restore check the backup and report in the status what it
+	// would change, without changing anything.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+type RestoreMode string
+
+const (
+	RestoreModeFull RestoreMode = "full"
+	RestoreModeKeys RestoreMode = "keys"
+)
+
+type KeyConflictPolicy string
+
+const (
+	// KeyConflictOverwrite overwrites keys that exist in the cluster with their value in the backup.
+	KeyConflictOverwrite KeyConflictPolicy = "overwrite"
+	// KeyConflictSkipExisting leaves keys that exist in the cluster as they are.
+	KeyConflictSkipExisting KeyConflictPolicy = "skip-existing"
+)
+
+// KeyRestorePolicy defines which keys a restore of mode "keys" copies into the cluster.
+type KeyRestorePolicy struct {
+	// Prefixes are the key prefixes to restore, e.g. "/registry/configmaps/team-a/".
+	// Keys in the cluster under these prefixes that are not in the backup are left as they are.
+	Prefixes []string `json:"prefixes"`
+	// ConflictPolicy is what to do with keys that exist both in the backup and in the
+	// cluster. Defaults to "overwrite".
+	ConflictPolicy KeyConflictPolicy `json:"conflictPolicy,omitempty"`
 }
 
 // EtcdCluster references an EtcdCluster resource whose metadata and spec
@@ -84,10 +147,173 @@ type S3RestoreSource struct {
 	Endpoint string `json:"endpoint"`
 }
 
+// ValidateRestoreVersion checks that a cluster of clusterVersion can restore a backup
+// taken from etcd backupVersion. etcd restores backups of the same or an older minor version.
+func ValidateRestoreVersion(backupVersion, clusterVersion string) error {
+	major, minor, err := majorMinor(clusterVersion)
+	if err != nil {
+		return fmt.Errorf("invalid cluster version (%s): %v", clusterVersion, err)
+	}
+	backupMajor, backupMinor, err := majorMinor(backupVersion)
+	if err != nil {
+		return fmt.Errorf("invalid backup etcd version (%s): %v", backupVersion, err)
+	}
+	if backupMajor != major || backupMinor > minor {
+		return fmt.Errorf("backup of etcd %s cannot be restored by etcd %s", backupVersion, clusterVersion)
+	}
+	return nil
+}
+
+// IsKeyRestore returns true if the restore copies keys into the running cluster
+// instead of replacing it.
+func (rs *RestoreSpec) IsKeyRestore() bool {
+	return rs.Mode == RestoreModeKeys
+}
+
+// Validate checks that the restore spec references an etcd cluster and a
+// complete restore source for its storage type.
+func (rs *RestoreSpec) Validate() error {
+	if len(rs.EtcdCluster.Name) == 0 {
+		return errors.New("spec: etcdCluster.name must be set")
+	}
+	switch rs.BackupStorageType {
+	case BackupStorageTypeS3:
+		if rs.S3 == nil {
+			return errors.New("spec: s3 must be set for backup storage type S3")
+		}
+		if len(rs.S3.Path) == 0 || len(rs.S3.AWSSecret) == 0 {
+			return errors.New("spec: s3 path and awsSecret must be set")
+		}
+	default:
+		return fmt.Errorf("spec: unknown backupStorageType (%s)", rs.BackupStorageType)
+	}
+	if rs.TargetRevision < 0 {
+		return errors.New("spec: targetRevision must not be negative")
+	}
+	if rs.TargetRevision != 0 && rs.TargetTime != nil {
+		return errors.New("spec: only one of targetRevision and targetTime can be set")
+	}
+	switch rs.Mode {
+	case "", RestoreModeFull:
+		if rs.Keys != nil {
+			return errors.New("spec: keys can only be set with mode keys")
+		}
+	case RestoreModeKeys:
+		return rs.Keys.validate()
+	default:
+		return fmt.Errorf("spec: unknown mode (%s)", rs.Mode)
+	}
+	return nil
+}
+
+func (kp *KeyRestorePolicy) validate() error {
+	if kp == nil || len(kp.Prefixes) == 0 {
+		return errors.New("spec: keys.prefixes must be set with mode keys")
+	}
+	for _, p := range kp.Prefixes {
+		if len(p) == 0 {
+			return errors.New("spec: keys.prefixes must not be empty")
+		}
+	}
+	switch kp.ConflictPolicy {
+	case "", KeyConflictOverwrite, KeyConflictSkipExisting:
+		return nil
+	default:
+		return fmt.Errorf("spec: unknown keys.conflictPolicy (%s)", kp.ConflictPolicy)
+	}
+}
+
+// IsPointInTime returns true if the restore picks the backup by a target
+// revision or time instead of restoring the backup at the given path.
+func (rs *RestoreSpec) IsPointInTime() bool {
+	return rs.TargetRevision != 0 || rs.TargetTime != nil
+}
+
 // RestoreStatus reports the status of this restore operation.
 type RestoreStatus struct {
+	OperationStatus `json:",inline"`
+
 	// Succeeded indicates if the backup has Succeeded.
 	Succeeded bool `json:"succeeded"`
 	// Reason indicates the reason for any backup related failures.
 	Reason string `json:"reason,omitempty"`
+	// BackupPath is the path of the backup that is restored.
+	BackupPath string `json:"backupPath,omitempty"`
+	// BackupRevision is the etcd revision of the backup that is restored.
+	// It is only set for a point in time restore.
+	BackupRevision int64 `json:"backupRevision,omitempty"`
+	// ReplayedRevision is the revision of the last change of an incremental backup
+	// replayed on top of the restored backup. It is not set if no change was replayed.
+	ReplayedRevision int64 `json:"replayedRevision,omitempty"`
+	// Keys reports the keys a restore of mode "keys" changed, or would change with dryRun.
+	Keys *KeyRestoreStatus `json:"keys,omitempty"`
+	// DryRun reports what a dry run of a full restore found.
+	DryRun *RestoreDryRunStatus `json:"dryRun,omitempty"`
+	// ClusterUID is the UID of the EtcdCluster the restore created.
+	// A retry of a restore that failed while replaying or scaling resumes on that cluster.
+	ClusterUID types.UID `json:"clusterUID,omitempty"`
+}
+
+// ShouldRun returns true if the restore hasn't run yet, or failed and the retry
+// annotation was changed since.
+func (er *EtcdRestore) ShouldRun() bool {
+	return ShouldRun(&er.Status.OperationStatus, er.Status.Succeeded, er.Status.Reason, er.Annotations[RetryAnnotation])
+}
+
+// RestoreDryRunStatus reports the backup a full restore would restore, and the
+// resources of the reference cluster it would replace.
+type RestoreDryRunStatus struct {
+	// BackupSize is the size of the backup in bytes.
+	BackupSize int64 `json:"backupSize"`
+	// BackupEtcdVersion is the etcd version the backup was taken from.
+	// It is only known for a backup named by its etcd version and revision.
+	BackupEtcdVersion string `json:"backupEtcdVersion,omitempty"`
+	// BackupEtcdVersionUnknown is set if BackupEtcdVersion is not known, so the
+	// backup could not be checked against ClusterVersion.
+	BackupEtcdVersionUnknown bool `json:"backupEtcdVersionUnknown,omitempty"`
+	// SnapshotRevision is the kv store revision of the backup.
+	SnapshotRevision int64 `json:"snapshotRevision"`
+	// SnapshotHash is the hash of the keys and values of the backup, as `etcdctl snapshot status` reports it.
+	SnapshotHash uint32 `json:"snapshotHash"`
+	// TotalKey is the number of keys in the backend database of the backup.
+	TotalKey int `json:"totalKey"`
+	// ReplaySegments is the number of segments of an incremental backup that would be replayed.
+	ReplaySegments int `json:"replaySegments,omitempty"`
+	// ClusterVersion is the etcd version of the restored cluster.
+	ClusterVersion string `json:"clusterVersion"`
+	// ClusterSize is the number of members the restored cluster would be scaled to.
+	ClusterSize int `json:"clusterSize"`
+	// DeletedResources are the resources of the reference cluster that would be
+	// deleted and recreated, as "<kind>/<name>".
+	DeletedResources []string `json:"deletedResources,omitempty"`
+}
+
+type KeyAction string
+
+const (
+	KeyActionCreate KeyAction = "Create"
+	KeyActionUpdate KeyAction = "Update"
+	KeyActionSkip   KeyAction = "Skip"
+)
+
+// KeyRestoreStatus reports the keys of the backup under the restored prefixes by
+// what the restore did with them.
+type KeyRestoreStatus struct {
+	// Created is the number of keys that didn't exist in the cluster.
+	Created int `json:"created"`
+	// Updated is the number of keys whose value in the cluster was overwritten.
+	Updated int `json:"updated"`
+	// Skipped is the number of keys left as they are by conflictPolicy "skip-existing".
+	Skipped int `json:"skipped"`
+	// Unchanged is the number of keys with the same value in the cluster and the backup.
+	Unchanged int `json:"unchanged"`
+	// Changes lists the first created, updated or skipped keys, in key order.
+	Changes []KeyChange `json:"changes,omitempty"`
+	// ChangesTruncated indicates if Changes doesn't list all the changed keys.
+	ChangesTruncated bool `json:"changesTruncated,omitempty"`
+}
+
+type KeyChange struct {
+	Key    string    `json:"key"`
+	Action KeyAction `json:"action"`
 }
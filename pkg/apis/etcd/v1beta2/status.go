@@ -16,6 +16,7 @@ package v1beta2
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -25,16 +26,19 @@ type ClusterPhase string
 type ClusterConditionType string
 
 const (
-	ClusterPhaseNone     ClusterPhase = ""
-	ClusterPhaseCreating              = "Creating"
-	ClusterPhaseRunning               = "Running"
-	ClusterPhaseFailed                = "Failed"
+	ClusterPhaseNone       ClusterPhase = ""
+	ClusterPhaseCreating                = "Creating"
+	ClusterPhaseRunning                 = "Running"
+	ClusterPhaseFailed                  = "Failed"
+	ClusterPhaseHibernated              = "Hibernated"
 
 	// See ./doc/user/conditions_and_events.md
-	ClusterConditionAvailable  ClusterConditionType = "Available"
-	ClusterConditionRecovering                      = "Recovering"
-	ClusterConditionScaling                         = "Scaling"
-	ClusterConditionUpgrading                       = "Upgrading"
+	ClusterConditionAvailable        ClusterConditionType = "Available"
+	ClusterConditionRecovering                            = "Recovering"
+	ClusterConditionScaling                               = "Scaling"
+	ClusterConditionUpgrading                             = "Upgrading"
+	ClusterConditionUpgradeFailed                         = "UpgradeFailed"
+	ClusterConditionExpandingVolumes                      = "ExpandingVolumes"
 )
 
 type ClusterStatus struct {
@@ -65,6 +69,68 @@ type ClusterStatus struct {
 	// TargetVersion is the version the cluster upgrading to.
 	// If the cluster is not upgrading, TargetVersion is empty.
 	TargetVersion string `json:"targetVersion"`
+
+	// AuthEnabled is true once the operator has enabled etcd authentication.
+	AuthEnabled bool `json:"authEnabled,omitempty"`
+
+	// PreUpgradeBackup is the backup the operator saved before the last upgrade
+	// of the cluster.
+	PreUpgradeBackup *PreUpgradeBackupStatus `json:"preUpgradeBackup,omitempty"`
+
+	// MemberUpgrade is the member the operator has upgraded last and waits for
+	// to become healthy before upgrading the next one.
+	MemberUpgrade *MemberUpgradeStatus `json:"memberUpgrade,omitempty"`
+
+	// Hibernation describes the hibernated cluster. It is only set while the
+	// cluster is hibernated.
+	Hibernation *HibernationStatus `json:"hibernation,omitempty"`
+
+	// Migration describes the migration from the external cluster of
+	// spec.migrateFrom. It is only set until the migration completes.
+	Migration *MigrationStatus `json:"migration,omitempty"`
+}
+
+// MigrationStatus describes the progress of a migration from an external etcd cluster.
+type MigrationStatus struct {
+	// ExternalMembers are the names of the external members that have not been removed yet.
+	ExternalMembers []string `json:"externalMembers"`
+}
+
+// HibernationStatus records what the operator needs to resume a hibernated cluster.
+type HibernationStatus struct {
+	// Members are the names of the members whose PVCs are kept while the cluster is hibernated.
+	Members []string `json:"members"`
+	// BackupPath is the full path of the backup saved before the cluster hibernated.
+	// It is empty if the cluster has no backup policy.
+	BackupPath string `json:"backupPath,omitempty"`
+	// Time is the time the cluster hibernated.
+	Time string `json:"time,omitempty"`
+}
+
+// MemberUpgradeStatus describes the upgrade of a single etcd member.
+type MemberUpgradeStatus struct {
+	// Name is the name of the upgraded member.
+	Name string `json:"name"`
+	// FromVersion is the version the member ran before the upgrade.
+	FromVersion string `json:"fromVersion"`
+	// ToVersion is the version the member is upgraded to.
+	ToVersion string `json:"toVersion"`
+	// StartTime is the time the member was upgraded.
+	StartTime string `json:"startTime"`
+}
+
+// PreUpgradeBackupStatus describes a backup saved before upgrading the cluster.
+type PreUpgradeBackupStatus struct {
+	// UpgradeVersion is the version the cluster is upgraded to after the backup.
+	UpgradeVersion string `json:"upgradeVersion"`
+	// Path is the full path of the backup, e.g. "<s3-bucket-name>/<path-to-backup-file>" for S3.
+	Path string `json:"path"`
+	// EtcdVersion is the version of the backup etcd server.
+	EtcdVersion string `json:"etcdVersion,omitempty"`
+	// EtcdRevision is the revision of etcd's KV store where the backup is performed on.
+	EtcdRevision int64 `json:"etcdRevision,omitempty"`
+	// CreationTime is the time the backup was saved.
+	CreationTime string `json:"creationTime,omitempty"`
 }
 
 // ClusterCondition represents one current condition of an etcd cluster.
@@ -145,10 +211,59 @@ func (cs *ClusterStatus) SetRecoveringCondition() {
 	cs.ClearCondition(ClusterConditionAvailable)
 }
 
-func (cs *ClusterStatus) SetUpgradingCondition(to string) {
-	// TODO: show x/y members has upgraded.
+// SetUpgradingCondition reports an upgrade to version "to" through the given
+// path of versions, where upgraded of total members run the first version of the path.
+func (cs *ClusterStatus) SetUpgradingCondition(to string, path []string, upgraded, total int) {
 	c := newClusterCondition(ClusterConditionUpgrading, v1.ConditionTrue,
-		"Cluster upgrading", "upgrading to "+to)
+		"Cluster upgrading", upgradingMsg(to, path, upgraded, total))
+	cs.setClusterCondition(*c)
+}
+
+// SetUpgradeRefusedCondition reports that the operator will not upgrade the
+// cluster to spec.version, e.g. because it is a downgrade.
+func (cs *ClusterStatus) SetUpgradeRefusedCondition(msg string) {
+	c := newClusterCondition(ClusterConditionUpgrading, v1.ConditionFalse,
+		"Unsupported version change", msg)
+	cs.setClusterCondition(*c)
+}
+
+// SetPreUpgradeBackupFailedCondition reports that the upgrade waits for the
+// pre-upgrade backup to succeed.
+func (cs *ClusterStatus) SetPreUpgradeBackupFailedCondition(msg string) {
+	c := newClusterCondition(ClusterConditionUpgrading, v1.ConditionFalse,
+		"Pre-upgrade backup failed", msg)
+	cs.setClusterCondition(*c)
+}
+
+// SetUpgradeFailedCondition reports that the member upgraded to version "to" did
+// not become healthy in time. The operator stops upgrading the cluster until
+// spec.version is changed.
+func (cs *ClusterStatus) SetUpgradeFailedCondition(member, to string, rolledBack bool, msg string) {
+	c := newClusterCondition(ClusterConditionUpgradeFailed, v1.ConditionTrue,
+		"Member not healthy", upgradeFailedMsg(member, to, rolledBack, msg))
+	cs.setClusterCondition(*c)
+
+	cs.ClearCondition(ClusterConditionUpgrading)
+}
+
+// IsUpgradeFailed returns true if the upgrade to the current target version failed.
+func (cs *ClusterStatus) IsUpgradeFailed() bool {
+	_, c := getClusterCondition(cs, ClusterConditionUpgradeFailed)
+	return c != nil && c.Status == v1.ConditionTrue
+}
+
+// SetExpandingVolumesCondition reports that the PVCs of the members are being
+// expanded to the given size, where expanded of total PVCs are done.
+func (cs *ClusterStatus) SetExpandingVolumesCondition(size string, expanded, total int) {
+	c := newClusterCondition(ClusterConditionExpandingVolumes, v1.ConditionTrue,
+		"Expanding volumes", fmt.Sprintf("expanding member volumes to %s: %d/%d volumes expanded", size, expanded, total))
+	cs.setClusterCondition(*c)
+}
+
+// SetVolumeExpansionFailedCondition reports that a member's PVC could not be expanded.
+func (cs *ClusterStatus) SetVolumeExpansionFailedCondition(msg string) {
+	c := newClusterCondition(ClusterConditionExpandingVolumes, v1.ConditionFalse,
+		"Volume expansion failed", msg)
 	cs.setClusterCondition(*c)
 }
 
@@ -203,3 +318,16 @@ func newClusterCondition(condType ClusterConditionType, status v1.ConditionStatu
 func scalingMsg(from, to int) string {
 	return fmt.Sprintf("Current cluster size: %d, desired cluster size: %d", from, to)
 }
+
+func upgradingMsg(to string, path []string, upgraded, total int) string {
+	return fmt.Sprintf("upgrading to %s through %s: %d/%d members upgraded to %s",
+		to, strings.Join(path, " -> "), upgraded, total, path[0])
+}
+
+func upgradeFailedMsg(member, to string, rolledBack bool, msg string) string {
+	m := fmt.Sprintf("member %s failed to upgrade to %s: %s", member, to, msg)
+	if rolledBack {
+		m += "; member rolled back"
+	}
+	return m
+}
@@ -22,6 +22,7 @@ package v1beta2
 
 import (
 	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	reflect "reflect"
@@ -32,6 +33,26 @@ import (
 // Deprecated: deepcopy registration will go away when static deepcopy is fully implemented.
 func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 	return []conversion.GeneratedDeepCopyFunc{
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*AuthPermission).DeepCopyInto(out.(*AuthPermission))
+			return nil
+		}, InType: reflect.TypeOf(&AuthPermission{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*AuthPolicy).DeepCopyInto(out.(*AuthPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&AuthPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*AuthRole).DeepCopyInto(out.(*AuthRole))
+			return nil
+		}, InType: reflect.TypeOf(&AuthRole{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*AuthUser).DeepCopyInto(out.(*AuthUser))
+			return nil
+		}, InType: reflect.TypeOf(&AuthUser{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*BackupPolicy).DeepCopyInto(out.(*BackupPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&BackupPolicy{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*BackupSource).DeepCopyInto(out.(*BackupSource))
 			return nil
@@ -44,6 +65,14 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*BackupStatus).DeepCopyInto(out.(*BackupStatus))
 			return nil
 		}, InType: reflect.TypeOf(&BackupStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*BackupVerification).DeepCopyInto(out.(*BackupVerification))
+			return nil
+		}, InType: reflect.TypeOf(&BackupVerification{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*ClientServicePolicy).DeepCopyInto(out.(*ClientServicePolicy))
+			return nil
+		}, InType: reflect.TypeOf(&ClientServicePolicy{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*ClusterCondition).DeepCopyInto(out.(*ClusterCondition))
 			return nil
@@ -76,6 +105,14 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*EtcdClusterRef).DeepCopyInto(out.(*EtcdClusterRef))
 			return nil
 		}, InType: reflect.TypeOf(&EtcdClusterRef{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*EtcdMirror).DeepCopyInto(out.(*EtcdMirror))
+			return nil
+		}, InType: reflect.TypeOf(&EtcdMirror{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*EtcdMirrorList).DeepCopyInto(out.(*EtcdMirrorList))
+			return nil
+		}, InType: reflect.TypeOf(&EtcdMirrorList{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*EtcdRestore).DeepCopyInto(out.(*EtcdRestore))
 			return nil
@@ -84,18 +121,90 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*EtcdRestoreList).DeepCopyInto(out.(*EtcdRestoreList))
 			return nil
 		}, InType: reflect.TypeOf(&EtcdRestoreList{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*HibernationStatus).DeepCopyInto(out.(*HibernationStatus))
+			return nil
+		}, InType: reflect.TypeOf(&HibernationStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*IncrementalBackupPolicy).DeepCopyInto(out.(*IncrementalBackupPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&IncrementalBackupPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*KeyChange).DeepCopyInto(out.(*KeyChange))
+			return nil
+		}, InType: reflect.TypeOf(&KeyChange{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*KeyRestorePolicy).DeepCopyInto(out.(*KeyRestorePolicy))
+			return nil
+		}, InType: reflect.TypeOf(&KeyRestorePolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*KeyRestoreStatus).DeepCopyInto(out.(*KeyRestoreStatus))
+			return nil
+		}, InType: reflect.TypeOf(&KeyRestoreStatus{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*MemberSecret).DeepCopyInto(out.(*MemberSecret))
 			return nil
 		}, InType: reflect.TypeOf(&MemberSecret{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*MemberUpgradeStatus).DeepCopyInto(out.(*MemberUpgradeStatus))
+			return nil
+		}, InType: reflect.TypeOf(&MemberUpgradeStatus{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*MembersStatus).DeepCopyInto(out.(*MembersStatus))
 			return nil
 		}, InType: reflect.TypeOf(&MembersStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*MetricsPolicy).DeepCopyInto(out.(*MetricsPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&MetricsPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*MigrationPolicy).DeepCopyInto(out.(*MigrationPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&MigrationPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*MigrationStatus).DeepCopyInto(out.(*MigrationStatus))
+			return nil
+		}, InType: reflect.TypeOf(&MigrationStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*MirrorEndpoint).DeepCopyInto(out.(*MirrorEndpoint))
+			return nil
+		}, InType: reflect.TypeOf(&MirrorEndpoint{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*MirrorSpec).DeepCopyInto(out.(*MirrorSpec))
+			return nil
+		}, InType: reflect.TypeOf(&MirrorSpec{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*MirrorStatus).DeepCopyInto(out.(*MirrorStatus))
+			return nil
+		}, InType: reflect.TypeOf(&MirrorStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*OperationCondition).DeepCopyInto(out.(*OperationCondition))
+			return nil
+		}, InType: reflect.TypeOf(&OperationCondition{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*OperationStatus).DeepCopyInto(out.(*OperationStatus))
+			return nil
+		}, InType: reflect.TypeOf(&OperationStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*PodDisruptionBudgetPolicy).DeepCopyInto(out.(*PodDisruptionBudgetPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&PodDisruptionBudgetPolicy{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*PodPolicy).DeepCopyInto(out.(*PodPolicy))
 			return nil
 		}, InType: reflect.TypeOf(&PodPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*PreUpgradeBackupStatus).DeepCopyInto(out.(*PreUpgradeBackupStatus))
+			return nil
+		}, InType: reflect.TypeOf(&PreUpgradeBackupStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*ProxyPolicy).DeepCopyInto(out.(*ProxyPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&ProxyPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*RestoreDryRunStatus).DeepCopyInto(out.(*RestoreDryRunStatus))
+			return nil
+		}, InType: reflect.TypeOf(&RestoreDryRunStatus{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*RestoreSource).DeepCopyInto(out.(*RestoreSource))
 			return nil
@@ -128,7 +237,124 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*TLSPolicy).DeepCopyInto(out.(*TLSPolicy))
 			return nil
 		}, InType: reflect.TypeOf(&TLSPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*UpgradePolicy).DeepCopyInto(out.(*UpgradePolicy))
+			return nil
+		}, InType: reflect.TypeOf(&UpgradePolicy{})},
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthPermission) DeepCopyInto(out *AuthPermission) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthPermission.
+func (in *AuthPermission) DeepCopy() *AuthPermission {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthPermission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthPolicy) DeepCopyInto(out *AuthPolicy) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]AuthRole, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]AuthUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthPolicy.
+func (in *AuthPolicy) DeepCopy() *AuthPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthRole) DeepCopyInto(out *AuthRole) {
+	*out = *in
+	if in.Permissions != nil {
+		in, out := &in.Permissions, &out.Permissions
+		*out = make([]AuthPermission, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthRole.
+func (in *AuthRole) DeepCopy() *AuthRole {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthUser) DeepCopyInto(out *AuthUser) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthUser.
+func (in *AuthUser) DeepCopy() *AuthUser {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupPolicy) DeepCopyInto(out *BackupPolicy) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(S3BackupSource)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupPolicy.
+func (in *BackupPolicy) DeepCopy() *BackupPolicy {
+	if in == nil {
+		return nil
 	}
+	out := new(BackupPolicy)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -165,6 +391,15 @@ func (in *BackupSpec) DeepCopyInto(out *BackupSpec) {
 		copy(*out, *in)
 	}
 	in.BackupSource.DeepCopyInto(&out.BackupSource)
+	if in.Incremental != nil {
+		in, out := &in.Incremental, &out.Incremental
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(IncrementalBackupPolicy)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -181,6 +416,21 @@ func (in *BackupSpec) DeepCopy() *BackupSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 	*out = *in
+	in.OperationStatus.DeepCopyInto(&out.OperationStatus)
+	if in.ChainEtcdEndpoints != nil {
+		in, out := &in.ChainEtcdEndpoints, &out.ChainEtcdEndpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupVerification)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -194,6 +444,51 @@ func (in *BackupStatus) DeepCopy() *BackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVerification) DeepCopyInto(out *BackupVerification) {
+	*out = *in
+	in.VerifyTime.DeepCopyInto(&out.VerifyTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVerification.
+func (in *BackupVerification) DeepCopy() *BackupVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientServicePolicy) DeepCopyInto(out *ClientServicePolicy) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientServicePolicy.
+func (in *ClientServicePolicy) DeepCopy() *ClientServicePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientServicePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterCondition) DeepCopyInto(out *ClusterCondition) {
 	*out = *in
@@ -231,6 +526,15 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 			**out = **in
 		}
 	}
+	if in.MigrateFrom != nil {
+		in, out := &in.MigrateFrom, &out.MigrateFrom
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(MigrationPolicy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	if in.TLS != nil {
 		in, out := &in.TLS, &out.TLS
 		if *in == nil {
@@ -240,6 +544,69 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(AuthPolicy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.BackupPolicy != nil {
+		in, out := &in.BackupPolicy, &out.BackupPolicy
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupPolicy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(UpgradePolicy)
+			**out = **in
+		}
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PodDisruptionBudgetPolicy)
+			**out = **in
+		}
+	}
+	if in.ClientService != nil {
+		in, out := &in.ClientService, &out.ClientService
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ClientServicePolicy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ProxyPolicy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(MetricsPolicy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -261,263 +628,667 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 		*out = make([]ClusterCondition, len(*in))
 		copy(*out, *in)
 	}
-	in.Members.DeepCopyInto(&out.Members)
+	in.Members.DeepCopyInto(&out.Members)
+	if in.PreUpgradeBackup != nil {
+		in, out := &in.PreUpgradeBackup, &out.PreUpgradeBackup
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PreUpgradeBackupStatus)
+			**out = **in
+		}
+	}
+	if in.MemberUpgrade != nil {
+		in, out := &in.MemberUpgrade, &out.MemberUpgrade
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(MemberUpgradeStatus)
+			**out = **in
+		}
+	}
+	if in.Hibernation != nil {
+		in, out := &in.Hibernation, &out.Hibernation
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(HibernationStatus)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(MigrationStatus)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
+func (in *ClusterStatus) DeepCopy() *ClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdBackup) DeepCopyInto(out *EtcdBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackup.
+func (in *EtcdBackup) DeepCopy() *EtcdBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdBackupList) DeepCopyInto(out *EtcdBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EtcdBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackupList.
+func (in *EtcdBackupList) DeepCopy() *EtcdBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdCluster) DeepCopyInto(out *EtcdCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdCluster.
+func (in *EtcdCluster) DeepCopy() *EtcdCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdCluster) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdClusterList) DeepCopyInto(out *EtcdClusterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EtcdCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterList.
+func (in *EtcdClusterList) DeepCopy() *EtcdClusterList {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdClusterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdClusterRef) DeepCopyInto(out *EtcdClusterRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterRef.
+func (in *EtcdClusterRef) DeepCopy() *EtcdClusterRef {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdClusterRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdMirror) DeepCopyInto(out *EtcdMirror) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdMirror.
+func (in *EtcdMirror) DeepCopy() *EtcdMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdMirror) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdMirrorList) DeepCopyInto(out *EtcdMirrorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EtcdMirror, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdMirrorList.
+func (in *EtcdMirrorList) DeepCopy() *EtcdMirrorList {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdMirrorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdMirrorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRestore) DeepCopyInto(out *EtcdRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRestore.
+func (in *EtcdRestore) DeepCopy() *EtcdRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRestoreList) DeepCopyInto(out *EtcdRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EtcdRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRestoreList.
+func (in *EtcdRestoreList) DeepCopy() *EtcdRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HibernationStatus) DeepCopyInto(out *HibernationStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HibernationStatus.
+func (in *HibernationStatus) DeepCopy() *HibernationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HibernationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IncrementalBackupPolicy) DeepCopyInto(out *IncrementalBackupPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IncrementalBackupPolicy.
+func (in *IncrementalBackupPolicy) DeepCopy() *IncrementalBackupPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(IncrementalBackupPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyChange) DeepCopyInto(out *KeyChange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyChange.
+func (in *KeyChange) DeepCopy() *KeyChange {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyRestorePolicy) DeepCopyInto(out *KeyRestorePolicy) {
+	*out = *in
+	if in.Prefixes != nil {
+		in, out := &in.Prefixes, &out.Prefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRestorePolicy.
+func (in *KeyRestorePolicy) DeepCopy() *KeyRestorePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyRestorePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyRestoreStatus) DeepCopyInto(out *KeyRestoreStatus) {
+	*out = *in
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]KeyChange, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRestoreStatus.
+func (in *KeyRestoreStatus) DeepCopy() *KeyRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberSecret) DeepCopyInto(out *MemberSecret) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberSecret.
+func (in *MemberSecret) DeepCopy() *MemberSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberUpgradeStatus) DeepCopyInto(out *MemberUpgradeStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberUpgradeStatus.
+func (in *MemberUpgradeStatus) DeepCopy() *MemberUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MembersStatus) DeepCopyInto(out *MembersStatus) {
+	*out = *in
+	if in.Ready != nil {
+		in, out := &in.Ready, &out.Ready
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unready != nil {
+		in, out := &in.Unready, &out.Unready
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
-func (in *ClusterStatus) DeepCopy() *ClusterStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MembersStatus.
+func (in *MembersStatus) DeepCopy() *MembersStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterStatus)
+	out := new(MembersStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EtcdBackup) DeepCopyInto(out *EtcdBackup) {
+func (in *MetricsPolicy) DeepCopyInto(out *MetricsPolicy) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	if in.ServiceMonitorLabels != nil {
+		in, out := &in.ServiceMonitorLabels, &out.ServiceMonitorLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackup.
-func (in *EtcdBackup) DeepCopy() *EtcdBackup {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsPolicy.
+func (in *MetricsPolicy) DeepCopy() *MetricsPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(EtcdBackup)
+	out := new(MetricsPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *EtcdBackup) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	} else {
-		return nil
-	}
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EtcdBackupList) DeepCopyInto(out *EtcdBackupList) {
+func (in *MigrationPolicy) DeepCopyInto(out *MigrationPolicy) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	out.ListMeta = in.ListMeta
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]EtcdBackup, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.ClientEndpoints != nil {
+		in, out := &in.ClientEndpoints, &out.ClientEndpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackupList.
-func (in *EtcdBackupList) DeepCopy() *EtcdBackupList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationPolicy.
+func (in *MigrationPolicy) DeepCopy() *MigrationPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(EtcdBackupList)
+	out := new(MigrationPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *EtcdBackupList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	} else {
-		return nil
-	}
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EtcdCluster) DeepCopyInto(out *EtcdCluster) {
+func (in *MigrationStatus) DeepCopyInto(out *MigrationStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.ExternalMembers != nil {
+		in, out := &in.ExternalMembers, &out.ExternalMembers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdCluster.
-func (in *EtcdCluster) DeepCopy() *EtcdCluster {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationStatus.
+func (in *MigrationStatus) DeepCopy() *MigrationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(EtcdCluster)
+	out := new(MigrationStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *EtcdCluster) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	} else {
-		return nil
-	}
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EtcdClusterList) DeepCopyInto(out *EtcdClusterList) {
+func (in *MirrorEndpoint) DeepCopyInto(out *MirrorEndpoint) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	out.ListMeta = in.ListMeta
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]EtcdCluster, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.EtcdEndpoints != nil {
+		in, out := &in.EtcdEndpoints, &out.EtcdEndpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterList.
-func (in *EtcdClusterList) DeepCopy() *EtcdClusterList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorEndpoint.
+func (in *MirrorEndpoint) DeepCopy() *MirrorEndpoint {
 	if in == nil {
 		return nil
 	}
-	out := new(EtcdClusterList)
+	out := new(MirrorEndpoint)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *EtcdClusterList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	} else {
-		return nil
-	}
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EtcdClusterRef) DeepCopyInto(out *EtcdClusterRef) {
+func (in *MirrorSpec) DeepCopyInto(out *MirrorSpec) {
 	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+	in.Destination.DeepCopyInto(&out.Destination)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterRef.
-func (in *EtcdClusterRef) DeepCopy() *EtcdClusterRef {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorSpec.
+func (in *MirrorSpec) DeepCopy() *MirrorSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(EtcdClusterRef)
+	out := new(MirrorSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EtcdRestore) DeepCopyInto(out *EtcdRestore) {
+func (in *MirrorStatus) DeepCopyInto(out *MirrorStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRestore.
-func (in *EtcdRestore) DeepCopy() *EtcdRestore {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorStatus.
+func (in *MirrorStatus) DeepCopy() *MirrorStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(EtcdRestore)
+	out := new(MirrorStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *EtcdRestore) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	} else {
-		return nil
-	}
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EtcdRestoreList) DeepCopyInto(out *EtcdRestoreList) {
+func (in *OperationCondition) DeepCopyInto(out *OperationCondition) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	out.ListMeta = in.ListMeta
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]EtcdRestore, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRestoreList.
-func (in *EtcdRestoreList) DeepCopy() *EtcdRestoreList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationCondition.
+func (in *OperationCondition) DeepCopy() *OperationCondition {
 	if in == nil {
 		return nil
 	}
-	out := new(EtcdRestoreList)
+	out := new(OperationCondition)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *EtcdRestoreList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	} else {
-		return nil
-	}
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MemberSecret) DeepCopyInto(out *MemberSecret) {
+func (in *OperationStatus) DeepCopyInto(out *OperationStatus) {
 	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]OperationCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberSecret.
-func (in *MemberSecret) DeepCopy() *MemberSecret {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationStatus.
+func (in *OperationStatus) DeepCopy() *OperationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MemberSecret)
+	out := new(OperationStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MembersStatus) DeepCopyInto(out *MembersStatus) {
+func (in *PodDisruptionBudgetPolicy) DeepCopyInto(out *PodDisruptionBudgetPolicy) {
 	*out = *in
-	if in.Ready != nil {
-		in, out := &in.Ready, &out.Ready
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.Unready != nil {
-		in, out := &in.Unready, &out.Unready
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MembersStatus.
-func (in *MembersStatus) DeepCopy() *MembersStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudgetPolicy.
+func (in *PodDisruptionBudgetPolicy) DeepCopy() *PodDisruptionBudgetPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(MembersStatus)
+	out := new(PodDisruptionBudgetPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -585,6 +1356,60 @@ func (in *PodPolicy) DeepCopy() *PodPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreUpgradeBackupStatus) DeepCopyInto(out *PreUpgradeBackupStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreUpgradeBackupStatus.
+func (in *PreUpgradeBackupStatus) DeepCopy() *PreUpgradeBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreUpgradeBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyPolicy) DeepCopyInto(out *ProxyPolicy) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyPolicy.
+func (in *ProxyPolicy) DeepCopy() *ProxyPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreDryRunStatus) DeepCopyInto(out *RestoreDryRunStatus) {
+	*out = *in
+	if in.DeletedResources != nil {
+		in, out := &in.DeletedResources, &out.DeletedResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreDryRunStatus.
+func (in *RestoreDryRunStatus) DeepCopy() *RestoreDryRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreDryRunStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestoreSource) DeepCopyInto(out *RestoreSource) {
 	*out = *in
@@ -615,6 +1440,23 @@ func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
 	*out = *in
 	in.RestoreSource.DeepCopyInto(&out.RestoreSource)
 	out.EtcdCluster = in.EtcdCluster
+	if in.TargetTime != nil {
+		in, out := &in.TargetTime, &out.TargetTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = (*in).DeepCopy()
+		}
+	}
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(KeyRestorePolicy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -631,6 +1473,25 @@ func (in *RestoreSpec) DeepCopy() *RestoreSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
 	*out = *in
+	in.OperationStatus.DeepCopyInto(&out.OperationStatus)
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(KeyRestoreStatus)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RestoreDryRunStatus)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -741,3 +1602,19 @@ func (in *TLSPolicy) DeepCopy() *TLSPolicy {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradePolicy) DeepCopyInto(out *UpgradePolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradePolicy.
+func (in *UpgradePolicy) DeepCopy() *UpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -18,9 +18,13 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"path"
 
+	"github.com/coreos/etcd-operator/pkg/backup/util"
 	"github.com/coreos/etcd-operator/pkg/backup/writer"
 	"github.com/coreos/etcd-operator/pkg/util/constants"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/sirupsen/logrus"
@@ -34,73 +38,94 @@ type BackupManager struct {
 	endpoints     []string
 	namespace     string
 	etcdTLSConfig *tls.Config
+	etcdCreds     *etcdutil.Credentials
 
 	bw writer.Writer
 }
 
 // NewBackupManagerFromWriter creates a BackupManager with backup writer.
-func NewBackupManagerFromWriter(kubecli kubernetes.Interface, bw writer.Writer, tc *tls.Config, endpoints []string, namespace string) *BackupManager {
+// creds must be set if the etcd cluster has authentication enabled.
+func NewBackupManagerFromWriter(kubecli kubernetes.Interface, bw writer.Writer, tc *tls.Config, creds *etcdutil.Credentials, endpoints []string, namespace string) *BackupManager {
 	return &BackupManager{
 		kubecli:       kubecli,
 		endpoints:     endpoints,
 		namespace:     namespace,
 		etcdTLSConfig: tc,
+		etcdCreds:     creds,
 		bw:            bw,
 	}
 }
 
 // SaveSnap uses backup writer to save etcd snapshot to a specified S3 path
 // and returns backup etcd server's kv store revision and its version.
-func (bm *BackupManager) SaveSnap(s3Path string) (int64, string, error) {
+// The backup is traced as a child of the span in ctx.
+func (bm *BackupManager) SaveSnap(ctx context.Context, s3Path string) (int64, string, error) {
+	_, rev, etcdVersion, err := bm.saveSnap(ctx, func(int64, string) string { return s3Path })
+	return rev, etcdVersion, err
+}
+
+// SaveSnapUnderPrefix uses backup writer to save etcd snapshot under the given S3 prefix.
+// The backup is named after the etcd version and kv store revision of the backup etcd server.
+// It returns the full path of the backup, the revision and the version.
+func (bm *BackupManager) SaveSnapUnderPrefix(ctx context.Context, s3Prefix string) (string, int64, string, error) {
+	return bm.saveSnap(ctx, func(rev int64, etcdVersion string) string {
+		return path.Join(s3Prefix, util.MakeBackupName(etcdVersion, rev))
+	})
+}
+
+func (bm *BackupManager) saveSnap(ctx context.Context, pathFn func(rev int64, etcdVersion string) string) (p string, rev int64, etcdVersion string, err error) {
+	ctx, span := tracing.Start(ctx, "BackupManager.SaveSnap")
+	defer func() { span.End(err) }()
+
 	etcdcli, rev, err := bm.etcdClientWithMaxRevision()
 	if err != nil {
-		return 0, "", fmt.Errorf("create etcd client failed: %v", err)
+		return "", 0, "", fmt.Errorf("create etcd client failed: %v", err)
 	}
 	defer etcdcli.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
-	resp, err := etcdcli.Status(ctx, etcdcli.Endpoints()[0])
+	rctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	resp, err := etcdcli.Status(rctx, etcdcli.Endpoints()[0])
 	cancel()
 	if err != nil {
-		return 0, "", fmt.Errorf("failed to retrieve etcd version from the status call: %v", err)
+		return "", 0, "", fmt.Errorf("failed to retrieve etcd version from the status call: %v", err)
 	}
 
-	ctx, cancel = context.WithTimeout(context.Background(), constants.DefaultSnapshotTimeout)
+	rctx, cancel = context.WithTimeout(context.Background(), constants.DefaultSnapshotTimeout)
 	defer cancel() // Can't cancel() after Snapshot() because that will close the reader.
-	rc, err := etcdcli.Snapshot(ctx)
+	rc, err := etcdcli.Snapshot(rctx)
 	if err != nil {
-		return 0, "", fmt.Errorf("failed to receive snapshot (%v)", err)
+		return "", 0, "", fmt.Errorf("failed to receive snapshot (%v)", err)
 	}
 	defer rc.Close()
 
-	_, err = bm.bw.Write(s3Path, rc)
+	p = pathFn(rev, resp.Version)
+	_, wspan := tracing.Start(ctx, "BackupWriter.Write")
+	wspan.SetAttribute("backup.path", p)
+	_, err = bm.bw.Write(p, rc)
+	wspan.End(err)
 	if err != nil {
-		return 0, "", fmt.Errorf("failed to write snapshot (%v)", err)
+		return "", 0, "", fmt.Errorf("failed to write snapshot (%v)", err)
 	}
-	return rev, resp.Version, nil
+	return p, rev, resp.Version, nil
 }
 
 // etcdClientWithMaxRevision gets the etcd endpoint with the maximum kv store revision
 // and returns the etcd client of that member.
 func (bm *BackupManager) etcdClientWithMaxRevision() (*clientv3.Client, int64, error) {
-	etcdcli, rev, err := getClientWithMaxRev(bm.endpoints, bm.etcdTLSConfig)
+	etcdcli, rev, err := getClientWithMaxRev(bm.endpoints, bm.etcdTLSConfig, bm.etcdCreds)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get etcd client with maximum kv store revision: %v", err)
 	}
 	return etcdcli, rev, nil
 }
 
-func getClientWithMaxRev(endpoints []string, tc *tls.Config) (*clientv3.Client, int64, error) {
+func getClientWithMaxRev(endpoints []string, tc *tls.Config, creds *etcdutil.Credentials) (*clientv3.Client, int64, error) {
 	mapEps := make(map[string]*clientv3.Client)
 	var maxClient *clientv3.Client
 	maxRev := int64(0)
 	errors := make([]string, 0)
 	for _, endpoint := range endpoints {
-		cfg := clientv3.Config{
-			Endpoints:   []string{endpoint},
-			DialTimeout: constants.DefaultDialTimeout,
-			TLS:         tc,
-		}
+		cfg := etcdutil.NewClientConfig([]string{endpoint}, tc, creds)
 		etcdcli, err := clientv3.New(cfg)
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("failed to create etcd client for endpoint (%v): %v", endpoint, err))
@@ -14,7 +14,10 @@
 
 package backupapi
 
-import "path"
+import (
+	"path"
+	"time"
+)
 
 const (
 	APIV1 = "/v1"
@@ -28,3 +31,29 @@ const (
 func ToS3Prefix(s3Prefix, namespace, clusterName string) string {
 	return path.Join(s3Prefix, S3V1, namespace, clusterName)
 }
+
+// BackupInfo describes a snapshot saved under the S3V1 layout.
+type BackupInfo struct {
+	// Name is the file name of the snapshot.
+	Name string `json:"name"`
+	// Path is the full storage path of the snapshot, e.g. "<s3Bucket>/<key>".
+	Path string `json:"path"`
+	// Size is the size of the snapshot in bytes.
+	Size int64 `json:"size"`
+	// Revision is the etcd revision the snapshot was taken at.
+	Revision int64 `json:"revision"`
+	// EtcdVersion is the version of the etcd server the snapshot was taken from.
+	EtcdVersion string `json:"etcdVersion"`
+	// Timestamp is when the snapshot was saved.
+	Timestamp time.Time `json:"timestamp"`
+	// Checksum is the checksum the storage reports for the snapshot.
+	// For S3, it is the ETag of the object.
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// BackupList is the list of snapshots saved for a cluster, oldest revision first.
+type BackupList struct {
+	Namespace   string       `json:"namespace"`
+	ClusterName string       `json:"clusterName"`
+	Items       []BackupInfo `json:"items"`
+}
@@ -27,3 +27,28 @@ func BackupURLForRestore(scheme, host, restoreName string) *url.URL {
 		Path:   path.Join(APIV1, "backup", restoreName),
 	}
 }
+
+// Headers the catalog sets on the response to a HEAD request for a snapshot.
+const (
+	HeaderEtcdRevision = "X-Etcd-Revision"
+	HeaderEtcdVersion  = "X-Etcd-Version"
+	HeaderBackupSize   = "X-Backup-Size"
+)
+
+// BackupListURL returns the URL of the catalog of snapshots saved for the given cluster.
+func BackupListURL(scheme, host, namespace, clusterName string) *url.URL {
+	return &url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   path.Join(APIV1, "backups", namespace, clusterName),
+	}
+}
+
+// BackupInfoURL returns the URL of the metadata of the given snapshot of a cluster.
+func BackupInfoURL(scheme, host, namespace, clusterName, backupName string) *url.URL {
+	return &url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   path.Join(APIV1, "backups", namespace, clusterName, backupName),
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// KeySet is a set of etcd keys and their values.
+type KeySet map[string][]byte
+
+// HasAnyPrefix returns true if key starts with one of prefixes.
+func HasAnyPrefix(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadSnapshotKeys reads an etcd snapshot from r and returns the keys under prefixes
+// that exist at the revision of the snapshot, with their values.
+func ReadSnapshotKeys(r io.Reader, prefixes []string) (KeySet, error) {
+	p, err := saveSnapshotDB(r)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(p)
+
+	db, err := bolt.Open(p, 0400, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot database: %v", err)
+	}
+	defer db.Close()
+
+	ks := KeySet{}
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(keyBucketName)
+		if b == nil {
+			return fmt.Errorf("snapshot database has no key bucket")
+		}
+		// The key bucket holds every version of every key that was not compacted,
+		// keyed by revision, so the last version of a key is the one at the snapshot.
+		return b.ForEach(func(k, v []byte) error {
+			var kv mvccpb.KeyValue
+			if err := kv.Unmarshal(v); err != nil {
+				return fmt.Errorf("failed to decode key at revision %x: %v", k, err)
+			}
+			key := string(kv.Key)
+			if !HasAnyPrefix(key, prefixes) {
+				return nil
+			}
+			if isTombstone(k) {
+				delete(ks, key)
+			} else {
+				ks[key] = kv.Value
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot database: %v", err)
+	}
+	return ks, nil
+}
+
+// isTombstone returns true if the revision key marks the deletion of a key.
+// etcd appends 't' to the revision of a deletion.
+func isTombstone(revKey []byte) bool {
+	return len(revKey) == 18 && revKey[17] == 't'
+}
+
+// Apply applies the changes to keys under prefixes made after revision afterRev and
+// up to revision untilRev to the key set, in order, like ReplayChanges does to a cluster.
+// It returns the revision of the last change, or 0 if there was none.
+func (ks KeySet) Apply(changes []Change, prefixes []string, afterRev, untilRev int64) int64 {
+	var lastRev int64
+	for _, c := range changes {
+		if c.Revision <= afterRev {
+			continue
+		}
+		if c.Revision > untilRev {
+			break
+		}
+		lastRev = c.Revision
+		key := string(c.Key)
+		if !HasAnyPrefix(key, prefixes) {
+			continue
+		}
+		if c.Delete {
+			delete(ks, key)
+		} else {
+			ks[key] = c.Value
+		}
+	}
+	return lastRev
+}
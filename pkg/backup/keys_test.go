@@ -0,0 +1,87 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+func TestReadSnapshotKeys(t *testing.T) {
+	type put struct {
+		rev   int64
+		key   string
+		value string
+		del   bool
+	}
+	puts := []put{
+		{rev: 2, key: "/a/1", value: "v1"},
+		{rev: 3, key: "/a/2", value: "v2"},
+		{rev: 4, key: "/b/1", value: "v3"},
+		{rev: 5, key: "/a/1", value: "v4"},
+		{rev: 6, key: "/a/2", del: true},
+		{rev: 7, key: "/a/3", value: "v5"},
+	}
+	snap := newTestSnapshotDB(t, func(tx *bolt.Tx) error {
+		kb, err := tx.CreateBucket(keyBucketName)
+		if err != nil {
+			return err
+		}
+		for _, p := range puts {
+			k := revBytes(p.rev)
+			kv := &mvccpb.KeyValue{Key: []byte(p.key), Value: []byte(p.value), ModRevision: p.rev}
+			if p.del {
+				k = append(k, 't')
+				kv = &mvccpb.KeyValue{Key: []byte(p.key)}
+			}
+			v, err := kv.Marshal()
+			if err != nil {
+				return err
+			}
+			if err := kb.Put(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	ks, err := ReadSnapshotKeys(bytes.NewReader(snap), []string{"/a/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := KeySet{"/a/1": []byte("v4"), "/a/3": []byte("v5")}
+	if !reflect.DeepEqual(ks, want) {
+		t.Errorf("expect keys %q, get %q", want, ks)
+	}
+
+	changes := []Change{
+		{Revision: 7, Key: []byte("/a/3"), Value: []byte("v5")},
+		{Revision: 8, Key: []byte("/a/1"), Delete: true},
+		{Revision: 9, Key: []byte("/b/1"), Value: []byte("v6")},
+		{Revision: 10, Key: []byte("/a/4"), Value: []byte("v7")},
+		{Revision: 11, Key: []byte("/a/5"), Value: []byte("v8")},
+	}
+	if rev := ks.Apply(changes, []string{"/a/"}, 7, 10); rev != 10 {
+		t.Errorf("expect last revision 10, get %d", rev)
+	}
+	want = KeySet{"/a/3": []byte("v5"), "/a/4": []byte("v7")}
+	if !reflect.DeepEqual(ks, want) {
+		t.Errorf("expect keys %q after changes, get %q", want, ks)
+	}
+}
@@ -14,10 +14,27 @@
 
 package reader
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 // Reader defines required reader operations
 type Reader interface {
 	// Open opens up a backup file for reading.
 	Open(path string) (rc io.ReadCloser, err error)
+	// List returns the backup files whose paths start with prefix.
+	List(prefix string) ([]Object, error)
+}
+
+// Object describes a backup file.
+type Object struct {
+	// Path is the path of the file in the format Open takes.
+	Path string
+	// LastModified is when the file was last written.
+	LastModified time.Time
+	// Size is the size of the file in bytes.
+	Size int64
+	// Checksum is the checksum the storage reports for the file, if any.
+	Checksum string
 }
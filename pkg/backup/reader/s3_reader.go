@@ -17,6 +17,8 @@ package reader
 import (
 	"fmt"
 	"io"
+	"path"
+	"strings"
 
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 
@@ -52,3 +54,39 @@ func (s3r *s3Reader) Open(path string) (io.ReadCloser, error) {
 
 	return resp.Body, nil
 }
+
+// List lists the files under prefix where prefix must be in the format "<s3-bucket-name>/<key-prefix>".
+func (s3r *s3Reader) List(prefix string) ([]Object, error) {
+	toks := strings.SplitN(prefix, "/", 2)
+	if len(toks[0]) == 0 {
+		return nil, fmt.Errorf("invalid s3 prefix (%v)", prefix)
+	}
+	bucket, keyPrefix := toks[0], ""
+	if len(toks) == 2 {
+		keyPrefix = toks[1]
+	}
+
+	var objs []Object
+	err := s3r.s3.ListObjectsPages(&s3.ListObjectsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(keyPrefix),
+	}, func(page *s3.ListObjectsOutput, _ bool) bool {
+		for _, o := range page.Contents {
+			obj := Object{
+				Path: path.Join(bucket, aws.StringValue(o.Key)),
+				Size: aws.Int64Value(o.Size),
+				// S3 quotes the ETag.
+				Checksum: strings.Trim(aws.StringValue(o.ETag), `"`),
+			}
+			if o.LastModified != nil {
+				obj.LastModified = *o.LastModified
+			}
+			objs = append(objs, obj)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objs, nil
+}
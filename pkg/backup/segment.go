@@ -0,0 +1,131 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// Change is a change to a key saved in a segment of an incremental backup.
+// Leases are not saved: a key put with a lease is restored without it.
+type Change struct {
+	// Revision is the etcd revision the change was made at.
+	Revision int64 `json:"revision"`
+	// Delete is true if the key was deleted, and false if it was put.
+	Delete bool   `json:"delete,omitempty"`
+	Key    []byte `json:"key"`
+	Value  []byte `json:"value,omitempty"`
+	// Time is when the backup operator received the change, shortly after it was made.
+	// It is not set in segments saved by older backup operators.
+	Time *time.Time `json:"time,omitempty"`
+}
+
+// ChangesFromEvents converts watch events received at t to changes.
+func ChangesFromEvents(events []*clientv3.Event, t time.Time) []Change {
+	changes := make([]Change, 0, len(events))
+	for _, ev := range events {
+		c := Change{Revision: ev.Kv.ModRevision, Key: ev.Kv.Key, Time: &t}
+		if ev.Type == mvccpb.DELETE {
+			c.Delete = true
+		} else {
+			c.Value = ev.Kv.Value
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// ChangesUntil returns the leading changes received at or before t.
+// A change without a time ends them, since when it was made is unknown.
+func ChangesUntil(changes []Change, t time.Time) []Change {
+	for i, c := range changes {
+		if c.Time == nil || c.Time.After(t) {
+			return changes[:i]
+		}
+	}
+	return changes
+}
+
+// EncodeSegment writes changes to w, one JSON object per line.
+func EncodeSegment(w io.Writer, changes []Change) error {
+	enc := json.NewEncoder(w)
+	for i := range changes {
+		if err := enc.Encode(&changes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeSegment reads the changes written by EncodeSegment.
+func DecodeSegment(r io.Reader) ([]Change, error) {
+	var changes []Change
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var c Change
+		err := dec.Decode(&c)
+		if err == io.EOF {
+			return changes, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode segment: %v", err)
+		}
+		changes = append(changes, c)
+	}
+}
+
+// ReplayChanges applies the changes made after revision afterRev and up to revision untilRev, in order.
+// Changes made at the same revision are applied in one transaction, as etcd made them.
+// It returns the revision of the last replayed change, or 0 if none was replayed.
+func ReplayChanges(kv clientv3.KV, changes []Change, afterRev, untilRev int64) (int64, error) {
+	var lastRev int64
+	for i := 0; i < len(changes); {
+		rev := changes[i].Revision
+		if rev <= afterRev {
+			i++
+			continue
+		}
+		if rev > untilRev {
+			break
+		}
+		var ops []clientv3.Op
+		for ; i < len(changes) && changes[i].Revision == rev; i++ {
+			c := changes[i]
+			if c.Delete {
+				ops = append(ops, clientv3.OpDelete(string(c.Key)))
+			} else {
+				ops = append(ops, clientv3.OpPut(string(c.Key), string(c.Value)))
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+		_, err := kv.Txn(ctx).Then(ops...).Commit()
+		cancel()
+		if err != nil {
+			return lastRev, fmt.Errorf("failed to replay changes of revision %d: %v", rev, err)
+		}
+		lastRev = rev
+	}
+	return lastRev, nil
+}
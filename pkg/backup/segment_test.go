@@ -0,0 +1,151 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+func TestSegmentRoundTrip(t *testing.T) {
+	events := []*clientv3.Event{
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("/a"), Value: []byte("1"), ModRevision: 5}},
+		{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte("/b"), ModRevision: 6}},
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("/c"), Value: []byte{0, 0xff, '\n'}, ModRevision: 6}},
+	}
+	t0 := time.Date(2018, 3, 5, 14, 0, 0, 0, time.UTC)
+	changes := ChangesFromEvents(events, t0)
+	want := []Change{
+		{Revision: 5, Key: []byte("/a"), Value: []byte("1"), Time: &t0},
+		{Revision: 6, Delete: true, Key: []byte("/b"), Time: &t0},
+		{Revision: 6, Key: []byte("/c"), Value: []byte{0, 0xff, '\n'}, Time: &t0},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("expect changes %+v, get %+v", want, changes)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeSegment(&buf, changes); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeSegment(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("expect decoded changes %+v, get %+v", want, decoded)
+	}
+}
+
+func TestChangesUntil(t *testing.T) {
+	t0 := time.Date(2018, 3, 5, 14, 0, 0, 0, time.UTC)
+	at := func(sec int) *time.Time {
+		t := t0.Add(time.Duration(sec) * time.Second)
+		return &t
+	}
+	changes := []Change{
+		{Revision: 5, Time: at(0)},
+		{Revision: 6, Time: at(10)},
+		{Revision: 7, Time: at(20)},
+	}
+	legacy := []Change{{Revision: 5}, {Revision: 6}}
+
+	tests := []struct {
+		changes []Change
+		until   time.Time
+		wRevs   []int64
+	}{
+		{changes: changes, until: t0.Add(-time.Second)},
+		{changes: changes, until: t0, wRevs: []int64{5}},
+		{changes: changes, until: t0.Add(15 * time.Second), wRevs: []int64{5, 6}},
+		{changes: changes, until: t0.Add(time.Minute), wRevs: []int64{5, 6, 7}},
+		{changes: legacy, until: t0.Add(time.Minute)},
+	}
+	for i, tt := range tests {
+		var revs []int64
+		for _, c := range ChangesUntil(tt.changes, tt.until) {
+			revs = append(revs, c.Revision)
+		}
+		if !reflect.DeepEqual(revs, tt.wRevs) {
+			t.Errorf("#%d: expect revisions %v, get %v", i, tt.wRevs, revs)
+		}
+	}
+}
+
+// fakeKV records the number of operations of each committed transaction.
+type fakeKV struct {
+	clientv3.KV
+	txns []int
+}
+
+func (kv *fakeKV) Txn(context.Context) clientv3.Txn {
+	return &fakeTxn{kv: kv}
+}
+
+type fakeTxn struct {
+	kv  *fakeKV
+	ops []clientv3.Op
+}
+
+func (txn *fakeTxn) If(...clientv3.Cmp) clientv3.Txn  { return txn }
+func (txn *fakeTxn) Else(...clientv3.Op) clientv3.Txn { return txn }
+
+func (txn *fakeTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	txn.ops = append(txn.ops, ops...)
+	return txn
+}
+
+func (txn *fakeTxn) Commit() (*clientv3.TxnResponse, error) {
+	txn.kv.txns = append(txn.kv.txns, len(txn.ops))
+	return &clientv3.TxnResponse{}, nil
+}
+
+func TestReplayChanges(t *testing.T) {
+	changes := []Change{
+		{Revision: 4, Key: []byte("/a"), Value: []byte("0")},
+		{Revision: 5, Key: []byte("/a"), Value: []byte("1")},
+		{Revision: 6, Delete: true, Key: []byte("/b")},
+		{Revision: 6, Key: []byte("/c"), Value: []byte("2")},
+		{Revision: 7, Key: []byte("/d"), Value: []byte("3")},
+	}
+	tests := []struct {
+		afterRev, untilRev int64
+		wTxns              []int
+		wRev               int64
+	}{
+		{afterRev: 0, untilRev: 10, wTxns: []int{1, 1, 2, 1}, wRev: 7},
+		{afterRev: 4, untilRev: 6, wTxns: []int{1, 2}, wRev: 6},
+		{afterRev: 7, untilRev: 10, wRev: 0},
+	}
+	for i, tt := range tests {
+		kv := &fakeKV{}
+		rev, err := ReplayChanges(kv, changes, tt.afterRev, tt.untilRev)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if rev != tt.wRev {
+			t.Errorf("#%d: expect last revision %d, get %d", i, tt.wRev, rev)
+		}
+		if !reflect.DeepEqual(kv.txns, tt.wTxns) {
+			t.Errorf("#%d: expect transactions %v, get %v", i, tt.wTxns, kv.txns)
+		}
+	}
+}
@@ -16,4 +16,6 @@ package util
 
 const (
 	BackupFilenameSuffix = "etcd.backup"
+	// SegmentFilenameSuffix is the suffix of the files holding the changes saved by an incremental backup.
+	SegmentFilenameSuffix = "etcd.segment"
 )
@@ -16,6 +16,8 @@ package util
 
 import (
 	"fmt"
+	"path"
+	"strconv"
 	"strings"
 )
 
@@ -23,6 +25,44 @@ func MakeBackupName(ver string, rev int64) string {
 	return fmt.Sprintf("%s_%016x_%s", ver, rev, BackupFilenameSuffix)
 }
 
+// ParseBackupName returns the etcd version and revision of a backup named by MakeBackupName.
+// Any directories in name are ignored.
+func ParseBackupName(name string) (string, int64, error) {
+	toks := strings.Split(path.Base(name), "_")
+	if len(toks) != 3 || len(toks[0]) == 0 || toks[2] != BackupFilenameSuffix {
+		return "", 0, fmt.Errorf("invalid backup name (%v)", name)
+	}
+	rev, err := strconv.ParseInt(toks[1], 16, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid revision in backup name (%v): %v", name, err)
+	}
+	return toks[0], rev, nil
+}
+
+// MakeSegmentName returns the name of a segment holding the changes from
+// revision startRev to endRev, both included.
+func MakeSegmentName(startRev, endRev int64) string {
+	return fmt.Sprintf("%016x_%016x_%s", startRev, endRev, SegmentFilenameSuffix)
+}
+
+// ParseSegmentName returns the first and last revision of a segment named by MakeSegmentName.
+// Any directories in name are ignored.
+func ParseSegmentName(name string) (int64, int64, error) {
+	toks := strings.Split(path.Base(name), "_")
+	if len(toks) != 3 || toks[2] != SegmentFilenameSuffix {
+		return 0, 0, fmt.Errorf("invalid segment name (%v)", name)
+	}
+	startRev, err := strconv.ParseInt(toks[0], 16, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start revision in segment name (%v): %v", name, err)
+	}
+	endRev, err := strconv.ParseInt(toks[1], 16, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end revision in segment name (%v): %v", name, err)
+	}
+	return startRev, endRev, nil
+}
+
 // ParseBucketAndKey parses the path to return the s3 bucket name and key(path in the bucket)
 // returns error if path is not in the format <s3-bucket-name>/<key>
 func ParseBucketAndKey(path string) (string, string, error) {
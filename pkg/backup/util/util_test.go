@@ -0,0 +1,96 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import "testing"
+
+func TestParseBackupName(t *testing.T) {
+	tests := []struct {
+		name string
+		wVer string
+		wRev int64
+		wErr bool
+	}{{
+		name: MakeBackupName("3.2.13", 42),
+		wVer: "3.2.13",
+		wRev: 42,
+	}, {
+		name: "v1/default/example/" + MakeBackupName("3.3.0", 0x1234),
+		wVer: "3.3.0",
+		wRev: 0x1234,
+	}, {
+		name: "etcd.backup",
+		wErr: true,
+	}, {
+		name: "3.2.13_xyz_etcd.backup",
+		wErr: true,
+	}, {
+		name: "3.2.13_000000000000002a_etcd.segment",
+		wErr: true,
+	}}
+
+	for i, tt := range tests {
+		ver, rev, err := ParseBackupName(tt.name)
+		if tt.wErr {
+			if err == nil {
+				t.Errorf("#%d: expect error, get nil", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: expect no error, get %v", i, err)
+			continue
+		}
+		if ver != tt.wVer || rev != tt.wRev {
+			t.Errorf("#%d: expect (%s, %d), get (%s, %d)", i, tt.wVer, tt.wRev, ver, rev)
+		}
+	}
+}
+
+func TestParseSegmentName(t *testing.T) {
+	tests := []struct {
+		name   string
+		wStart int64
+		wEnd   int64
+		wErr   bool
+	}{{
+		name:   "bucket/v1/default/example/" + MakeSegmentName(101, 0x1ff),
+		wStart: 101,
+		wEnd:   0x1ff,
+	}, {
+		name: MakeBackupName("3.2.13", 42),
+		wErr: true,
+	}, {
+		name: "0000000000000001_xyz_etcd.segment",
+		wErr: true,
+	}}
+
+	for i, tt := range tests {
+		start, end, err := ParseSegmentName(tt.name)
+		if tt.wErr {
+			if err == nil {
+				t.Errorf("#%d: expect error, get nil", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: expect no error, get %v", i, err)
+			continue
+		}
+		if start != tt.wStart || end != tt.wEnd {
+			t.Errorf("#%d: expect (%d, %d), get (%d, %d)", i, tt.wStart, tt.wEnd, start, end)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/boltdb/bolt"
+)
+
+var (
+	keyBucketName          = []byte("key")
+	metaBucketName         = []byte("meta")
+	finishedCompactKeyName = []byte("finishedCompactRev")
+)
+
+// SnapshotStatus is the status of an etcd snapshot, as `etcdctl snapshot status` reports it.
+type SnapshotStatus struct {
+	// Hash is the hash of the keys and values of the backend database.
+	Hash uint32
+	// Revision is the kv store revision the snapshot was taken at.
+	Revision int64
+	// TotalKey is the number of keys in the backend database, including etcd's own.
+	TotalKey int
+	// TotalSize is the size of the backend database in bytes.
+	TotalSize int64
+}
+
+// VerifySnapshot reads an etcd snapshot from r and checks that etcd can restore it:
+// the integrity hash appended to the snapshot must match, and the backend database
+// must open and be readable to its last key. It returns the status of the snapshot.
+func VerifySnapshot(r io.Reader) (*SnapshotStatus, error) {
+	p, err := saveSnapshotDB(r)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(p)
+	return snapshotStatus(p)
+}
+
+// saveSnapshotDB saves the backend database of the etcd snapshot read from r
+// to a temporary file, after checking its integrity hash. It returns the path
+// of the file, which the caller must remove.
+func saveSnapshotDB(r io.Reader) (string, error) {
+	f, err := ioutil.TempFile("", "etcd-snapshot")
+	if err != nil {
+		return "", err
+	}
+	err = func() error {
+		defer f.Close()
+		size, err := io.Copy(f, r)
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot: %v", err)
+		}
+		if err := checkSnapshotHash(f, size); err != nil {
+			return err
+		}
+		// Remove the hash, as etcd does when it restores the snapshot.
+		if err := f.Truncate(size - sha256.Size); err != nil {
+			return err
+		}
+		return f.Close()
+	}()
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// checkSnapshotHash checks the sha256 hash that etcd appends to a snapshot of the given size.
+func checkSnapshotHash(f *os.File, size int64) error {
+	// The database is made of pages, so a snapshot with the hash appended is
+	// sha256.Size bytes longer than a multiple of the smallest page size.
+	if size%512 != sha256.Size {
+		return errors.New("snapshot has no integrity hash")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, size-sha256.Size); err != nil {
+		return err
+	}
+	want := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(f, want); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), want) {
+		return errors.New("snapshot integrity hash mismatch")
+	}
+	return nil
+}
+
+// snapshotStatus computes the status of the backend database at path the way etcdctl does.
+func snapshotStatus(path string) (*SnapshotStatus, error) {
+	db, err := bolt.Open(path, 0400, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot database: %v", err)
+	}
+	defer db.Close()
+
+	// Like etcd's kv store, a snapshot without changes is at revision 1.
+	st := &SnapshotStatus{Revision: 1}
+	h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	hasKeyBucket := false
+	err = db.View(func(tx *bolt.Tx) error {
+		st.TotalSize = tx.Size()
+		c := tx.Cursor()
+		for name, _ := c.First(); name != nil; name, _ = c.Next() {
+			b := tx.Bucket(name)
+			if b == nil {
+				return fmt.Errorf("cannot get bucket (%s)", name)
+			}
+			h.Write(name)
+			if bytes.Equal(name, keyBucketName) {
+				hasKeyBucket = true
+			}
+			isKeyBucket := bytes.Equal(name, keyBucketName)
+			isMetaBucket := bytes.Equal(name, metaBucketName)
+			err := b.ForEach(func(k, v []byte) error {
+				h.Write(k)
+				h.Write(v)
+				// Revisions are saved as 8 bytes of the main revision in big endian,
+				// followed by '_' and the sub revision.
+				if isKeyBucket && len(k) >= 8 {
+					st.Revision = maxRevision(st.Revision, k)
+				}
+				// A compaction may have removed the tombstone of the last revision.
+				if isMetaBucket && bytes.Equal(k, finishedCompactKeyName) && len(v) >= 8 {
+					st.Revision = maxRevision(st.Revision, v)
+				}
+				st.TotalKey++
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot database: %v", err)
+	}
+	if !hasKeyBucket {
+		return nil, errors.New("snapshot database has no key bucket")
+	}
+	st.Hash = h.Sum32()
+	return st, nil
+}
+
+func maxRevision(rev int64, b []byte) int64 {
+	if r := int64(binary.BigEndian.Uint64(b[:8])); r > rev {
+		return r
+	}
+	return rev
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+func revBytes(main int64) []byte {
+	b := make([]byte, 17)
+	binary.BigEndian.PutUint64(b, uint64(main))
+	b[8] = '_'
+	return b
+}
+
+// newTestSnapshot returns an etcd snapshot of a backend with the given key revisions
+// and finished compaction revision, with the integrity hash appended.
+func newTestSnapshot(t *testing.T, revs []int64, compactRev int64) []byte {
+	return newTestSnapshotDB(t, func(tx *bolt.Tx) error {
+		kb, err := tx.CreateBucket(keyBucketName)
+		if err != nil {
+			return err
+		}
+		for _, rev := range revs {
+			if err := kb.Put(revBytes(rev), []byte("kv")); err != nil {
+				return err
+			}
+		}
+		mb, err := tx.CreateBucket(metaBucketName)
+		if err != nil {
+			return err
+		}
+		if compactRev != 0 {
+			return mb.Put(finishedCompactKeyName, revBytes(compactRev))
+		}
+		return nil
+	})
+}
+
+// newTestSnapshotDB returns an etcd snapshot of a backend written by update,
+// with the integrity hash appended.
+func newTestSnapshotDB(t *testing.T, update func(tx *bolt.Tx) error) []byte {
+	dir, err := ioutil.TempDir("", "verify-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "db")
+	db, err := bolt.Open(p, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Update(update); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(b)
+	return append(b, sum[:]...)
+}
+
+func TestVerifySnapshot(t *testing.T) {
+	snap := newTestSnapshot(t, []int64{2, 3, 5}, 0)
+	st, err := VerifySnapshot(bytes.NewReader(snap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Revision != 5 || st.TotalKey != 3 || st.Hash == 0 {
+		t.Errorf("unexpected snapshot status: %+v", st)
+	}
+
+	// The tombstone of the last revision was compacted away.
+	st, err = VerifySnapshot(bytes.NewReader(newTestSnapshot(t, []int64{2, 3}, 7)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Revision != 7 || st.TotalKey != 3 {
+		t.Errorf("unexpected snapshot status: %+v", st)
+	}
+
+	corrupted := append([]byte(nil), snap...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	if _, err := VerifySnapshot(bytes.NewReader(corrupted)); err == nil {
+		t.Error("expect error for a corrupted snapshot, get nil")
+	}
+	if _, err := VerifySnapshot(bytes.NewReader(snap[:len(snap)-sha256.Size])); err == nil {
+		t.Error("expect error for a snapshot without integrity hash, get nil")
+	}
+}
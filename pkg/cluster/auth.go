@@ -0,0 +1,284 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"github.com/coreos/etcd/auth/authpb"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// syncAuth enables authentication on the etcd cluster and keeps the roles and
+// users of spec.auth in sync.
+func (c *Cluster) syncAuth(pods []*v1.Pod) error {
+	ap := c.cluster.Spec.Auth
+	if ap == nil {
+		return nil
+	}
+	if c.rootCreds == nil {
+		if err := c.loadRootCreds(); err != nil {
+			return fmt.Errorf("failed to load root credentials: %v", err)
+		}
+	}
+	if !c.status.AuthEnabled {
+		// The probes of a member created without spec.auth would fail once
+		// authentication is enabled, and the member would be killed.
+		for _, pod := range pods {
+			if !k8sutil.HasProbeRootPassword(pod) {
+				return fmt.Errorf("cannot enable authentication: member (%s) was created without spec.auth", pod.Name)
+			}
+		}
+		if err := c.enableAuth(); err != nil {
+			return fmt.Errorf("failed to enable authentication: %v", err)
+		}
+	}
+
+	etcdcli, err := clientv3.New(etcdutil.NewClientConfig(c.members.ClientURLs(), c.tlsConfig, c.credentials()))
+	if err != nil {
+		return fmt.Errorf("failed to create etcd client: %v", err)
+	}
+	defer etcdcli.Close()
+
+	for _, r := range ap.Roles {
+		if err := syncRole(etcdcli, r); err != nil {
+			return fmt.Errorf("failed to sync role (%s): %v", r.Name, err)
+		}
+	}
+	for _, u := range ap.Users {
+		if err := c.syncUser(etcdcli, u); err != nil {
+			return fmt.Errorf("failed to sync user (%s): %v", u.Name, err)
+		}
+	}
+	return nil
+}
+
+// loadRootCreds loads the credentials of the etcd root user from spec.auth.rootSecret.
+func (c *Cluster) loadRootCreds() error {
+	creds, err := k8sutil.GetCredentialsFromSecret(c.config.KubeCli, c.cluster.Namespace, c.cluster.Spec.Auth.RootSecret, api.AuthRootUser)
+	if err != nil {
+		return err
+	}
+	creds.Username = api.AuthRootUser
+	c.rootCreds = creds
+	return nil
+}
+
+// enableAuth creates the root user and enables authentication. If the cluster
+// already authenticates the root user, e.g. after a restore, it only records that.
+func (c *Cluster) enableAuth() error {
+	// The client authenticates the root user when it connects, which etcd refuses
+	// while authentication is not enabled.
+	etcdcli, err := clientv3.New(etcdutil.NewClientConfig(c.members.ClientURLs(), c.tlsConfig, c.rootCreds))
+	if err == nil {
+		etcdcli.Close()
+		return c.setAuthEnabled()
+	}
+	if rpctypes.Error(err) != rpctypes.ErrAuthNotEnabled {
+		return err
+	}
+
+	etcdcli, err = clientv3.New(etcdutil.NewClientConfig(c.members.ClientURLs(), c.tlsConfig, nil))
+	if err != nil {
+		return err
+	}
+	defer etcdcli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	defer cancel()
+	_, err = etcdcli.UserAdd(ctx, api.AuthRootUser, c.rootCreds.Password)
+	if err == rpctypes.ErrUserAlreadyExist {
+		_, err = etcdcli.UserChangePassword(ctx, api.AuthRootUser, c.rootCreds.Password)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = etcdcli.RoleAdd(ctx, api.AuthRootUser); err != nil && err != rpctypes.ErrRoleAlreadyExist {
+		return err
+	}
+	if _, err = etcdcli.UserGrantRole(ctx, api.AuthRootUser, api.AuthRootUser); err != nil {
+		return err
+	}
+	if _, err = etcdcli.AuthEnable(ctx); err != nil {
+		return err
+	}
+	c.logger.Info("enabled etcd authentication")
+	return c.setAuthEnabled()
+}
+
+func (c *Cluster) setAuthEnabled() error {
+	c.status.AuthEnabled = true
+	return c.updateCRStatus()
+}
+
+// syncRole creates the role if needed and grants and revokes its permissions
+// to match the spec.
+func syncRole(etcdcli *clientv3.Client, role api.AuthRole) error {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	defer cancel()
+
+	var current []*authpb.Permission
+	resp, err := etcdcli.RoleGet(ctx, role.Name)
+	switch err {
+	case nil:
+		current = resp.Perm
+	case rpctypes.ErrRoleNotFound:
+		if _, err = etcdcli.RoleAdd(ctx, role.Name); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	grant, revoke := diffPermissions(current, role.Permissions)
+	for _, p := range grant {
+		if _, err = etcdcli.RoleGrantPermission(ctx, role.Name, string(p.Key), string(p.RangeEnd), clientv3.PermissionType(p.PermType)); err != nil {
+			return err
+		}
+	}
+	for _, p := range revoke {
+		if _, err = etcdcli.RoleRevokePermission(ctx, role.Name, string(p.Key), string(p.RangeEnd)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncUser creates the user if needed, sets its password when the password
+// secret changed, and grants and revokes its roles to match the spec.
+func (c *Cluster) syncUser(etcdcli *clientv3.Client, user api.AuthUser) error {
+	secret, err := c.config.KubeCli.CoreV1().Secrets(c.cluster.Namespace).Get(user.PasswordSecret, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	password := string(secret.Data[api.AuthSecretPasswordKey])
+	if len(password) == 0 {
+		return fmt.Errorf("secret (%s) has no %q", user.PasswordSecret, api.AuthSecretPasswordKey)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	defer cancel()
+
+	var current []string
+	resp, err := etcdcli.UserGet(ctx, user.Name)
+	switch err {
+	case nil:
+		current = resp.Roles
+		if c.userSecretVersions[user.Name] != secret.ResourceVersion {
+			if _, err = etcdcli.UserChangePassword(ctx, user.Name, password); err != nil {
+				return err
+			}
+		}
+	case rpctypes.ErrUserNotFound:
+		if _, err = etcdcli.UserAdd(ctx, user.Name, password); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+	if c.userSecretVersions == nil {
+		c.userSecretVersions = make(map[string]string)
+	}
+	c.userSecretVersions[user.Name] = secret.ResourceVersion
+
+	grant, revoke := diffRoles(current, user.Roles)
+	for _, r := range grant {
+		if _, err = etcdcli.UserGrantRole(ctx, user.Name, r); err != nil {
+			return err
+		}
+	}
+	for _, r := range revoke {
+		if _, err = etcdcli.UserRevokeRole(ctx, user.Name, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toEtcdPermission converts a spec permission to an etcd permission.
+func toEtcdPermission(p api.AuthPermission) *authpb.Permission {
+	ep := &authpb.Permission{Key: []byte(p.Key)}
+	if p.Prefix {
+		ep.RangeEnd = []byte(clientv3.GetPrefixRangeEnd(p.Key))
+	}
+	switch p.Type {
+	case api.AuthPermissionRead:
+		ep.PermType = authpb.READ
+	case api.AuthPermissionWrite:
+		ep.PermType = authpb.WRITE
+	default:
+		ep.PermType = authpb.READWRITE
+	}
+	return ep
+}
+
+// diffPermissions returns the permissions to grant and the permissions to revoke
+// to turn current into want.
+func diffPermissions(current []*authpb.Permission, want []api.AuthPermission) (grant, revoke []*authpb.Permission) {
+	have := make(map[string]authpb.Permission_Type)
+	for _, p := range current {
+		have[permissionRange(p)] = p.PermType
+	}
+	wanted := make(map[string]bool)
+	for _, wp := range want {
+		p := toEtcdPermission(wp)
+		k := permissionRange(p)
+		wanted[k] = true
+		if t, ok := have[k]; !ok || t != p.PermType {
+			grant = append(grant, p)
+		}
+	}
+	for _, p := range current {
+		if !wanted[permissionRange(p)] {
+			revoke = append(revoke, p)
+		}
+	}
+	return grant, revoke
+}
+
+// permissionRange identifies the key range of a permission.
+func permissionRange(p *authpb.Permission) string {
+	return string(p.Key) + "\x00" + string(p.RangeEnd)
+}
+
+// diffRoles returns the roles to grant and the roles to revoke to turn current into want.
+func diffRoles(current, want []string) (grant, revoke []string) {
+	have := make(map[string]bool)
+	for _, r := range current {
+		have[r] = true
+	}
+	wanted := make(map[string]bool)
+	for _, r := range want {
+		wanted[r] = true
+		if !have[r] {
+			grant = append(grant, r)
+		}
+	}
+	for _, r := range current {
+		if !wanted[r] {
+			revoke = append(revoke, r)
+		}
+	}
+	return grant, revoke
+}
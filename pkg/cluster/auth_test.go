@@ -0,0 +1,97 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+
+	"github.com/coreos/etcd/auth/authpb"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiffPermissions(t *testing.T) {
+	perm := func(t authpb.Permission_Type, key, end string) *authpb.Permission {
+		p := &authpb.Permission{PermType: t, Key: []byte(key)}
+		if len(end) != 0 {
+			p.RangeEnd = []byte(end)
+		}
+		return p
+	}
+	tests := []struct {
+		current []*authpb.Permission
+		want    []api.AuthPermission
+		wGrant  []*authpb.Permission
+		wRevoke []*authpb.Permission
+	}{{
+		want:   []api.AuthPermission{{Type: api.AuthPermissionRead, Key: "/app/", Prefix: true}},
+		wGrant: []*authpb.Permission{perm(authpb.READ, "/app/", "/app0")},
+	}, {
+		current: []*authpb.Permission{perm(authpb.READ, "/app/", "/app0")},
+		want:    []api.AuthPermission{{Type: api.AuthPermissionRead, Key: "/app/", Prefix: true}},
+	}, {
+		current: []*authpb.Permission{perm(authpb.READ, "/app/", "/app0")},
+		want:    []api.AuthPermission{{Type: api.AuthPermissionReadWrite, Key: "/app/", Prefix: true}},
+		wGrant:  []*authpb.Permission{perm(authpb.READWRITE, "/app/", "/app0")},
+	}, {
+		current: []*authpb.Permission{perm(authpb.READ, "/app/", "/app0"), perm(authpb.WRITE, "/lock", "")},
+		want:    []api.AuthPermission{{Type: api.AuthPermissionWrite, Key: "/app/"}},
+		wGrant:  []*authpb.Permission{perm(authpb.WRITE, "/app/", "")},
+		wRevoke: []*authpb.Permission{perm(authpb.READ, "/app/", "/app0"), perm(authpb.WRITE, "/lock", "")},
+	}}
+	for i, tt := range tests {
+		grant, revoke := diffPermissions(tt.current, tt.want)
+		if !reflect.DeepEqual(grant, tt.wGrant) {
+			t.Errorf("#%d: grant = %v, want %v", i, grant, tt.wGrant)
+		}
+		if !reflect.DeepEqual(revoke, tt.wRevoke) {
+			t.Errorf("#%d: revoke = %v, want %v", i, revoke, tt.wRevoke)
+		}
+	}
+}
+
+func TestDiffRoles(t *testing.T) {
+	grant, revoke := diffRoles([]string{"reader", "admin"}, []string{"reader", "writer"})
+	if !reflect.DeepEqual(grant, []string{"writer"}) {
+		t.Errorf("grant = %v, want [writer]", grant)
+	}
+	if !reflect.DeepEqual(revoke, []string{"admin"}) {
+		t.Errorf("revoke = %v, want [admin]", revoke)
+	}
+}
+
+func TestSyncAuthRefusesMembersWithoutAuth(t *testing.T) {
+	c := &Cluster{
+		cluster:   &api.EtcdCluster{Spec: api.ClusterSpec{Auth: &api.AuthPolicy{RootSecret: "root"}}},
+		rootCreds: &etcdutil.Credentials{Username: api.AuthRootUser, Password: "secret"},
+	}
+	pods := []*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-0000"},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "etcd"}}},
+	}}
+
+	err := c.syncAuth(pods)
+	if err == nil || !strings.Contains(err.Error(), "test-0000") {
+		t.Fatalf("expect error about member test-0000, get %v", err)
+	}
+	if c.status.AuthEnabled {
+		t.Error("expect authentication not to be recorded as enabled")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup"
+	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+	"github.com/coreos/etcd-operator/pkg/backup/writer"
+	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+)
+
+// backupBeforeUpgrade saves a backup with the cluster's backup policy before
+// the first member is upgraded to spec.version.
+// It is a no-op if the cluster has no backup policy or the backup is already saved.
+func (c *Cluster) backupBeforeUpgrade() error {
+	bp := c.cluster.Spec.BackupPolicy
+	if bp == nil {
+		return nil
+	}
+	version := c.cluster.Spec.Version
+	if b := c.status.PreUpgradeBackup; b != nil && b.UpgradeVersion == version {
+		return nil
+	}
+
+	c.logger.Infof("saving backup before upgrading to %s", version)
+	p, rev, etcdVersion, err := c.saveBackup(bp)
+	if err != nil {
+		c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonBackupFailed, "Failed to save backup before upgrading to %s: %v", version, err)
+		return err
+	}
+	c.status.PreUpgradeBackup = &api.PreUpgradeBackupStatus{
+		UpgradeVersion: version,
+		Path:           p,
+		EtcdVersion:    etcdVersion,
+		EtcdRevision:   rev,
+		CreationTime:   time.Now().Format(time.RFC3339),
+	}
+	c.logger.Infof("saved backup (%s) before upgrading to %s", p, version)
+	c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonBackupSucceeded, "Saved backup %s before upgrading to %s", p, version)
+	// The backup must be recorded before any member is upgraded.
+	return c.updateCRStatus()
+}
+
+// saveBackup saves a backup of the cluster with the given backup policy and
+// returns its path, etcd revision and etcd version.
+func (c *Cluster) saveBackup(bp *api.BackupPolicy) (string, int64, string, error) {
+	switch bp.StorageType {
+	case api.BackupStorageTypeS3:
+		cli, err := s3factory.NewClientFromSecret(c.config.KubeCli, c.cluster.Namespace, bp.S3.Endpoint, bp.S3.AWSSecret)
+		if err != nil {
+			return "", 0, "", err
+		}
+		defer cli.Close()
+
+		bm := backup.NewBackupManagerFromWriter(c.config.KubeCli, writer.NewS3Writer(cli.S3), c.tlsConfig, c.credentials(), c.members.ClientURLs(), c.cluster.Namespace)
+		return bm.SaveSnapUnderPrefix(c.traceCtx, backupapi.ToS3Prefix(bp.S3.Path, c.cluster.Namespace, c.cluster.Name))
+	default:
+		return "", 0, "", fmt.Errorf("unknown backup storage type (%s)", bp.StorageType)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// serverCertFile is the key of the server certificate in the member server secret.
+	serverCertFile = "server.crt"
+	// serviceMonitorCRDCheckInterval is how long the ServiceMonitor CRD is taken to be
+	// missing before it is looked up again.
+	serviceMonitorCRDCheckInterval = 5 * time.Minute
+)
+
+// syncClientService keeps the client Service in sync with spec.clientService.
+// With spec.metrics, it also creates the ServiceMonitor if the Prometheus
+// Operator is installed, and annotates the Service for scraping otherwise.
+func (c *Cluster) syncClientService() error {
+	scrapeAnnotations := false
+	if c.cluster.Spec.Metrics != nil && !c.serviceMonitorCreated {
+		ok, err := c.hasServiceMonitorCRD()
+		if err != nil {
+			return fmt.Errorf("failed to discover ServiceMonitor CRD: %v", err)
+		}
+		if ok {
+			sm := k8sutil.NewEtcdServiceMonitor(c.cluster.Name, c.cluster.Spec.Metrics.ServiceMonitorLabels, c.cluster.AsOwner())
+			if err := k8sutil.CreateServiceMonitor(c.config.KubeCli, c.cluster.Namespace, sm); err != nil {
+				return fmt.Errorf("failed to create ServiceMonitor: %v", err)
+			}
+			c.serviceMonitorCreated = true
+		}
+		scrapeAnnotations = !ok
+	}
+
+	svc, err := k8sutil.SyncClientService(c.config.KubeCli, c.cluster.Name, c.cluster.Namespace, c.cluster.Spec.ClientService, c.cluster.Spec.Metrics, scrapeAnnotations, c.cluster.AsOwner())
+	if err != nil {
+		return err
+	}
+	if c.isSecureClient() {
+		c.checkServerCertNames(svc)
+	}
+	return nil
+}
+
+// hasServiceMonitorCRD returns true if the ServiceMonitor CRD is installed.
+// A missing CRD is only looked up again after serviceMonitorCRDCheckInterval.
+func (c *Cluster) hasServiceMonitorCRD() (bool, error) {
+	if !c.serviceMonitorCRDMissingAt.IsZero() && time.Since(c.serviceMonitorCRDMissingAt) < serviceMonitorCRDCheckInterval {
+		return false, nil
+	}
+	ok, err := k8sutil.HasServiceMonitorCRD(c.config.KubeCli)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		c.serviceMonitorCRDMissingAt = time.Time{}
+	} else {
+		c.serviceMonitorCRDMissingAt = time.Now()
+	}
+	return ok, nil
+}
+
+// checkServerCertNames reports a warning event if the server certificate of the
+// members is not valid for the external addresses of the client Service.
+// It checks the addresses only once after they change.
+func (c *Cluster) checkServerCertNames(svc *v1.Service) {
+	var addrs []string
+	for _, ing := range svc.Status.LoadBalancer.Ingress {
+		if len(ing.Hostname) != 0 {
+			addrs = append(addrs, ing.Hostname)
+		}
+		if len(ing.IP) != 0 {
+			addrs = append(addrs, ing.IP)
+		}
+	}
+	sort.Strings(addrs)
+	key := strings.Join(addrs, ",")
+	if key == c.checkedClientServiceAddrs {
+		return
+	}
+
+	cert, err := c.serverCert()
+	if err != nil {
+		c.logger.Warningf("failed to check server certificate names: %v", err)
+		return
+	}
+	c.checkedClientServiceAddrs = key
+
+	var missing []string
+	for _, addr := range addrs {
+		if err := cert.VerifyHostname(addr); err != nil {
+			missing = append(missing, addr)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	c.logger.Warningf("server certificate is not valid for client service addresses %v", missing)
+	c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonServerCertMissingNames, "The server certificate is not valid for the client service addresses %s", strings.Join(missing, ", "))
+}
+
+func (c *Cluster) serverCert() (*x509.Certificate, error) {
+	se := c.cluster.Spec.TLS.Static.Member.ServerSecret
+	secret, err := c.config.KubeCli.CoreV1().Secrets(c.cluster.Namespace).Get(se, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(secret.Data[serverCertFile])
+	if block == nil {
+		return nil, errors.New("no PEM encoded certificate found in server secret")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
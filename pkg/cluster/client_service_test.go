@@ -0,0 +1,118 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncClientServiceKeepsForeignAnnotations(t *testing.T) {
+	svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:      k8sutil.ClientServiceName("test"),
+		Namespace: metav1.NamespaceDefault,
+		Annotations: map[string]string{
+			"cloud.example.com/lb-id":                      "lb-1",
+			"example.com/removed":                          "true",
+			k8sutil.TolerateUnreadyEndpointsAnnotation:     "true",
+			"etcd.database.coreos.com/managed-annotations": "example.com/removed," + k8sutil.TolerateUnreadyEndpointsAnnotation,
+		},
+	}}
+	kubecli := kubefake.NewSimpleClientset(svc)
+	c := &Cluster{
+		config: Config{KubeCli: kubecli},
+		cluster: &api.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+			Spec: api.ClusterSpec{ClientService: &api.ClientServicePolicy{
+				Annotations: map[string]string{"example.com/added": "true"},
+			}},
+		},
+	}
+
+	if err := c.syncClientService(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := kubecli.CoreV1().Services(metav1.NamespaceDefault).Get(svc.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"cloud.example.com/lb-id":                      "lb-1",
+		"example.com/added":                            "true",
+		k8sutil.TolerateUnreadyEndpointsAnnotation:     "true",
+		"etcd.database.coreos.com/managed-annotations": "example.com/added," + k8sutil.TolerateUnreadyEndpointsAnnotation,
+	}
+	if !reflect.DeepEqual(got.Annotations, want) {
+		t.Errorf("expect annotations %v, get %v", want, got.Annotations)
+	}
+}
+
+func TestSyncClientServiceCachesMissingServiceMonitorCRD(t *testing.T) {
+	kubecli := kubefake.NewSimpleClientset()
+	fd := kubecli.Discovery().(*fakediscovery.FakeDiscovery)
+	// The monitoring API group is served without ServiceMonitors.
+	fd.Resources = []*metav1.APIResourceList{{GroupVersion: "monitoring.coreos.com/v1"}}
+	c := &Cluster{
+		config: Config{KubeCli: kubecli},
+		cluster: &api.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+			Spec:       api.ClusterSpec{Metrics: &api.MetricsPolicy{Port: 2381}},
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := c.syncClientService(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	discoveries := func() int {
+		n := 0
+		for _, a := range fd.Actions() {
+			// The fake discovery client records lookups as this resource.
+			if a.GetResource().Resource == "resource" {
+				n++
+			}
+		}
+		return n
+	}
+	if discoveries := discoveries(); discoveries != 1 {
+		t.Errorf("expect the missing CRD to be looked up once, get %d", discoveries)
+	}
+	got, err := kubecli.CoreV1().Services(metav1.NamespaceDefault).Get(k8sutil.ClientServiceName("test"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Annotations["prometheus.io/scrape"] != "true" {
+		t.Errorf("expect scrape annotations without the CRD, get %v", got.Annotations)
+	}
+
+	// The CRD is looked up again once the check interval passed.
+	c.serviceMonitorCRDMissingAt = time.Now().Add(-serviceMonitorCRDCheckInterval)
+	if err := c.syncClientService(); err != nil {
+		t.Fatal(err)
+	}
+	if n := discoveries(); n != 2 {
+		t.Errorf("expect the CRD to be looked up again, get %d lookups", n)
+	}
+}
@@ -15,6 +15,7 @@
 package cluster
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -29,6 +30,8 @@ import (
 	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 	"github.com/coreos/etcd-operator/pkg/util/retryutil"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
+	"github.com/coreos/etcd/etcdserver/etcdserverpb"
 
 	"github.com/pborman/uuid"
 	"github.com/sirupsen/logrus"
@@ -36,7 +39,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 var (
@@ -60,6 +63,8 @@ type Config struct {
 
 	KubeCli   kubernetes.Interface
 	EtcdCRCli versioned.Interface
+	// EventRecorder records the events of the cluster.
+	EventRecorder record.EventRecorder
 }
 
 type Cluster struct {
@@ -86,7 +91,47 @@ type Cluster struct {
 
 	tlsConfig *tls.Config
 
-	eventsCli corev1.EventInterface
+	// migrationTLSConfig is used to talk to the external cluster set in
+	// spec.migrateFrom.
+	migrationTLSConfig *tls.Config
+	// externalMembers are the members of the external cluster that are not
+	// removed yet while migrating.
+	externalMembers []*etcdserverpb.Member
+
+	// rootCreds are the credentials of the etcd root user when spec.auth is set.
+	rootCreds *etcdutil.Credentials
+	// userSecretVersions are the resource versions of the password secrets
+	// that were last applied to each etcd user.
+	userSecretVersions map[string]string
+
+	recorder record.EventRecorder
+
+	// traceCtx carries the span of the reconcile in progress.
+	// Calls to etcd and Kubernetes made on its behalf are traced as its children.
+	traceCtx context.Context
+
+	// memberRecoveries counts how many times each dead member was recovered from
+	// its PVC since it last kept running for memberRecoveryResetPeriod.
+	memberRecoveries map[string]*memberRecovery
+
+	// reportedVersion is the current and target version last reported by the
+	// version_info metric.
+	reportedVersion [2]string
+	// reportedMembers are the members whose DB size was last reported.
+	reportedMembers map[string]bool
+	// leaderID is the ID of the leader last seen by the operator.
+	leaderID uint64
+
+	// checkedClientServiceAddrs are the external client service addresses
+	// the server certificate was last checked for.
+	checkedClientServiceAddrs string
+	// serviceMonitorCreated is true once the ServiceMonitor of spec.metrics is created.
+	serviceMonitorCreated bool
+	// serviceMonitorCRDMissingAt is when the ServiceMonitor CRD was last found missing.
+	serviceMonitorCRDMissingAt time.Time
+	// proxyDeleted is true once the proxies of a cluster without spec.proxy are
+	// known to be deleted.
+	proxyDeleted bool
 }
 
 func New(config Config, cl *api.EtcdCluster) *Cluster {
@@ -104,7 +149,8 @@ func New(config Config, cl *api.EtcdCluster) *Cluster {
 		eventCh:     make(chan *clusterEvent, 100),
 		stopCh:      make(chan struct{}),
 		status:      *(cl.Status.DeepCopy()),
-		eventsCli:   config.KubeCli.Core().Events(cl.Namespace),
+		traceCtx:    context.Background(),
+		recorder:    config.EventRecorder,
 	}
 
 	go func() {
@@ -132,7 +178,7 @@ func (c *Cluster) setup() error {
 		shouldCreateCluster = true
 	case api.ClusterPhaseCreating:
 		return errCreatedCluster
-	case api.ClusterPhaseRunning:
+	case api.ClusterPhaseRunning, api.ClusterPhaseHibernated:
 		shouldCreateCluster = false
 
 	default:
@@ -142,10 +188,31 @@ func (c *Cluster) setup() error {
 	if c.isSecureClient() {
 		d, err := k8sutil.GetTLSDataFromSecret(c.config.KubeCli, c.cluster.Namespace, c.cluster.Spec.TLS.Static.OperatorSecret)
 		if err != nil {
+			c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonTLSConfigFailed, "Failed to get operator TLS secret: %v", err)
 			return err
 		}
 		c.tlsConfig, err = etcdutil.NewTLSConfig(d.CertData, d.KeyData, d.CAData)
 		if err != nil {
+			c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonTLSConfigFailed, "Invalid operator TLS secret: %v", err)
+			return err
+		}
+	}
+
+	if mf := c.cluster.Spec.MigrateFrom; mf != nil && len(mf.ClientTLSSecret) != 0 && (shouldCreateCluster || c.status.Migration != nil) {
+		d, err := k8sutil.GetTLSDataFromSecret(c.config.KubeCli, c.cluster.Namespace, mf.ClientTLSSecret)
+		if err != nil {
+			c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonTLSConfigFailed, "Failed to get migration TLS secret: %v", err)
+			return err
+		}
+		c.migrationTLSConfig, err = etcdutil.NewTLSConfig(d.CertData, d.KeyData, d.CAData)
+		if err != nil {
+			c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonTLSConfigFailed, "Invalid migration TLS secret: %v", err)
+			return err
+		}
+	}
+
+	if c.cluster.Spec.Auth != nil {
+		if err := c.loadRootCreds(); err != nil {
 			return err
 		}
 	}
@@ -177,6 +244,8 @@ func (c *Cluster) prepareSeedMember() error {
 		} else {
 			err = c.migrateBootMember()
 		}
+	} else if c.cluster.Spec.MigrateFrom != nil {
+		err = c.joinExternalCluster()
 	} else {
 		err = c.bootstrap()
 	}
@@ -190,6 +259,8 @@ func (c *Cluster) prepareSeedMember() error {
 
 func (c *Cluster) Delete() {
 	c.logger.Info("cluster is deleted by user")
+	// run() removes the metrics of the cluster once it stops, so that they are
+	// not recreated by a reconciliation in progress.
 	close(c.stopCh)
 }
 
@@ -211,7 +282,9 @@ func (c *Cluster) run() {
 	c.status.ServiceName = k8sutil.ClientServiceName(c.cluster.Name)
 	c.status.ClientPort = k8sutil.EtcdClientPort
 
-	c.status.SetPhase(api.ClusterPhaseRunning)
+	if c.status.Phase != api.ClusterPhaseHibernated {
+		c.status.SetPhase(api.ClusterPhaseRunning)
+	}
 	if err := c.updateCRStatus(); err != nil {
 		c.logger.Warningf("update initial CR status failed: %v", err)
 	}
@@ -221,6 +294,7 @@ func (c *Cluster) run() {
 	for {
 		select {
 		case <-c.stopCh:
+			c.deleteMetrics()
 			return
 		case event := <-c.eventCh:
 			switch event.typ {
@@ -240,13 +314,30 @@ func (c *Cluster) run() {
 			start := time.Now()
 
 			if c.cluster.Spec.Paused {
+				if !c.status.ControlPaused {
+					c.recorder.Event(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonPaused, "Reconciliation is paused")
+				}
 				c.status.PauseControl()
 				c.logger.Infof("control is paused, skipping reconciliation")
 				continue
 			} else {
+				if c.status.ControlPaused {
+					c.recorder.Event(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonResumed, "Reconciliation is resumed")
+				}
 				c.status.Control()
 			}
 
+			if c.cluster.Spec.Hibernate || c.status.Phase == api.ClusterPhaseHibernated {
+				rerr = c.reconcileHibernation()
+				if rerr != nil {
+					c.logger.Errorf("failed to reconcile hibernation: %v", rerr)
+				}
+				if err := c.updateCRStatus(); err != nil {
+					c.logger.Warningf("periodic update CR status failed: %v", err)
+				}
+				break
+			}
+
 			running, pending, err := c.pollPods()
 			if err != nil {
 				c.logger.Errorf("fail to poll pods: %v", err)
@@ -283,10 +374,13 @@ func (c *Cluster) run() {
 			if err := c.updateCRStatus(); err != nil {
 				c.logger.Warningf("periodic update CR status failed: %v", err)
 			}
+			c.updateMemberMetrics()
 
 			reconcileHistogram.WithLabelValues(c.name()).Observe(time.Since(start).Seconds())
 		}
 
+		c.updateClusterMetrics()
+
 		if rerr != nil {
 			reconcileFailed.WithLabelValues(rerr.Error()).Inc()
 		}
@@ -318,7 +412,7 @@ func (c *Cluster) handleUpdateEvent(event *clusterEvent) error {
 }
 
 func isSpecEqual(s1, s2 api.ClusterSpec) bool {
-	if s1.Size != s2.Size || s1.Paused != s2.Paused || s1.Version != s2.Version {
+	if s1.Size != s2.Size || s1.Paused != s2.Paused || s1.Version != s2.Version || s1.Hibernate != s2.Hibernate {
 		return false
 	}
 	return true
@@ -338,10 +432,7 @@ func (c *Cluster) startSeedMember() error {
 	c.memberCounter++
 	c.members = ms
 	c.logger.Infof("cluster created with seed member (%s)", m.Name)
-	_, err := c.eventsCli.Create(k8sutil.NewMemberAddEvent(m.Name, c.cluster))
-	if err != nil {
-		c.logger.Errorf("failed to create new member add event: %v", err)
-	}
+	c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonMemberAdded, "New member %s added to cluster", m.Name)
 
 	return nil
 }
@@ -354,6 +445,15 @@ func (c *Cluster) isSecureClient() bool {
 	return c.cluster.Spec.TLS.IsSecureClient()
 }
 
+// credentials returns the credentials the operator uses to talk to etcd,
+// or nil until authentication has been enabled on the cluster.
+func (c *Cluster) credentials() *etcdutil.Credentials {
+	if !c.status.AuthEnabled {
+		return nil
+	}
+	return c.rootCreds
+}
+
 // bootstrap creates the seed etcd member for a new cluster.
 func (c *Cluster) bootstrap() error {
 	return c.startSeedMember()
@@ -367,12 +467,17 @@ func (c *Cluster) Update(cl *api.EtcdCluster) {
 }
 
 func (c *Cluster) setupServices() error {
-	err := k8sutil.CreateClientService(c.config.KubeCli, c.cluster.Name, c.cluster.Namespace, c.cluster.AsOwner())
+	err := c.syncClientService()
 	if err != nil {
 		return err
 	}
 
-	return k8sutil.CreatePeerService(c.config.KubeCli, c.cluster.Name, c.cluster.Namespace, c.cluster.AsOwner())
+	err = k8sutil.CreatePeerService(c.config.KubeCli, c.cluster.Name, c.cluster.Namespace, c.cluster.AsOwner())
+	if err != nil {
+		return err
+	}
+
+	return c.syncPodDisruptionBudget()
 }
 
 func (c *Cluster) isPodPVEnabled() bool {
@@ -383,18 +488,25 @@ func (c *Cluster) isPodPVEnabled() bool {
 }
 
 func (c *Cluster) createPod(members etcdutil.MemberSet, m *etcdutil.Member, state string) error {
-	pod := k8sutil.NewEtcdPod(m, members.PeerURLPairs(), c.cluster.Name, state, uuid.New(), c.cluster.Spec, c.cluster.AsOwner())
+	pod := k8sutil.NewEtcdPod(m, c.initialCluster(members), c.cluster.Name, state, uuid.New(), c.cluster.Spec, c.cluster.AsOwner())
 	if c.isPodPVEnabled() {
 		pvc := k8sutil.NewEtcdPodPVC(m, *c.cluster.Spec.Pod.PersistentVolumeClaimSpec, c.cluster.Name, c.cluster.Namespace, c.cluster.AsOwner())
 		_, err := c.config.KubeCli.CoreV1().PersistentVolumeClaims(c.cluster.Namespace).Create(pvc)
 		if err != nil {
+			c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonPodCreationFailed, "Failed to create PVC for member %s: %v", m.Name, err)
 			return fmt.Errorf("failed to create PVC for member (%s): %v", m.Name, err)
 		}
 		k8sutil.AddEtcdVolumeToPod(pod, pvc)
 	} else {
 		k8sutil.AddEtcdVolumeToPod(pod, nil)
 	}
+	_, span := tracing.Start(c.traceCtx, "corev1.Pods.Create")
+	span.SetAttribute("k8s.pod", pod.Name)
 	_, err := c.config.KubeCli.CoreV1().Pods(c.cluster.Namespace).Create(pod)
+	span.End(err)
+	if err != nil {
+		c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonPodCreationFailed, "Failed to create pod %s: %v", pod.Name, err)
+	}
 	return err
 }
 
@@ -469,6 +581,7 @@ func (c *Cluster) updateCRStatus() error {
 		return nil
 	}
 
+	oldPhase := c.cluster.Status.Phase
 	newCluster := c.cluster
 	newCluster.Status = c.status
 	newCluster, err := c.config.EtcdCRCli.EtcdV1beta2().EtcdClusters(c.cluster.Namespace).Update(c.cluster)
@@ -477,6 +590,13 @@ func (c *Cluster) updateCRStatus() error {
 	}
 
 	c.cluster = newCluster
+	if p := c.status.Phase; p != oldPhase {
+		if p == api.ClusterPhaseFailed {
+			c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonPhaseChanged, "Cluster phase changed to %s: %s", p, c.status.Reason)
+		} else {
+			c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonPhaseChanged, "Cluster phase changed to %s", p)
+		}
+	}
 
 	return nil
 }
@@ -514,6 +634,7 @@ func (c *Cluster) reportFailedStatus() {
 	}
 
 	retryutil.Retry(retryInterval, math.MaxInt64, f)
+	c.updateClusterMetrics()
 }
 
 func (c *Cluster) name() string {
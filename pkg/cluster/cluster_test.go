@@ -15,11 +15,19 @@
 package cluster
 
 import (
+	"context"
 	"testing"
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
 
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 )
 
 // When EtcdCluster update event happens, local object ref should be updated.
@@ -56,3 +64,78 @@ func TestUpdateEventUpdateLocalClusterObj(t *testing.T) {
 		t.Errorf("expect version=%s, get=%s", newVersion, c.cluster.ResourceVersion)
 	}
 }
+
+func TestUpdateCRStatusRecordsPhaseChange(t *testing.T) {
+	cl := &api.EtcdCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+		Status:     api.ClusterStatus{Phase: api.ClusterPhaseCreating},
+	}
+	recorder := record.NewFakeRecorder(10)
+	c := &Cluster{
+		config:   Config{EtcdCRCli: fake.NewSimpleClientset(cl.DeepCopy())},
+		cluster:  cl,
+		status:   *cl.Status.DeepCopy(),
+		recorder: recorder,
+	}
+
+	c.status.SetPhase(api.ClusterPhaseRunning)
+	if err := c.updateCRStatus(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-recorder.Events:
+		want := "Normal PhaseChanged Cluster phase changed to Running"
+		if e != want {
+			t.Errorf("event = %q, want %q", e, want)
+		}
+	default:
+		t.Fatal("expect phase changed event, get none")
+	}
+
+	// An update without a phase change records no event.
+	c.status.Size = 3
+	if err := c.updateCRStatus(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-recorder.Events:
+		t.Errorf("expect no event, get %q", e)
+	default:
+	}
+}
+
+func TestPatchMemberVersionIsTraced(t *testing.T) {
+	e := &tracing.MemoryExporter{}
+	tracing.SetExporter(e)
+	defer tracing.SetExporter(nil)
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-0000", Namespace: metav1.NamespaceDefault, Annotations: map[string]string{}},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "etcd"}}},
+	}
+	k8sutil.SetEtcdVersion(pod, "3.2.13")
+	ctx, span := tracing.Start(context.Background(), "Cluster.reconcile")
+	c := &Cluster{
+		logger:   logrus.WithField("pkg", "cluster"),
+		config:   Config{KubeCli: kubefake.NewSimpleClientset(pod)},
+		cluster:  &api.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault}},
+		traceCtx: ctx,
+	}
+
+	if _, err := c.patchMemberVersion("test-0000", "3.2.16"); err != nil {
+		t.Fatal(err)
+	}
+	span.End(nil)
+
+	spans := e.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expect 2 spans, get %d", len(spans))
+	}
+	patch, reconcile := spans[0], spans[1]
+	if patch.Name != "corev1.Pods.Patch" || patch.Attributes["k8s.pod"] != "test-0000" {
+		t.Errorf("unexpected patch span: %+v", patch)
+	}
+	if patch.ParentID != reconcile.SpanID || patch.TraceID != reconcile.TraceID {
+		t.Errorf("expect the patch span to be a child of the reconcile span")
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reconcileHibernation hibernates the cluster while spec.hibernate is set,
+// and resumes the hibernated cluster once spec.hibernate is cleared.
+func (c *Cluster) reconcileHibernation() error {
+	if !c.cluster.Spec.Hibernate {
+		return c.resume()
+	}
+	if c.status.Phase != api.ClusterPhaseHibernated {
+		return c.hibernate()
+	}
+	return c.deleteHibernatedPods()
+}
+
+// hibernate records the members of the cluster and sets the phase to Hibernated.
+// It only hibernates a cluster whose members all run and have a PVC.
+func (c *Cluster) hibernate() error {
+	running, pending, err := c.pollPods()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 || len(running) == 0 {
+		c.logger.Infof("waiting for all pods to run before hibernating: running (%v), pending (%v)", k8sutil.GetPodNames(running), k8sutil.GetPodNames(pending))
+		return nil
+	}
+	if c.members == nil {
+		if err := c.updateMembers(podsToMemberSet(running, c.isSecureClient())); err != nil {
+			return err
+		}
+	}
+	if !podsToMemberSet(running, c.isSecureClient()).IsEqual(c.members) {
+		c.logger.Infof("waiting for all members to run before hibernating: running (%v), members (%s)", k8sutil.GetPodNames(running), c.members)
+		return nil
+	}
+
+	var names []string
+	for _, m := range c.members {
+		pvc, err := c.usablePVC(m)
+		if err != nil {
+			return err
+		}
+		if pvc == nil {
+			return fmt.Errorf("cannot hibernate: member %s has no usable PVC", m.Name)
+		}
+		names = append(names, m.Name)
+	}
+	sort.Strings(names)
+
+	hs := &api.HibernationStatus{Members: names}
+	if bp := c.cluster.Spec.BackupPolicy; bp != nil {
+		p, _, _, err := c.saveBackup(bp)
+		if err != nil {
+			c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonBackupFailed, "Failed to save backup before hibernating: %v", err)
+			return fmt.Errorf("failed to save backup before hibernating: %v", err)
+		}
+		c.logger.Infof("saved backup (%s) before hibernating", p)
+		c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonBackupSucceeded, "Saved backup %s before hibernating", p)
+		hs.BackupPath = p
+	}
+	hs.Time = time.Now().Format(time.RFC3339)
+
+	c.status.Hibernation = hs
+	c.status.SetPhase(api.ClusterPhaseHibernated)
+	c.status.ClearCondition(api.ClusterConditionAvailable)
+	c.status.Size = 0
+	c.status.Members = api.MembersStatus{}
+	// The members must be recorded before any pod is deleted.
+	if err := c.updateCRStatus(); err != nil {
+		return err
+	}
+	c.logger.Infof("hibernating members %v", names)
+	return c.deleteHibernatedPods()
+}
+
+// deleteHibernatedPods deletes the pods of the hibernated cluster. Their PVCs are kept.
+func (c *Cluster) deleteHibernatedPods() error {
+	running, pending, err := c.pollPods()
+	if err != nil {
+		return err
+	}
+	for _, pod := range append(running, pending...) {
+		if err := c.removePod(pod.Name); err != nil {
+			return err
+		}
+	}
+	c.members = nil
+	return nil
+}
+
+// resume recreates the pods of the hibernated members on their PVCs, and sets
+// the phase back to Running.
+func (c *Cluster) resume() error {
+	hs := c.status.Hibernation
+	if hs == nil {
+		c.status.SetPhase(api.ClusterPhaseRunning)
+		return nil
+	}
+
+	ms := etcdutil.MemberSet{}
+	for _, name := range hs.Members {
+		ms.Add(&etcdutil.Member{
+			Name:         name,
+			Namespace:    c.cluster.Namespace,
+			SecurePeer:   c.isSecurePeer(),
+			SecureClient: c.isSecureClient(),
+		})
+		ct, err := etcdutil.GetCounterFromMemberName(name)
+		if err != nil {
+			return newFatalError(fmt.Sprintf("get counter from member name (%s) failed: %v", name, err))
+		}
+		if ct+1 > c.memberCounter {
+			c.memberCounter = ct + 1
+		}
+	}
+	c.members = ms
+
+	for _, m := range ms {
+		pvc, err := c.usablePVC(m)
+		if err != nil {
+			return err
+		}
+		if pvc == nil {
+			// The member will be replaced once the other members run.
+			c.logger.Warningf("cannot resume member %s without its PVC", m.Name)
+			continue
+		}
+		err = c.createPodOnPVC(m, pvc)
+		if k8sutil.IsKubernetesResourceAlreadyExistError(err) {
+			pod, gerr := c.config.KubeCli.CoreV1().Pods(c.cluster.Namespace).Get(m.Name, metav1.GetOptions{})
+			if gerr != nil {
+				return gerr
+			}
+			if pod.DeletionTimestamp != nil {
+				c.logger.Infof("waiting for the hibernated pod (%s) to be deleted", m.Name)
+				return nil
+			}
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("fail to create pod of member (%s): %v", m.Name, err)
+		}
+	}
+	c.logger.Infof("resumed members %v", hs.Members)
+
+	// The member IDs are retrieved from etcd once the pods run.
+	c.members = nil
+	c.status.Hibernation = nil
+	c.status.SetPhase(api.ClusterPhaseRunning)
+	return nil
+}
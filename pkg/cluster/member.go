@@ -27,12 +27,17 @@ import (
 )
 
 func (c *Cluster) updateMembers(known etcdutil.MemberSet) error {
-	resp, err := etcdutil.ListMembers(known.ClientURLs(), c.tlsConfig)
+	resp, err := etcdutil.ListMembers(c.traceCtx, known.ClientURLs(), c.tlsConfig, c.credentials())
 	if err != nil {
 		return err
 	}
 	members := etcdutil.MemberSet{}
+	var external []*etcdserverpb.Member
 	for _, m := range resp.Members {
+		if c.isExternalMember(m) {
+			external = append(external, m)
+			continue
+		}
 		name, err := getMemberName(m, c.cluster.GetName(), c.cluster.Spec.SelfHosted)
 		if err != nil {
 			return errors.Wrap(err, "get member name failed")
@@ -54,6 +59,7 @@ func (c *Cluster) updateMembers(known etcdutil.MemberSet) error {
 		}
 	}
 	c.members = members
+	c.externalMembers = external
 	return nil
 }
 
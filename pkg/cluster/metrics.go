@@ -15,6 +15,11 @@
 package cluster
 
 import (
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -37,7 +42,195 @@ var reconcileFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
 	[]string{"Reason"},
 )
 
+var (
+	clusterLabels = []string{"namespace", "cluster"}
+
+	desiredSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "cluster",
+		Name:      "desired_size",
+		Help:      "Number of members in the cluster spec",
+	}, clusterLabels)
+
+	currentSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "cluster",
+		Name:      "current_size",
+		Help:      "Number of members in the cluster",
+	}, clusterLabels)
+
+	readyMembers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "cluster",
+		Name:      "ready_members",
+		Help:      "Number of ready members in the cluster",
+	}, clusterLabels)
+
+	versionInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "cluster",
+		Name:      "version_info",
+		Help:      "Current and target etcd version of the cluster, always 1",
+	}, append(clusterLabels, "current_version", "target_version"))
+
+	phaseGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "cluster",
+		Name:      "phase",
+		Help:      "1 for the current phase of the cluster, 0 for the other phases",
+	}, append(clusterLabels, "phase"))
+
+	lastOperatorBackupTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "cluster",
+		Name:      "last_operator_backup_timestamp_seconds",
+		Help:      "Unix time of the last backup the etcd operator saved of the cluster before an upgrade or hibernation",
+	}, clusterLabels)
+
+	lastReconcileTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "cluster",
+		Name:      "last_successful_reconcile_timestamp_seconds",
+		Help:      "Unix time of the last successful reconciliation of the cluster",
+	}, clusterLabels)
+
+	leaderChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "cluster",
+		Name:      "leader_changes_total",
+		Help:      "Total number of leader changes seen by the operator",
+	}, clusterLabels)
+
+	memberDBSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "cluster",
+		Name:      "member_db_size_bytes",
+		Help:      "Size of the backend database of each member",
+	}, append(clusterLabels, "member"))
+)
+
+// clusterPhases are the phases reported by the phase gauge.
+var clusterPhases = []api.ClusterPhase{
+	api.ClusterPhaseCreating,
+	api.ClusterPhaseRunning,
+	api.ClusterPhaseFailed,
+	api.ClusterPhaseHibernated,
+}
+
 func init() {
 	prometheus.MustRegister(reconcileHistogram)
 	prometheus.MustRegister(reconcileFailed)
+	prometheus.MustRegister(desiredSize)
+	prometheus.MustRegister(currentSize)
+	prometheus.MustRegister(readyMembers)
+	prometheus.MustRegister(versionInfo)
+	prometheus.MustRegister(phaseGauge)
+	prometheus.MustRegister(lastOperatorBackupTimestamp)
+	prometheus.MustRegister(lastReconcileTimestamp)
+	prometheus.MustRegister(leaderChanges)
+	prometheus.MustRegister(memberDBSize)
+}
+
+// updateClusterMetrics sets the cluster gauges from the spec and the in memory status.
+func (c *Cluster) updateClusterMetrics() {
+	ns, name := c.cluster.Namespace, c.cluster.Name
+	desiredSize.WithLabelValues(ns, name).Set(float64(c.cluster.Spec.Size))
+	currentSize.WithLabelValues(ns, name).Set(float64(c.status.Size))
+	readyMembers.WithLabelValues(ns, name).Set(float64(len(c.status.Members.Ready)))
+
+	version := [2]string{c.status.CurrentVersion, c.status.TargetVersion}
+	if version != c.reportedVersion {
+		versionInfo.DeleteLabelValues(ns, name, c.reportedVersion[0], c.reportedVersion[1])
+		c.reportedVersion = version
+	}
+	versionInfo.WithLabelValues(ns, name, version[0], version[1]).Set(1)
+
+	for _, p := range clusterPhases {
+		v := 0.0
+		if c.status.Phase == p {
+			v = 1
+		}
+		phaseGauge.WithLabelValues(ns, name, string(p)).Set(v)
+	}
+
+	if t, ok := lastOperatorBackupTime(c.status); ok {
+		lastOperatorBackupTimestamp.WithLabelValues(ns, name).Set(float64(t.Unix()))
+	}
+}
+
+// updateMemberMetrics sets the member gauges from the status of each member
+// and counts leader changes. It is called after a successful reconciliation.
+func (c *Cluster) updateMemberMetrics() {
+	ns, name := c.cluster.Namespace, c.cluster.Name
+	lastReconcileTimestamp.WithLabelValues(ns, name).Set(float64(time.Now().Unix()))
+
+	reported := make(map[string]bool)
+	for _, m := range c.members {
+		// A member stays reported while it is in the cluster, even if its status
+		// can't be read, so that its series is deleted once it leaves.
+		if c.reportedMembers[m.Name] {
+			reported[m.Name] = true
+		}
+		st, err := etcdutil.MemberStatus(c.traceCtx, m.ClientURL(), c.tlsConfig, c.credentials())
+		if err != nil {
+			c.logger.Warningf("failed to get status of member (%s) for metrics: %v", m.Name, err)
+			continue
+		}
+		memberDBSize.WithLabelValues(ns, name, m.Name).Set(float64(st.DbSize))
+		reported[m.Name] = true
+		if st.Leader != 0 {
+			if c.leaderID != 0 && c.leaderID != st.Leader {
+				leaderChanges.WithLabelValues(ns, name).Inc()
+			}
+			c.leaderID = st.Leader
+		}
+	}
+	for m := range c.reportedMembers {
+		if !reported[m] {
+			memberDBSize.DeleteLabelValues(ns, name, m)
+		}
+	}
+	c.reportedMembers = reported
+}
+
+// deleteMetrics removes the series of the cluster once it is deleted.
+func (c *Cluster) deleteMetrics() {
+	ns, name := c.cluster.Namespace, c.cluster.Name
+	desiredSize.DeleteLabelValues(ns, name)
+	currentSize.DeleteLabelValues(ns, name)
+	readyMembers.DeleteLabelValues(ns, name)
+	versionInfo.DeleteLabelValues(ns, name, c.reportedVersion[0], c.reportedVersion[1])
+	for _, p := range clusterPhases {
+		phaseGauge.DeleteLabelValues(ns, name, string(p))
+	}
+	lastOperatorBackupTimestamp.DeleteLabelValues(ns, name)
+	lastReconcileTimestamp.DeleteLabelValues(ns, name)
+	leaderChanges.DeleteLabelValues(ns, name)
+	for m := range c.reportedMembers {
+		memberDBSize.DeleteLabelValues(ns, name, m)
+	}
+}
+
+// lastOperatorBackupTime returns the time of the latest backup the etcd operator saved
+// before an upgrade or hibernation, as recorded in the status. Backups saved by the
+// backup operator are reported in the status of their EtcdBackup instead.
+func lastOperatorBackupTime(st api.ClusterStatus) (time.Time, bool) {
+	var times []string
+	if b := st.PreUpgradeBackup; b != nil {
+		times = append(times, b.CreationTime)
+	}
+	if h := st.Hibernation; h != nil && len(h.BackupPath) != 0 {
+		times = append(times, h.Time)
+	}
+	var last time.Time
+	for _, s := range times {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			continue
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	return last, !last.IsZero()
 }
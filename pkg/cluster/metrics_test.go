@@ -0,0 +1,147 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestLastOperatorBackupTime(t *testing.T) {
+	t1 := "2018-03-01T10:00:00Z"
+	t2 := "2018-03-02T10:00:00Z"
+	tests := []struct {
+		status api.ClusterStatus
+		want   string
+	}{
+		{api.ClusterStatus{}, ""},
+		{api.ClusterStatus{PreUpgradeBackup: &api.PreUpgradeBackupStatus{CreationTime: t1}}, t1},
+		{api.ClusterStatus{PreUpgradeBackup: &api.PreUpgradeBackupStatus{CreationTime: "invalid"}}, ""},
+		// a hibernation without backup policy saves no backup
+		{api.ClusterStatus{Hibernation: &api.HibernationStatus{Time: t2}}, ""},
+		{api.ClusterStatus{
+			PreUpgradeBackup: &api.PreUpgradeBackupStatus{CreationTime: t1},
+			Hibernation:      &api.HibernationStatus{BackupPath: "bucket/backup", Time: t2},
+		}, t2},
+	}
+	for i, tt := range tests {
+		got, ok := lastOperatorBackupTime(tt.status)
+		if len(tt.want) == 0 {
+			if ok {
+				t.Errorf("#%d: lastOperatorBackupTime = %v, want none", i, got)
+			}
+			continue
+		}
+		want, _ := time.Parse(time.RFC3339, tt.want)
+		if !ok || !got.Equal(want) {
+			t.Errorf("#%d: lastOperatorBackupTime = %v, want %v", i, got, want)
+		}
+	}
+}
+
+// Deleting a cluster while it handles events must not race with its metric updates
+// (run with -race), and must leave no series of the cluster behind.
+func TestDeleteRemovesMetrics(t *testing.T) {
+	cl := &api.EtcdCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-delete", Namespace: metav1.NamespaceDefault},
+		Spec:       api.ClusterSpec{Size: 3, Version: "3.2.13"},
+	}
+	c := &Cluster{
+		logger:   logrus.WithField("pkg", "cluster"),
+		config:   Config{KubeCli: kubefake.NewSimpleClientset(), EtcdCRCli: fake.NewSimpleClientset(cl.DeepCopy())},
+		cluster:  cl.DeepCopy(),
+		recorder: record.NewFakeRecorder(10),
+		eventCh:  make(chan *clusterEvent, 100),
+		stopCh:   make(chan struct{}),
+	}
+	done := make(chan struct{})
+	go func() {
+		c.run()
+		close(done)
+	}()
+	for i := 0; i < 10; i++ {
+		c.send(&clusterEvent{typ: eventModifyCluster, cluster: cl.DeepCopy()})
+	}
+	c.Delete()
+	<-done
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range mfs {
+		for _, m := range mf.Metric {
+			for _, l := range m.Label {
+				if l.GetName() == "cluster" && l.GetValue() == cl.Name {
+					t.Errorf("expect no series of the deleted cluster, get %s%v", mf.GetName(), m.Label)
+				}
+			}
+		}
+	}
+}
+
+func TestUpdateMemberMetricsKeepsUnreachableMembers(t *testing.T) {
+	cl := &api.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test-members", Namespace: "no-such-namespace"}}
+	c := &Cluster{
+		logger:  logrus.WithField("pkg", "cluster"),
+		cluster: cl,
+		// The status of the member can't be read.
+		members:         etcdutil.NewMemberSet(&etcdutil.Member{Name: "test-members-0000", Namespace: cl.Namespace}),
+		reportedMembers: map[string]bool{"test-members-0000": true, "test-members-0001": true},
+	}
+	memberDBSize.WithLabelValues(cl.Namespace, cl.Name, "test-members-0000").Set(1)
+	memberDBSize.WithLabelValues(cl.Namespace, cl.Name, "test-members-0001").Set(1)
+	defer c.deleteMetrics()
+
+	c.updateMemberMetrics()
+	want := map[string]bool{"test-members-0000": true}
+	if !reflect.DeepEqual(c.reportedMembers, want) {
+		t.Errorf("expect reported members %v, get %v", want, c.reportedMembers)
+	}
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, mf := range mfs {
+		if mf.GetName() != "etcd_operator_cluster_member_db_size_bytes" {
+			continue
+		}
+		for _, m := range mf.Metric {
+			labels := make(map[string]string)
+			for _, l := range m.Label {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["cluster"] == cl.Name {
+				got[labels["member"]] = true
+			}
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expect DB size of members %v, get %v", want, got)
+	}
+}
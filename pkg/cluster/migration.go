@@ -0,0 +1,212 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/coreos/etcd/etcdserver/etcdserverpb"
+
+	"k8s.io/api/core/v1"
+)
+
+// joinExternalCluster starts the seed member as a new member of the external
+// cluster set in spec.migrateFrom. The external members are removed by
+// migrateExternalMembers once the members in Kubernetes have caught up.
+func (c *Cluster) joinExternalCluster() error {
+	mf := c.cluster.Spec.MigrateFrom
+
+	c.logger.Infof("migrating from external cluster (%v)", mf.ClientEndpoints)
+
+	etcdcli, err := clientv3.New(etcdutil.NewClientConfig(mf.ClientEndpoints, c.migrationTLSConfig, nil))
+	if err != nil {
+		return fmt.Errorf("failed to create client for external cluster: %v", err)
+	}
+	defer etcdcli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	resp, err := etcdcli.MemberList(ctx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to list members of external cluster: %v", err)
+	}
+	names := make([]string, 0, len(resp.Members))
+	for _, em := range resp.Members {
+		if len(em.Name) == 0 {
+			return fmt.Errorf("external member (%x) has not started", em.ID)
+		}
+		names = append(names, em.Name)
+	}
+
+	m := c.newMember(c.memberCounter)
+	_, span := tracing.Start(c.traceCtx, "clientv3.MemberAdd")
+	span.SetAttribute("etcd.member", m.Name)
+	ctx, cancel = context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	addResp, err := etcdcli.MemberAdd(ctx, []string{m.PeerURL()})
+	cancel()
+	span.End(err)
+	if err != nil {
+		return fmt.Errorf("failed to add seed member (%s) to external cluster: %v", m.Name, err)
+	}
+	m.ID = addResp.Member.ID
+
+	c.externalMembers = resp.Members
+	ms := etcdutil.NewMemberSet(m)
+	if err := c.createPod(ms, m, "existing"); err != nil {
+		// An unstarted member counts towards the quorum of the external cluster,
+		// so it must not be left there.
+		ctx, cancel = context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+		_, rerr := etcdcli.MemberRemove(ctx, m.ID)
+		cancel()
+		if rerr != nil {
+			return fmt.Errorf("failed to create seed member (%s): %v, and failed to remove it from external cluster: %v", m.Name, err, rerr)
+		}
+		return fmt.Errorf("failed to create seed member (%s): %v", m.Name, err)
+	}
+	c.memberCounter++
+	c.members = ms
+	c.status.Migration = &api.MigrationStatus{ExternalMembers: names}
+	c.logger.Infof("seed member (%s) joined external cluster with members (%v)", m.Name, names)
+	c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonMemberAdded, "New member %s added to cluster", m.Name)
+	return nil
+}
+
+// migrateExternalMembers removes one external member once every member in
+// Kubernetes has caught up with the leader. The migration completes when no
+// external member is left.
+func (c *Cluster) migrateExternalMembers() error {
+	if len(c.externalMembers) == 0 {
+		c.status.Migration = nil
+		c.logger.Info("migration from external cluster completed")
+		return nil
+	}
+
+	if err := c.checkMembersCaughtUp(); err != nil {
+		c.logger.Infof("waiting to remove external members: %v", err)
+		return nil
+	}
+
+	em := c.externalMembers[0]
+	err := etcdutil.RemoveMember(c.traceCtx, c.members.ClientURLs(), c.tlsConfig, c.credentials(), em.ID)
+	if err != nil && err != rpctypes.ErrMemberNotFound {
+		return fmt.Errorf("failed to remove external member (%s): %v", em.Name, err)
+	}
+	c.externalMembers = c.externalMembers[1:]
+	c.status.Migration.ExternalMembers = removeString(c.status.Migration.ExternalMembers, em.Name)
+	c.logger.Infof("removed external member (%s)", em.Name)
+	c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonMemberRemoved, "Existing member %s removed from the cluster", em.Name)
+	return nil
+}
+
+// checkMembersCaughtUp returns an error unless the raft index of every
+// member in Kubernetes has reached the raft index of the leader, which might
+// still be an external member.
+func (c *Cluster) checkMembersCaughtUp() error {
+	var leaderID uint64
+	for _, m := range c.members {
+		st, err := etcdutil.MemberStatus(c.traceCtx, m.ClientURL(), c.tlsConfig, c.credentials())
+		if err != nil {
+			return fmt.Errorf("failed to get status of member (%s): %v", m.Name, err)
+		}
+		leaderID = st.Leader
+		break
+	}
+
+	var leader *clientv3.StatusResponse
+	var err error
+	if em := c.externalMember(leaderID); em != nil {
+		if len(em.ClientURLs) == 0 {
+			return fmt.Errorf("external leader (%s) has no client URL", em.Name)
+		}
+		leader, err = etcdutil.MemberStatus(c.traceCtx, em.ClientURLs[0], c.migrationTLSConfig, nil)
+	} else {
+		for _, m := range c.members {
+			if m.ID == leaderID {
+				leader, err = etcdutil.MemberStatus(c.traceCtx, m.ClientURL(), c.tlsConfig, c.credentials())
+				break
+			}
+		}
+		if leader == nil && err == nil {
+			return fmt.Errorf("leader (%x) not found", leaderID)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get status of leader (%x): %v", leaderID, err)
+	}
+
+	for _, m := range c.members {
+		st, err := etcdutil.MemberStatus(c.traceCtx, m.ClientURL(), c.tlsConfig, c.credentials())
+		if err != nil {
+			return fmt.Errorf("failed to get status of member (%s): %v", m.Name, err)
+		}
+		if st.RaftIndex < leader.RaftIndex {
+			return fmt.Errorf("member (%s) at raft index %d is behind leader at %d", m.Name, st.RaftIndex, leader.RaftIndex)
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) externalMember(id uint64) *etcdserverpb.Member {
+	for _, em := range c.externalMembers {
+		if em.ID == id {
+			return em
+		}
+	}
+	return nil
+}
+
+// isExternalMember returns true if m is an external member that the
+// migration has not removed yet.
+func (c *Cluster) isExternalMember(m *etcdserverpb.Member) bool {
+	if c.status.Migration == nil {
+		return false
+	}
+	for _, name := range c.status.Migration.ExternalMembers {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// initialCluster returns the initial cluster of a new member: the given
+// members plus the external members while migrating.
+func (c *Cluster) initialCluster(members etcdutil.MemberSet) []string {
+	ps := members.PeerURLPairs()
+	for _, em := range c.externalMembers {
+		for _, u := range em.PeerURLs {
+			ps = append(ps, fmt.Sprintf("%s=%s", em.Name, u))
+		}
+	}
+	return ps
+}
+
+func removeString(ss []string, s string) []string {
+	var out []string
+	for _, x := range ss {
+		if x != s {
+			out = append(out, x)
+		}
+	}
+	return out
+}
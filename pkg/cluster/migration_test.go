@@ -0,0 +1,68 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd/etcdserver/etcdserverpb"
+)
+
+func TestInitialCluster(t *testing.T) {
+	m := &etcdutil.Member{Name: "example-0000", Namespace: "default"}
+	external := []*etcdserverpb.Member{
+		{Name: "infra0", PeerURLs: []string{"http://10.0.0.1:2380"}},
+		{Name: "infra1", PeerURLs: []string{"http://10.0.0.2:2380", "http://10.0.1.2:2380"}},
+	}
+	tests := []struct {
+		external []*etcdserverpb.Member
+		want     []string
+	}{
+		{nil, []string{"example-0000=http://example-0000.example.default.svc:2380"}},
+		{external, []string{
+			"example-0000=http://example-0000.example.default.svc:2380",
+			"infra0=http://10.0.0.1:2380",
+			"infra1=http://10.0.0.2:2380",
+			"infra1=http://10.0.1.2:2380",
+		}},
+	}
+	for i, tt := range tests {
+		c := &Cluster{externalMembers: tt.external}
+		if got := c.initialCluster(etcdutil.NewMemberSet(m)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("#%d: initialCluster = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestIsExternalMember(t *testing.T) {
+	tests := []struct {
+		migration *api.MigrationStatus
+		name      string
+		want      bool
+	}{
+		{nil, "infra0", false},
+		{&api.MigrationStatus{ExternalMembers: []string{"infra0", "infra1"}}, "infra1", true},
+		{&api.MigrationStatus{ExternalMembers: []string{"infra0"}}, "example-0000", false},
+	}
+	for i, tt := range tests {
+		c := &Cluster{status: api.ClusterStatus{Migration: tt.migration}}
+		if got := c.isExternalMember(&etcdserverpb.Member{Name: tt.name}); got != tt.want {
+			t.Errorf("#%d: isExternalMember(%s) = %v, want %v", i, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+)
+
+// syncPodDisruptionBudget keeps the PodDisruptionBudget of the etcd pods in sync
+// with the cluster size and spec.podDisruptionBudget.
+func (c *Cluster) syncPodDisruptionBudget() error {
+	policy := c.cluster.Spec.PodDisruptionBudget
+	if policy != nil && policy.Disabled {
+		return k8sutil.DeletePodDisruptionBudget(c.config.KubeCli, c.cluster.Namespace, k8sutil.PodDisruptionBudgetName(c.cluster.Name), c.cluster.UID)
+	}
+
+	pdb := k8sutil.NewEtcdPodDisruptionBudget(c.cluster.Name, maxUnavailable(c.cluster.Spec.Size, policy), c.cluster.AsOwner())
+	return k8sutil.SyncPodDisruptionBudget(c.config.KubeCli, c.cluster.Namespace, pdb)
+}
+
+// maxUnavailable returns how many etcd pods of a cluster of the given size can be
+// disrupted at the same time. It is never more than the cluster can lose without
+// losing quorum, unless the cluster cannot lose any member at all.
+func maxUnavailable(size int, policy *api.PodDisruptionBudgetPolicy) int {
+	n := 1
+	if policy != nil && policy.MaxUnavailable > 0 {
+		n = policy.MaxUnavailable
+	}
+	if tolerable := (size - 1) / 2; tolerable >= 1 && n > tolerable {
+		n = tolerable
+	}
+	return n
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMaxUnavailable(t *testing.T) {
+	tests := []struct {
+		size   int
+		policy *api.PodDisruptionBudgetPolicy
+		want   int
+	}{
+		{1, nil, 1},
+		{3, nil, 1},
+		{5, nil, 1},
+		{3, &api.PodDisruptionBudgetPolicy{MaxUnavailable: 2}, 1},
+		{5, &api.PodDisruptionBudgetPolicy{MaxUnavailable: 2}, 2},
+		{7, &api.PodDisruptionBudgetPolicy{MaxUnavailable: 2}, 2},
+		{1, &api.PodDisruptionBudgetPolicy{MaxUnavailable: 2}, 2},
+	}
+	for i, tt := range tests {
+		if got := maxUnavailable(tt.size, tt.policy); got != tt.want {
+			t.Errorf("#%d: maxUnavailable(%d) = %d, want %d", i, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestSyncPodDisruptionBudgetOwnership(t *testing.T) {
+	ec := &api.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault, UID: "cluster-uid"}}
+	other := ec.DeepCopy()
+	other.UID = "other-uid"
+	tests := []struct {
+		owner   *api.EtcdCluster
+		policy  *api.PodDisruptionBudgetPolicy
+		wExists bool
+		wMax    int
+	}{
+		{owner: other, policy: &api.PodDisruptionBudgetPolicy{Disabled: true}, wExists: true, wMax: 2},
+		{owner: other, wExists: true, wMax: 2},
+		{owner: ec, policy: &api.PodDisruptionBudgetPolicy{Disabled: true}},
+		{owner: ec, wExists: true, wMax: 1},
+	}
+	for i, tt := range tests {
+		pdb := k8sutil.NewEtcdPodDisruptionBudget("test", 2, tt.owner.AsOwner())
+		pdb.Namespace, pdb.UID = metav1.NamespaceDefault, "pdb-uid"
+		kubecli := kubefake.NewSimpleClientset(pdb)
+		cl := ec.DeepCopy()
+		cl.Spec.Size, cl.Spec.PodDisruptionBudget = 3, tt.policy
+		c := &Cluster{config: Config{KubeCli: kubecli}, cluster: cl}
+
+		if err := c.syncPodDisruptionBudget(); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		got, err := kubecli.PolicyV1beta1().PodDisruptionBudgets(metav1.NamespaceDefault).Get("test", metav1.GetOptions{})
+		if !tt.wExists {
+			if !apierrors.IsNotFound(err) {
+				t.Errorf("#%d: expect PDB to be deleted, get %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if got.Spec.MaxUnavailable.IntValue() != tt.wMax {
+			t.Errorf("#%d: expect maxUnavailable %d, get %v", i, tt.wMax, got.Spec.MaxUnavailable)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sort"
+
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+)
+
+// syncProxy keeps the etcd gRPC proxies in sync with spec.proxy and points them
+// at the current members.
+func (c *Cluster) syncProxy() error {
+	if c.cluster.Spec.Proxy == nil {
+		if c.proxyDeleted {
+			return nil
+		}
+		if err := k8sutil.DeleteProxy(c.config.KubeCli, c.cluster.Name, c.cluster.Namespace, c.cluster.UID); err != nil {
+			return err
+		}
+		c.proxyDeleted = true
+		return nil
+	}
+	c.proxyDeleted = false
+
+	endpoints := c.members.ClientURLs()
+	// Sorted so that the proxies are only restarted when the members change.
+	sort.Strings(endpoints)
+	d := k8sutil.NewEtcdProxyDeployment(c.cluster.Name, endpoints, c.cluster.Spec, c.cluster.AsOwner())
+	svc := k8sutil.NewEtcdProxyService(c.cluster.Name, c.cluster.AsOwner())
+	return k8sutil.SyncProxy(c.config.KubeCli, c.cluster.Namespace, d, svc)
+}
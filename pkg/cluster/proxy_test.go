@@ -0,0 +1,96 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncProxyOwnership(t *testing.T) {
+	ec := &api.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault, UID: "cluster-uid"}}
+	other := ec.DeepCopy()
+	other.UID = "other-uid"
+	tests := []struct {
+		owner     *api.EtcdCluster
+		proxy     *api.ProxyPolicy
+		wExists   bool
+		wReplicas int32
+	}{
+		{owner: other, wExists: true, wReplicas: 1},
+		{owner: other, proxy: &api.ProxyPolicy{Replicas: 3}, wExists: true, wReplicas: 1},
+		{owner: ec},
+		{owner: ec, proxy: &api.ProxyPolicy{Replicas: 3}, wExists: true, wReplicas: 3},
+	}
+	for i, tt := range tests {
+		d := k8sutil.NewEtcdProxyDeployment("test", nil, api.ClusterSpec{Proxy: &api.ProxyPolicy{}}, tt.owner.AsOwner())
+		d.Namespace, d.UID = metav1.NamespaceDefault, "deployment-uid"
+		svc := k8sutil.NewEtcdProxyService("test", tt.owner.AsOwner())
+		svc.Namespace, svc.UID = metav1.NamespaceDefault, "service-uid"
+		// A selector the Service must not keep.
+		svc.Spec.Selector = map[string]string{"app": "other"}
+		kubecli := kubefake.NewSimpleClientset(d, svc)
+		cl := ec.DeepCopy()
+		cl.Spec.Proxy = tt.proxy
+		c := &Cluster{config: Config{KubeCli: kubecli}, cluster: cl}
+
+		if err := c.syncProxy(); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		gotD, err := kubecli.AppsV1beta1().Deployments(metav1.NamespaceDefault).Get("test-proxy", metav1.GetOptions{})
+		gotSvc, svcErr := kubecli.CoreV1().Services(metav1.NamespaceDefault).Get("test-proxy", metav1.GetOptions{})
+		if !tt.wExists {
+			if !apierrors.IsNotFound(err) || !apierrors.IsNotFound(svcErr) {
+				t.Errorf("#%d: expect proxy to be deleted, get %v, %v", i, err, svcErr)
+			}
+			continue
+		}
+		if err != nil || svcErr != nil {
+			t.Fatalf("#%d: %v, %v", i, err, svcErr)
+		}
+		if *gotD.Spec.Replicas != tt.wReplicas {
+			t.Errorf("#%d: expect %d replicas, get %d", i, tt.wReplicas, *gotD.Spec.Replicas)
+		}
+		wSelector := "other"
+		if tt.owner == ec {
+			wSelector = "etcd-proxy"
+		}
+		if gotSvc.Spec.Selector["app"] != wSelector {
+			t.Errorf("#%d: expect Service selector app=%s, get %v", i, wSelector, gotSvc.Spec.Selector)
+		}
+	}
+}
+
+func TestSyncProxyDeletesOnce(t *testing.T) {
+	kubecli := kubefake.NewSimpleClientset()
+	ec := &api.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault, UID: "cluster-uid"}}
+	c := &Cluster{config: Config{KubeCli: kubecli}, cluster: ec}
+
+	for i := 0; i < 3; i++ {
+		if err := c.syncProxy(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Only the first sync looks for proxies to delete.
+	if n := len(kubecli.Actions()); n != 2 {
+		t.Errorf("expect 2 API calls, get %d: %v", n, kubecli.Actions())
+	}
+}
@@ -18,24 +18,54 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/util/constants"
 	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/pborman/uuid"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// maxMemberRecoveries is how many times a dead member is recovered from its PVC
+// before it is replaced.
+const maxMemberRecoveries = 3
+
+// memberRecoveryResetPeriod is how long a recovered member has to keep running
+// before its recoveries are forgotten.
+const memberRecoveryResetPeriod = 10 * time.Minute
+
+// memberRecovery counts the recoveries of a dead member from its PVC.
+type memberRecovery struct {
+	count int
+	// last is when the member was last recovered.
+	last time.Time
+}
+
 // ErrLostQuorum indicates that the etcd cluster lost its quorum.
 var ErrLostQuorum = errors.New("lost quorum")
 
 // reconcile reconciles cluster current state to desired state specified by spec.
 // - it tries to reconcile the cluster to desired size.
 // - if the cluster needs for upgrade, it tries to upgrade old member one by one.
-func (c *Cluster) reconcile(pods []*v1.Pod) error {
+// - it waits for an upgraded member to become healthy before upgrading the next one.
+// - if the PVC storage request increases, it expands the PVCs of the members one by one.
+func (c *Cluster) reconcile(pods []*v1.Pod) (err error) {
+	lg := c.logger
+	ctx, span := tracing.Start(context.Background(), "Cluster.reconcile")
+	span.SetAttribute("etcd.cluster", c.cluster.Namespace+"/"+c.cluster.Name)
+	c.traceCtx, c.logger = ctx, lg.WithFields(tracing.LogFields(ctx))
+	defer func() {
+		span.End(err)
+		c.traceCtx, c.logger = context.Background(), lg
+	}()
+
 	c.logger.Infoln("Start reconciling")
 	defer c.logger.Infoln("Finish reconciling")
 
@@ -48,15 +78,47 @@ func (c *Cluster) reconcile(pods []*v1.Pod) error {
 	if !running.IsEqual(c.members) || c.members.Size() != sp.Size {
 		return c.reconcileMembers(running)
 	}
+	c.forgetRecoveredMembers(time.Now())
 	c.status.ClearCondition(api.ClusterConditionScaling)
 
+	if err := c.syncPodDisruptionBudget(); err != nil {
+		c.logger.Warningf("failed to sync pod disruption budget: %v", err)
+	}
+	if err := c.syncClientService(); err != nil {
+		c.logger.Warningf("failed to sync client service: %v", err)
+	}
+	if err := c.syncProxy(); err != nil {
+		c.logger.Warningf("failed to sync proxy: %v", err)
+	}
+	// Auth changes would apply to the external cluster too, so they wait
+	// until the migration completes.
+	if c.status.Migration != nil {
+		return c.migrateExternalMembers()
+	}
+	if err := c.syncAuth(pods); err != nil {
+		c.logger.Warningf("failed to sync auth: %v", err)
+	}
+
+	if mu := c.status.MemberUpgrade; mu != nil {
+		if ok, err := c.waitMemberUpgrade(mu); !ok {
+			return err
+		}
+	}
+
+	if expanding, err := c.expandVolumes(pods); expanding || err != nil {
+		return err
+	}
+
+	if c.status.TargetVersion != sp.Version {
+		// A failed upgrade is only retried for a new spec.version.
+		c.status.ClearCondition(api.ClusterConditionUpgradeFailed)
+	}
 	if needUpgrade(pods, sp) {
 		c.status.UpgradeVersionTo(sp.Version)
-
-		m := pickOneOldMember(pods, sp.Version)
-		return c.upgradeOneMember(m.Name)
+		return c.upgrade(pods)
 	}
 	c.status.ClearCondition(api.ClusterConditionUpgrading)
+	c.status.ClearCondition(api.ClusterConditionUpgradeFailed)
 
 	c.status.SetVersion(sp.Version)
 	c.status.SetReadyCondition()
@@ -71,8 +133,9 @@ func (c *Cluster) reconcile(pods []*v1.Pod) error {
 // 1. Remove all pods from running set that does not belong to member set.
 // 2. L consist of remaining pods of runnings
 // 3. If L = members, the current state matches the membership state. END.
-// 4. If len(L) < len(members)/2 + 1, return quorum lost error.
-// 5. Add one missing member. END.
+// 4. If a missing member's PVC is intact, recreate its pod on the PVC. END.
+// 5. If len(L) < len(members)/2 + 1, return quorum lost error.
+// 6. Remove one missing member. END.
 func (c *Cluster) reconcileMembers(running etcdutil.MemberSet) error {
 	c.logger.Infof("running members: %s", running)
 	c.logger.Infof("cluster membership: %s", c.members)
@@ -92,13 +155,76 @@ func (c *Cluster) reconcileMembers(running etcdutil.MemberSet) error {
 		return c.resize()
 	}
 
+	dead := c.members.Diff(L).PickOne()
+	// A dead member with intact data rejoins without a membership change, even without quorum.
+	if recovered, err := c.recoverDeadMember(dead); recovered || err != nil {
+		return err
+	}
+
 	if L.Size() < c.members.Size()/2+1 {
+		c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonQuorumLost, "Only %d of %d members are running", L.Size(), c.members.Size())
 		return ErrLostQuorum
 	}
 
 	c.logger.Infof("removing one dead member")
 	// remove dead members that doesn't have any running pods before doing resizing.
-	return c.removeDeadMember(c.members.Diff(L).PickOne())
+	return c.removeDeadMember(dead)
+}
+
+// forgetRecoveredMembers forgets the recoveries of the members that kept running
+// for memberRecoveryResetPeriod since they were last recovered. It is called when
+// all the members run; a member that fails again in the meantime is recovered again
+// and its period starts over.
+func (c *Cluster) forgetRecoveredMembers(now time.Time) {
+	for name, r := range c.memberRecoveries {
+		if now.Sub(r.last) >= memberRecoveryResetPeriod {
+			delete(c.memberRecoveries, name)
+		}
+	}
+}
+
+// recoverDeadMember recreates the pod of a dead member on its PVC, so that the
+// member restarts from its data with the same name and ID.
+// It returns false if the member cannot be recovered this way and has to be replaced,
+// i.e. its PVC is lost or it has failed again after maxMemberRecoveries recoveries.
+func (c *Cluster) recoverDeadMember(m *etcdutil.Member) (bool, error) {
+	if !c.isPodPVEnabled() || c.cluster.Spec.SelfHosted != nil {
+		return false, nil
+	}
+	if r := c.memberRecoveries[m.Name]; r != nil && r.count >= maxMemberRecoveries {
+		c.logger.Warningf("dead member %q failed again after %d recoveries from its PVC", m.Name, r.count)
+		return false, nil
+	}
+
+	pvc, err := c.usablePVC(m)
+	if err != nil || pvc == nil {
+		return false, err
+	}
+
+	c.logger.Infof("recovering dead member %q from its PVC (%s)", m.Name, pvc.Name)
+	if err := c.removePod(m.Name); err != nil {
+		return false, err
+	}
+	if err := c.createPodOnPVC(m, pvc); err != nil {
+		if k8sutil.IsKubernetesResourceAlreadyExistError(err) {
+			c.logger.Infof("waiting for the dead pod (%s) to be deleted", m.Name)
+			return true, nil
+		}
+		return true, fmt.Errorf("fail to recreate pod (%s): %v", m.Name, err)
+	}
+
+	if c.memberRecoveries == nil {
+		c.memberRecoveries = make(map[string]*memberRecovery)
+	}
+	r := c.memberRecoveries[m.Name]
+	if r == nil {
+		r = &memberRecovery{}
+		c.memberRecoveries[m.Name] = r
+	}
+	r.count++
+	r.last = time.Now()
+	c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonRecoveringDeadMember, "The dead member %s is being recovered from its persistent volume", m.Name)
+	return true, nil
 }
 
 func (c *Cluster) resize() error {
@@ -120,11 +246,7 @@ func (c *Cluster) resize() error {
 func (c *Cluster) addOneMember() error {
 	c.status.SetScalingUpCondition(c.members.Size(), c.cluster.Spec.Size)
 
-	cfg := clientv3.Config{
-		Endpoints:   c.members.ClientURLs(),
-		DialTimeout: constants.DefaultDialTimeout,
-		TLS:         c.tlsConfig,
-	}
+	cfg := etcdutil.NewClientConfig(c.members.ClientURLs(), c.tlsConfig, c.credentials())
 	etcdcli, err := clientv3.New(cfg)
 	if err != nil {
 		return fmt.Errorf("add one member failed: creating etcd client failed %v", err)
@@ -132,9 +254,12 @@ func (c *Cluster) addOneMember() error {
 	defer etcdcli.Close()
 
 	newMember := c.newMember(c.memberCounter)
+	_, span := tracing.Start(c.traceCtx, "clientv3.MemberAdd")
+	span.SetAttribute("etcd.member", newMember.Name)
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
 	resp, err := etcdcli.MemberAdd(ctx, []string{newMember.PeerURL()})
 	cancel()
+	span.End(err)
 	if err != nil {
 		return fmt.Errorf("fail to add new member (%s): %v", newMember.Name, err)
 	}
@@ -146,10 +271,7 @@ func (c *Cluster) addOneMember() error {
 	}
 	c.memberCounter++
 	c.logger.Infof("added member (%s)", newMember.Name)
-	_, err = c.eventsCli.Create(k8sutil.NewMemberAddEvent(newMember.Name, c.cluster))
-	if err != nil {
-		c.logger.Errorf("failed to create new member add event: %v", err)
-	}
+	c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonMemberAdded, "New member %s added to cluster", newMember.Name)
 	return nil
 }
 
@@ -184,10 +306,7 @@ func (c *Cluster) removeDeadMember(toRemove *etcdutil.Member) error {
 	}
 
 	c.logger.Infof("removing dead member %q", toRemove.Name)
-	_, err := c.eventsCli.Create(k8sutil.ReplacingDeadMemberEvent(toRemove.Name, c.cluster))
-	if err != nil {
-		c.logger.Errorf("failed to create replacing dead member event: %v", err)
-	}
+	c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonReplacingDeadMember, "The dead member %s is being replaced", toRemove.Name)
 
 	return c.removeMember(toRemove)
 }
@@ -199,7 +318,7 @@ func (c *Cluster) removeMember(toRemove *etcdutil.Member) (err error) {
 		}
 	}()
 
-	err = etcdutil.RemoveMember(c.members.ClientURLs(), c.tlsConfig, toRemove.ID)
+	err = etcdutil.RemoveMember(c.traceCtx, c.members.ClientURLs(), c.tlsConfig, c.credentials(), toRemove.ID)
 	if err != nil {
 		switch err {
 		case rpctypes.ErrMemberNotFound:
@@ -209,10 +328,7 @@ func (c *Cluster) removeMember(toRemove *etcdutil.Member) (err error) {
 		}
 	}
 	c.members.Remove(toRemove.Name)
-	_, err = c.eventsCli.Create(k8sutil.MemberRemoveEvent(toRemove.Name, c.cluster))
-	if err != nil {
-		c.logger.Errorf("failed to create remove member event: %v", err)
-	}
+	c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonMemberRemoved, "Existing member %s removed from the cluster", toRemove.Name)
 	if err := c.removePod(toRemove.Name); err != nil {
 		return err
 	}
@@ -234,6 +350,38 @@ func (c *Cluster) removePVC(pvcName string) error {
 	return nil
 }
 
+// usablePVC returns the PVC of the given member, or nil if it is lost.
+func (c *Cluster) usablePVC(m *etcdutil.Member) (*v1.PersistentVolumeClaim, error) {
+	pvcName := k8sutil.PVCNameFromMember(m.Name)
+	pvc, err := c.config.KubeCli.CoreV1().PersistentVolumeClaims(c.cluster.Namespace).Get(pvcName, metav1.GetOptions{})
+	if err != nil {
+		if k8sutil.IsKubernetesResourceNotFoundError(err) {
+			c.logger.Infof("PVC (%s) of member %q is lost", pvcName, m.Name)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to get PVC (%s): %v", pvcName, err)
+	}
+	if pvc.DeletionTimestamp != nil || pvc.Status.Phase != v1.ClaimBound {
+		c.logger.Infof("PVC (%s) of member %q is not usable: phase %s", pvcName, m.Name, pvc.Status.Phase)
+		return nil, nil
+	}
+	return pvc, nil
+}
+
+// createPodOnPVC creates the pod of an existing member on the member's PVC.
+func (c *Cluster) createPodOnPVC(m *etcdutil.Member, pvc *v1.PersistentVolumeClaim) error {
+	pod := k8sutil.NewEtcdPod(m, c.initialCluster(c.members), c.cluster.Name, "existing", uuid.New(), c.cluster.Spec, c.cluster.AsOwner())
+	k8sutil.AddEtcdVolumeToPod(pod, pvc)
+	_, span := tracing.Start(c.traceCtx, "corev1.Pods.Create")
+	span.SetAttribute("k8s.pod", pod.Name)
+	_, err := c.config.KubeCli.CoreV1().Pods(c.cluster.Namespace).Create(pod)
+	span.End(err)
+	if err != nil && !k8sutil.IsKubernetesResourceAlreadyExistError(err) {
+		c.recorder.Eventf(c.cluster, v1.EventTypeWarning, k8sutil.EventReasonPodCreationFailed, "Failed to create pod %s: %v", pod.Name, err)
+	}
+	return err
+}
+
 func needUpgrade(pods []*v1.Pod, cs api.ClusterSpec) bool {
 	return len(pods) == cs.Size && pickOneOldMember(pods, cs.Version) != nil
 }
@@ -0,0 +1,36 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForgetRecoveredMembers(t *testing.T) {
+	now := time.Now()
+	c := &Cluster{memberRecoveries: map[string]*memberRecovery{
+		"test-0000": {count: 2, last: now.Add(-time.Minute)},
+		"test-0001": {count: 3, last: now.Add(-memberRecoveryResetPeriod)},
+	}}
+
+	c.forgetRecoveredMembers(now)
+	if r := c.memberRecoveries["test-0000"]; r == nil || r.count != 2 {
+		t.Errorf("expect recoveries of a member recovered a minute ago to be kept, get %+v", r)
+	}
+	if r := c.memberRecoveries["test-0001"]; r != nil {
+		t.Errorf("expect recoveries of a member that kept running to be forgotten, get %+v", r)
+	}
+}
@@ -15,6 +15,7 @@
 package cluster
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"time"
@@ -181,7 +182,7 @@ func (c *Cluster) migrateBootMember() error {
 
 	c.logger.Infof("migrating boot member (%s)", endpoint)
 
-	resp, err := etcdutil.ListMembers([]string{endpoint}, c.tlsConfig)
+	resp, err := etcdutil.ListMembers(c.traceCtx, []string{endpoint}, c.tlsConfig, c.credentials())
 	if err != nil {
 		return fmt.Errorf("failed to list members from boot member (%v)", err)
 	}
@@ -225,7 +226,7 @@ func (c *Cluster) migrateBootMember() error {
 			c.logger.Infof("waiting %v before removing the boot member", delay)
 			time.Sleep(delay)
 
-			err = etcdutil.RemoveMember([]string{newMember.ClientURL()}, c.tlsConfig, bootMember.ID)
+			err = etcdutil.RemoveMember(context.Background(), []string{newMember.ClientURL()}, c.tlsConfig, c.credentials(), bootMember.ID)
 			if err != nil {
 				c.logger.Errorf("boot member migration: failed to remove the boot member (%v)", err)
 			}
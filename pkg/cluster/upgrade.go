@@ -16,43 +16,241 @@ package cluster
 
 import (
 	"fmt"
+	"time"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
 
+	"github.com/coreos/etcd/clientv3"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
-func (c *Cluster) upgradeOneMember(memberName string) error {
-	c.status.SetUpgradingCondition(c.cluster.Spec.Version)
+const defaultMemberUpgradeTimeout = 300 * time.Second
 
+// upgrade rolls one member one step closer to spec.version.
+// An upgrade that skips minor versions is done through the latest patch release of
+// each intermediate minor version. No member moves on to the next minor version
+// before etcd reports that the whole cluster runs the previous one.
+// If the cluster has a backup policy, no member is upgraded before a backup is saved.
+// After a member failed to upgrade, no other member is upgraded to the same version.
+func (c *Cluster) upgrade(pods []*v1.Pod) error {
+	target := c.cluster.Spec.Version
+	if c.status.IsUpgradeFailed() {
+		c.logger.Infof("not upgrading to %s: a member failed to upgrade to it", target)
+		return nil
+	}
+
+	from, err := lowestEtcdVersion(pods)
+	if err != nil {
+		return fmt.Errorf("fail to get current etcd version: %v", err)
+	}
+	path, err := planUpgrade(from, target)
+	if err != nil {
+		c.logger.Errorf("refuse to upgrade from %s to %s: %v", from, target, err)
+		c.status.SetUpgradeRefusedCondition(err.Error())
+		return nil
+	}
+
+	if err := c.backupBeforeUpgrade(); err != nil {
+		c.logger.Errorf("not upgrading until the pre-upgrade backup succeeds: %v", err)
+		c.status.SetPreUpgradeBackupFailedCondition(err.Error())
+		return nil
+	}
+
+	step := path[0]
+	if err := c.waitClusterVersionFor(from, step); err != nil {
+		c.logger.Infof("not upgrading to %s yet: %v", step, err)
+		return nil
+	}
+
+	upgraded := 0
+	for _, pod := range pods {
+		if k8sutil.GetEtcdVersion(pod) == step {
+			upgraded++
+		}
+	}
+	c.status.SetUpgradingCondition(target, path, upgraded, len(pods))
+
+	m := pickOneOldMember(pods, step)
+	return c.upgradeOneMember(m.Name, step)
+}
+
+// waitClusterVersionFor returns an error if the etcd cluster running "from" cannot
+// be rolled to "to" yet. etcd only allows a member to join with the next minor
+// version once the cluster version has caught up with all its members.
+func (c *Cluster) waitClusterVersionFor(from, to string) error {
+	f, err := parseVersion(from)
+	if err != nil {
+		return err
+	}
+	t, err := parseVersion(to)
+	if err != nil {
+		return err
+	}
+	if t.minor == f.minor {
+		return nil
+	}
+
+	cv, err := etcdutil.ClusterVersion(c.traceCtx, c.members.ClientURLs(), c.tlsConfig)
+	if err != nil {
+		return err
+	}
+	v, err := parseVersion(cv)
+	if err != nil {
+		return fmt.Errorf("cluster version is not decided: %v", err)
+	}
+	if v.minor+1 < t.minor {
+		return fmt.Errorf("cluster version %s has not reached %s", v.minorVersion(), f.minorVersion())
+	}
+	return nil
+}
+
+func (c *Cluster) upgradeOneMember(memberName, version string) error {
+	oldVersion, err := c.patchMemberVersion(memberName, version)
+	if err != nil {
+		return err
+	}
+	c.logger.Infof("finished upgrading the etcd member %v", memberName)
+	c.recorder.Eventf(c.cluster, v1.EventTypeNormal, k8sutil.EventReasonMemberUpgraded, "Member %s upgraded from %s to %s", memberName, oldVersion, version)
+
+	c.status.MemberUpgrade = &api.MemberUpgradeStatus{
+		Name:        memberName,
+		FromVersion: oldVersion,
+		ToVersion:   version,
+		StartTime:   time.Now().Format(time.RFC3339),
+	}
+	// No other member must be upgraded before this one is healthy, even if the operator restarts.
+	return c.updateCRStatus()
+}
+
+// patchMemberVersion patches the pod of the given member to run the given etcd version.
+// It returns the version the member ran before.
+func (c *Cluster) patchMemberVersion(memberName, version string) (string, error) {
 	ns := c.cluster.Namespace
 
 	pod, err := c.config.KubeCli.CoreV1().Pods(ns).Get(memberName, metav1.GetOptions{})
 	if err != nil {
-		return fmt.Errorf("fail to get pod (%s): %v", memberName, err)
+		return "", fmt.Errorf("fail to get pod (%s): %v", memberName, err)
 	}
 	oldpod := pod.DeepCopy()
+	oldVersion := k8sutil.GetEtcdVersion(oldpod)
 
-	c.logger.Infof("upgrading the etcd member %v from %s to %s", memberName, k8sutil.GetEtcdVersion(pod), c.cluster.Spec.Version)
-	pod.Spec.Containers[0].Image = k8sutil.ImageName(c.cluster.Spec.Repository, c.cluster.Spec.Version)
-	k8sutil.SetEtcdVersion(pod, c.cluster.Spec.Version)
+	c.logger.Infof("upgrading the etcd member %v from %s to %s", memberName, oldVersion, version)
+	pod.Spec.Containers[0].Image = k8sutil.ImageName(c.cluster.Spec.Repository, version)
+	k8sutil.SetEtcdVersion(pod, version)
 
 	patchdata, err := k8sutil.CreatePatch(oldpod, pod, v1.Pod{})
 	if err != nil {
-		return fmt.Errorf("error creating patch: %v", err)
+		return "", fmt.Errorf("error creating patch: %v", err)
 	}
 
+	_, span := tracing.Start(c.traceCtx, "corev1.Pods.Patch")
+	span.SetAttribute("k8s.pod", pod.GetName())
 	_, err = c.config.KubeCli.CoreV1().Pods(ns).Patch(pod.GetName(), types.StrategicMergePatchType, patchdata)
+	span.End(err)
 	if err != nil {
-		return fmt.Errorf("fail to update the etcd member (%s): %v", memberName, err)
+		return "", fmt.Errorf("fail to update the etcd member (%s): %v", memberName, err)
 	}
-	c.logger.Infof("finished upgrading the etcd member %v", memberName)
-	_, err = c.eventsCli.Create(k8sutil.MemberUpgradedEvent(memberName, k8sutil.GetEtcdVersion(oldpod), c.cluster.Spec.Version, c.cluster))
+	return oldVersion, nil
+}
+
+// waitMemberUpgrade checks the member the operator has upgraded last.
+// It returns true once the member is healthy and the next member can be upgraded.
+// If the member is not healthy within the upgrade policy's timeout, the upgrade
+// fails and the member is patched back to its previous version if the policy says so.
+func (c *Cluster) waitMemberUpgrade(mu *api.MemberUpgradeStatus) (bool, error) {
+	err := c.checkUpgradedMember(mu)
+	if err == nil {
+		c.logger.Infof("upgraded etcd member %s is healthy", mu.Name)
+		c.status.MemberUpgrade = nil
+		return true, nil
+	}
+
+	start, perr := time.Parse(time.RFC3339, mu.StartTime)
+	if perr == nil && time.Since(start) < c.memberUpgradeTimeout() {
+		c.logger.Infof("waiting for upgraded etcd member %s: %v", mu.Name, err)
+		return false, nil
+	}
+
+	c.logger.Errorf("etcd member %s failed to upgrade to %s: %v", mu.Name, mu.ToVersion, err)
+	rolledBack := false
+	if up := c.cluster.Spec.UpgradePolicy; up != nil && up.Rollback {
+		c.logger.Infof("rolling back the etcd member %s to %s", mu.Name, mu.FromVersion)
+		if _, rerr := c.patchMemberVersion(mu.Name, mu.FromVersion); rerr != nil {
+			return false, fmt.Errorf("fail to roll back the etcd member (%s): %v", mu.Name, rerr)
+		}
+		rolledBack = true
+	}
+	c.status.MemberUpgrade = nil
+	c.status.SetUpgradeFailedCondition(mu.Name, mu.ToVersion, rolledBack, err.Error())
+	return false, nil
+}
+
+func (c *Cluster) memberUpgradeTimeout() time.Duration {
+	if up := c.cluster.Spec.UpgradePolicy; up != nil && up.MemberTimeoutInSecond > 0 {
+		return time.Duration(up.MemberTimeoutInSecond) * time.Second
+	}
+	return defaultMemberUpgradeTimeout
+}
+
+// checkUpgradedMember returns an error unless the upgraded member's pod is ready
+// and the member runs the new version and has caught up with the leader's raft index.
+func (c *Cluster) checkUpgradedMember(mu *api.MemberUpgradeStatus) error {
+	pod, err := c.config.KubeCli.CoreV1().Pods(c.cluster.Namespace).Get(mu.Name, metav1.GetOptions{})
 	if err != nil {
-		c.logger.Errorf("failed to create member upgraded event: %v", err)
+		return fmt.Errorf("fail to get pod: %v", err)
+	}
+	if !k8sutil.IsPodReady(pod) {
+		return fmt.Errorf("pod is not ready")
+	}
+	m := c.members[mu.Name]
+	if m == nil {
+		return fmt.Errorf("not a member of the cluster")
 	}
 
+	// Get the leader's raft index first so that a healthy member must have reached it.
+	leader, err := c.leaderStatus(mu.Name)
+	if err != nil {
+		return err
+	}
+	st, err := etcdutil.MemberStatus(c.traceCtx, m.ClientURL(), c.tlsConfig, c.credentials())
+	if err != nil {
+		return fmt.Errorf("fail to get member status: %v", err)
+	}
+	if st.Version != mu.ToVersion {
+		return fmt.Errorf("member runs etcd %s", st.Version)
+	}
+	if leader == nil {
+		if st.Header.MemberId != st.Leader {
+			return fmt.Errorf("no leader found")
+		}
+		return nil
+	}
+	if st.RaftIndex < leader.RaftIndex {
+		return fmt.Errorf("raft index %d is behind the leader's %d", st.RaftIndex, leader.RaftIndex)
+	}
 	return nil
 }
+
+// leaderStatus returns the status of the leader among the members other than the
+// given one, or nil if none of them is the leader.
+func (c *Cluster) leaderStatus(exclude string) (*clientv3.StatusResponse, error) {
+	for name, m := range c.members {
+		if name == exclude {
+			continue
+		}
+		st, err := etcdutil.MemberStatus(c.traceCtx, m.ClientURL(), c.tlsConfig, c.credentials())
+		if err != nil {
+			c.logger.Warningf("fail to get status of the etcd member %s: %v", name, err)
+			continue
+		}
+		if st.Header.MemberId == st.Leader {
+			return st, nil
+		}
+	}
+	return nil, nil
+}
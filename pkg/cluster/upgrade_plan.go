@@ -0,0 +1,133 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+)
+
+// latestPatchReleases maps an etcd minor version to the release the operator
+// upgrades through when spec.version skips that minor version.
+// etcd only supports rolling upgrades from one minor version to the next.
+var latestPatchReleases = map[string]string{
+	"3.0": "3.0.17",
+	"3.1": "3.1.12",
+	"3.2": "3.2.16",
+	"3.3": "3.3.1",
+}
+
+type semver struct {
+	major, minor, patch int
+}
+
+// parseVersion parses an etcd release version, e.g. "3.2.13" or "v3.3.0-rc.0".
+// Pre-release and build metadata are ignored.
+func parseVersion(s string) (semver, error) {
+	v := strings.TrimLeft(s, "v")
+	if i := strings.IndexAny(v, "-+"); i != -1 {
+		v = v[:i]
+	}
+	toks := strings.Split(v, ".")
+	if len(toks) != 3 {
+		return semver{}, fmt.Errorf("invalid version (%s): must be of the form x.y.z", s)
+	}
+	var nums [3]int
+	for i, tok := range toks {
+		n, err := strconv.Atoi(tok)
+		if err != nil || n < 0 {
+			return semver{}, fmt.Errorf("invalid version (%s): %q is not a number", s, tok)
+		}
+		nums[i] = n
+	}
+	return semver{major: nums[0], minor: nums[1], patch: nums[2]}, nil
+}
+
+func (v semver) less(o semver) bool {
+	if v.major != o.major {
+		return v.major < o.major
+	}
+	if v.minor != o.minor {
+		return v.minor < o.minor
+	}
+	return v.patch < o.patch
+}
+
+func (v semver) minorVersion() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+type downgradeError struct {
+	from, to string
+}
+
+func (e *downgradeError) Error() string {
+	return fmt.Sprintf("downgrade from %s to %s is not supported", e.from, e.to)
+}
+
+// planUpgrade returns the versions a cluster running version "from" has to be
+// rolled through to reach version "to". The last version is always "to".
+// Downgrades are only supported within a minor version.
+func planUpgrade(from, to string) ([]string, error) {
+	f, err := parseVersion(from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := parseVersion(to)
+	if err != nil {
+		return nil, err
+	}
+	if f.major != t.major {
+		return nil, fmt.Errorf("upgrade from %s to %s across major versions is not supported", from, to)
+	}
+	if t.minor < f.minor {
+		return nil, &downgradeError{from: from, to: to}
+	}
+
+	var path []string
+	for minor := f.minor + 1; minor < t.minor; minor++ {
+		mv := fmt.Sprintf("%d.%d", f.major, minor)
+		v, ok := latestPatchReleases[mv]
+		if !ok {
+			return nil, fmt.Errorf("upgrade from %s to %s: no known etcd %s release to upgrade through", from, to, mv)
+		}
+		path = append(path, v)
+	}
+	return append(path, to), nil
+}
+
+// lowestEtcdVersion returns the lowest etcd version the given pods run.
+func lowestEtcdVersion(pods []*v1.Pod) (string, error) {
+	var (
+		lowest    string
+		lowestVer semver
+	)
+	for _, pod := range pods {
+		s := k8sutil.GetEtcdVersion(pod)
+		v, err := parseVersion(s)
+		if err != nil {
+			return "", fmt.Errorf("pod (%s): %v", pod.Name, err)
+		}
+		if len(lowest) == 0 || v.less(lowestVer) {
+			lowest, lowestVer = s, v
+		}
+	}
+	return lowest, nil
+}
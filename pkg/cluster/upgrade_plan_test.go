@@ -0,0 +1,117 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanUpgrade(t *testing.T) {
+	tests := []struct {
+		from, to  string
+		wPath     []string
+		wErr      bool
+		downgrade bool
+	}{{
+		from:  "3.2.13",
+		to:    "3.2.15",
+		wPath: []string{"3.2.15"},
+	}, {
+		from:  "3.2.15",
+		to:    "3.2.13",
+		wPath: []string{"3.2.13"},
+	}, {
+		from:  "3.2.13",
+		to:    "3.3.0",
+		wPath: []string{"3.3.0"},
+	}, {
+		from:  "3.1.9",
+		to:    "3.4.0",
+		wPath: []string{"3.2.16", "3.3.1", "3.4.0"},
+	}, {
+		from: "3.1.9",
+		to:   "3.5.0",
+		wErr: true,
+	}, {
+		from:      "3.2.13",
+		to:        "3.1.11",
+		wErr:      true,
+		downgrade: true,
+	}, {
+		from: "2.3.8",
+		to:   "3.0.17",
+		wErr: true,
+	}, {
+		from: "3.2.13",
+		to:   "latest",
+		wErr: true,
+	}}
+
+	for i, tt := range tests {
+		path, err := planUpgrade(tt.from, tt.to)
+		if tt.wErr {
+			if err == nil {
+				t.Errorf("#%d: expect error, get path %v", i, path)
+			}
+			if _, ok := err.(*downgradeError); ok != tt.downgrade {
+				t.Errorf("#%d: downgrade error want=%v, get=%v", i, tt.downgrade, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("#%d: want err = nil, get %v", i, err)
+		}
+		if !reflect.DeepEqual(path, tt.wPath) {
+			t.Errorf("#%d: path get=%v, want=%v", i, path, tt.wPath)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		v    string
+		wVer semver
+		wErr bool
+	}{{
+		v:    "3.2.13",
+		wVer: semver{3, 2, 13},
+	}, {
+		v:    "v3.3.0-rc.0",
+		wVer: semver{3, 3, 0},
+	}, {
+		v:    "3.3",
+		wErr: true,
+	}, {
+		v:    "not_decided",
+		wErr: true,
+	}}
+
+	for i, tt := range tests {
+		v, err := parseVersion(tt.v)
+		if tt.wErr {
+			if err == nil {
+				t.Errorf("#%d: expect error, get %v", i, v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("#%d: want err = nil, get %v", i, err)
+		}
+		if v != tt.wVer {
+			t.Errorf("#%d: version get=%v, want=%v", i, v, tt.wVer)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// pvcFileSystemResizePending is the PVC condition Kubernetes sets when the volume
+// is expanded and the file system is resized when the pod is restarted.
+const pvcFileSystemResizePending v1.PersistentVolumeClaimConditionType = "FileSystemResizePending"
+
+// expandVolumes expands the PVCs of the members to the storage request of
+// spec.pod.persistentVolumeClaimSpec, one member at a time.
+// It returns true while a PVC is being expanded.
+func (c *Cluster) expandVolumes(pods []*v1.Pod) (bool, error) {
+	if !c.isPodPVEnabled() {
+		return false, nil
+	}
+	want, ok := c.cluster.Spec.Pod.PersistentVolumeClaimSpec.Resources.Requests[v1.ResourceStorage]
+	if !ok {
+		return false, nil
+	}
+
+	names := k8sutil.GetPodNames(pods)
+	sort.Strings(names)
+	var (
+		next     *v1.PersistentVolumeClaim
+		member   string
+		expanded int
+		total    int
+	)
+	for _, name := range names {
+		pvc, err := c.config.KubeCli.CoreV1().PersistentVolumeClaims(c.cluster.Namespace).Get(k8sutil.PVCNameFromMember(name), metav1.GetOptions{})
+		if err != nil {
+			if k8sutil.IsKubernetesResourceNotFoundError(err) {
+				// e.g. the seed member does not use a PVC.
+				continue
+			}
+			return false, err
+		}
+		total++
+		if isPVCExpanded(pvc, want) {
+			expanded++
+			continue
+		}
+		if next == nil {
+			next, member = pvc, name
+		}
+	}
+	if next == nil {
+		c.status.ClearCondition(api.ClusterConditionExpandingVolumes)
+		return false, nil
+	}
+
+	if err := c.expandVolume(member, next, want); err != nil {
+		c.logger.Errorf("failed to expand the volume of member %s: %v", member, err)
+		c.status.SetVolumeExpansionFailedCondition(fmt.Sprintf("member %s: %v", member, err))
+		// Do not block other changes to the cluster. The expansion is retried on the next reconcile.
+		return false, nil
+	}
+	c.status.SetExpandingVolumesCondition(want.String(), expanded, total)
+	return true, nil
+}
+
+// expandVolume makes progress expanding the PVC of the given member. It raises the
+// storage request of the PVC, and restarts the member once Kubernetes waits for it
+// to resize the file system.
+func (c *Cluster) expandVolume(member string, pvc *v1.PersistentVolumeClaim, want resource.Quantity) error {
+	req := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	if req.Cmp(want) < 0 {
+		c.logger.Infof("expanding the volume of member %s from %s to %s", member, req.String(), want.String())
+		newPVC := pvc.DeepCopy()
+		if newPVC.Spec.Resources.Requests == nil {
+			newPVC.Spec.Resources.Requests = v1.ResourceList{}
+		}
+		newPVC.Spec.Resources.Requests[v1.ResourceStorage] = want
+		patchdata, err := k8sutil.CreatePatch(pvc, newPVC, v1.PersistentVolumeClaim{})
+		if err != nil {
+			return fmt.Errorf("error creating patch: %v", err)
+		}
+		_, err = c.config.KubeCli.CoreV1().PersistentVolumeClaims(c.cluster.Namespace).Patch(pvc.Name, types.StrategicMergePatchType, patchdata)
+		return err
+	}
+
+	cond := getPVCCondition(pvc, pvcFileSystemResizePending)
+	if cond == nil || cond.Status != v1.ConditionTrue {
+		c.logger.Infof("waiting for the volume of member %s to be expanded", member)
+		return nil
+	}
+	pod, err := c.config.KubeCli.CoreV1().Pods(c.cluster.Namespace).Get(member, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("fail to get pod: %v", err)
+	}
+	if pod.CreationTimestamp.After(cond.LastTransitionTime.Time) {
+		c.logger.Infof("waiting for the file system of member %s to be resized", member)
+		return nil
+	}
+	// The member's pod is recreated on its PVC like the pod of any dead member.
+	c.logger.Infof("restarting member %s to resize its file system", member)
+	return c.removePod(member)
+}
+
+// isPVCExpanded returns true if the PVC has at least the given capacity and needs
+// no file system resize.
+func isPVCExpanded(pvc *v1.PersistentVolumeClaim, want resource.Quantity) bool {
+	capacity := pvc.Status.Capacity[v1.ResourceStorage]
+	if capacity.Cmp(want) < 0 {
+		return false
+	}
+	cond := getPVCCondition(pvc, pvcFileSystemResizePending)
+	return cond == nil || cond.Status != v1.ConditionTrue
+}
+
+func getPVCCondition(pvc *v1.PersistentVolumeClaim, t v1.PersistentVolumeClaimConditionType) *v1.PersistentVolumeClaimCondition {
+	for i := range pvc.Status.Conditions {
+		if pvc.Status.Conditions[i].Type == t {
+			return &pvc.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestIsPVCExpanded(t *testing.T) {
+	pvc := func(capacity string, conds ...v1.PersistentVolumeClaimCondition) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{Status: v1.PersistentVolumeClaimStatus{
+			Capacity:   v1.ResourceList{v1.ResourceStorage: resource.MustParse(capacity)},
+			Conditions: conds,
+		}}
+	}
+	pending := v1.PersistentVolumeClaimCondition{Type: pvcFileSystemResizePending, Status: v1.ConditionTrue}
+
+	tests := []struct {
+		pvc  *v1.PersistentVolumeClaim
+		want bool
+	}{
+		{pvc("1Gi"), false},
+		{pvc("2Gi"), true},
+		{pvc("4Gi"), true},
+		{pvc("2Gi", pending), false},
+		{&v1.PersistentVolumeClaim{}, false},
+	}
+	for i, tt := range tests {
+		if got := isPVCExpanded(tt.pvc, resource.MustParse("2Gi")); got != tt.want {
+			t.Errorf("#%d: isPVCExpanded = %v, want %v", i, got, tt.want)
+		}
+	}
+}
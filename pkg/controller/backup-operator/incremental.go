@@ -0,0 +1,239 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+	"github.com/coreos/etcd-operator/pkg/backup/writer"
+	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"github.com/coreos/etcd/clientv3"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// incrementalBackup is the running continuous backup of an EtcdBackup.
+type incrementalBackup struct {
+	spec   api.BackupSpec
+	stopCh chan struct{}
+}
+
+// syncIncremental starts the continuous backup of eb, or restarts it if its spec changed.
+func (b *Backup) syncIncremental(key string, eb *api.EtcdBackup) {
+	if ib, ok := b.incrementals[key]; ok {
+		if reflect.DeepEqual(ib.spec, eb.Spec) {
+			return
+		}
+		b.stopIncremental(key)
+	}
+	ib := &incrementalBackup{
+		spec:   *eb.Spec.DeepCopy(),
+		stopCh: make(chan struct{}),
+	}
+	b.incrementals[key] = ib
+	b.logger.Infof("starting incremental backup (%v)", key)
+	go b.runIncremental(eb.DeepCopy(), ib)
+}
+
+// stopIncremental stops the continuous backup of the given EtcdBackup, if any.
+func (b *Backup) stopIncremental(key string) {
+	ib, ok := b.incrementals[key]
+	if !ok {
+		return
+	}
+	close(ib.stopCh)
+	delete(b.incrementals, key)
+	b.logger.Infof("stopped incremental backup (%v)", key)
+}
+
+// runIncremental saves chains of a full snapshot followed by segments of changes until stopped.
+// The first chain resumes after the last saved segment of the same chain, if any.
+func (b *Backup) runIncremental(eb *api.EtcdBackup, ib *incrementalBackup) {
+	resumeRev := resumeRevision(eb)
+	for {
+		err := b.runBackupChain(eb, resumeRev, ib.stopCh)
+		select {
+		case <-ib.stopCh:
+			return
+		default:
+		}
+		if err != nil {
+			b.logger.Errorf("incremental backup (%v) failed: %v", eb.Name, err)
+			b.recorder.Eventf(eb, v1.EventTypeWarning, k8sutil.EventReasonBackupFailed, "Incremental backup failed: %v", err)
+			b.updateBackupStatus(eb.Name, func(bs *api.BackupStatus) {
+				bs.Succeeded = false
+				bs.Reason = err.Error()
+				bs.SetFailed(bs.Reason)
+			})
+			select {
+			case <-ib.stopCh:
+				return
+			case <-time.After(eb.Spec.Incremental.SegmentInterval()):
+			}
+		}
+		// A failed chain can't tell which changes were lost, so every chain
+		// after the first starts with a full snapshot.
+		resumeRev = 0
+	}
+}
+
+// resumeRevision returns the revision after which the changes of eb are saved, or 0
+// if they are not saved with its current spec and a new chain must be started.
+// Segments saved under another path or from other endpoints have no full snapshot
+// under the current path, or revisions of another cluster.
+func resumeRevision(eb *api.EtcdBackup) int64 {
+	st := eb.Status
+	if st.ChainPath != eb.Spec.S3.Path || !reflect.DeepEqual(st.ChainEtcdEndpoints, eb.Spec.EtcdEndpoints) {
+		return 0
+	}
+	return st.LastSegmentRevision
+}
+
+// setLastSegment records that the changes up to rev are saved with the given spec.
+func setLastSegment(bs *api.BackupStatus, spec api.BackupSpec, rev int64) {
+	bs.LastSegmentRevision = rev
+	bs.ChainPath = spec.S3.Path
+	bs.ChainEtcdEndpoints = spec.EtcdEndpoints
+}
+
+// runBackupChain saves a full snapshot, unless resumeRev is set, and then saves the
+// changes made after it as segments until the full backup interval has passed.
+func (b *Backup) runBackupChain(eb *api.EtcdBackup, resumeRev int64, stopCh <-chan struct{}) error {
+	spec := eb.Spec
+	cli, err := s3factory.NewClientFromSecret(b.kubecli, b.namespace, spec.S3.Endpoint, spec.S3.AWSSecret)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+	w := writer.NewS3Writer(cli.S3)
+
+	tlsConfig, creds, err := getClientSecrets(b.kubecli, b.namespace, spec.ClientTLSSecret, spec.ClientCredentialsSecret)
+	if err != nil {
+		return err
+	}
+
+	lastRev := resumeRev
+	if lastRev == 0 {
+		b.updateBackupStatus(eb.Name, func(bs *api.BackupStatus) { bs.SetPhase(api.BackupPhaseSavingSnapshot) })
+		sw := &sizeRecordingWriter{Writer: w}
+		bm := backup.NewBackupManagerFromWriter(b.kubecli, sw, tlsConfig, creds, spec.EtcdEndpoints, b.namespace)
+		p, rev, etcdVersion, err := bm.SaveSnapUnderPrefix(context.Background(), spec.S3.Path)
+		if err != nil {
+			return fmt.Errorf("failed to save full snapshot: %v", err)
+		}
+		if spec.Verify {
+			b.updateBackupStatus(eb.Name, func(bs *api.BackupStatus) { bs.SetPhase(api.BackupPhaseVerifying) })
+			v, err := verifyS3Backup(cli.S3, p, rev)
+			b.updateBackupStatus(eb.Name, func(bs *api.BackupStatus) { bs.Verification = v })
+			if err != nil {
+				return err
+			}
+		}
+		b.recorder.Eventf(eb, v1.EventTypeNormal, k8sutil.EventReasonBackupSucceeded, "Saved full snapshot %s at revision %d", p, rev)
+		b.updateBackupStatus(eb.Name, func(bs *api.BackupStatus) {
+			bs.Succeeded = true
+			bs.Reason = ""
+			bs.EtcdVersion = etcdVersion
+			bs.EtcdRevision = rev
+			setLastSegment(bs, spec, rev)
+			bs.BytesTransferred = sw.size
+		})
+		lastRev = rev
+	}
+	b.updateBackupStatus(eb.Name, func(bs *api.BackupStatus) { bs.SetPhase(api.BackupPhaseStreaming) })
+
+	etcdcli, err := clientv3.New(etcdutil.NewClientConfig(spec.EtcdEndpoints, tlsConfig, creds))
+	if err != nil {
+		return fmt.Errorf("failed to create etcd client: %v", err)
+	}
+	defer etcdcli.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wch := etcdcli.Watch(ctx, "\x00", clientv3.WithFromKey(), clientv3.WithRev(lastRev+1))
+
+	var pending []backup.Change
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		endRev := pending[len(pending)-1].Revision
+		var buf bytes.Buffer
+		if err := backup.EncodeSegment(&buf, pending); err != nil {
+			return err
+		}
+		p := path.Join(spec.S3.Path, util.MakeSegmentName(lastRev+1, endRev))
+		if _, err := w.Write(p, &buf); err != nil {
+			return fmt.Errorf("failed to write segment (%s): %v", p, err)
+		}
+		pending, lastRev = nil, endRev
+		b.updateBackupStatus(eb.Name, func(bs *api.BackupStatus) {
+			bs.Succeeded = true
+			bs.Reason = ""
+			setLastSegment(bs, spec, endRev)
+		})
+		return nil
+	}
+
+	segmentTicker := time.NewTicker(spec.Incremental.SegmentInterval())
+	defer segmentTicker.Stop()
+	fullBackupTimer := time.NewTimer(spec.Incremental.FullBackupInterval())
+	defer fullBackupTimer.Stop()
+	for {
+		select {
+		case wresp, ok := <-wch:
+			if !ok {
+				return errors.New("watch is closed")
+			}
+			if err := wresp.Err(); err != nil {
+				return fmt.Errorf("watch from revision %d failed: %v", lastRev+1, err)
+			}
+			pending = append(pending, backup.ChangesFromEvents(wresp.Events, time.Now())...)
+		case <-segmentTicker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case <-fullBackupTimer.C:
+			return flush()
+		case <-stopCh:
+			return flush()
+		}
+	}
+}
+
+// updateBackupStatus updates the status of the latest version of the given EtcdBackup.
+func (b *Backup) updateBackupStatus(name string, update func(*api.BackupStatus)) {
+	eb, err := b.backupCRCli.EtcdV1beta2().EtcdBackups(b.namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		b.logger.Warningf("failed to get backup CR %v: %v", name, err)
+		return
+	}
+	update(&eb.Status)
+	if _, err := b.backupCRCli.EtcdV1beta2().EtcdBackups(b.namespace).Update(eb); err != nil {
+		b.logger.Warningf("failed to update status of backup CR %v : (%v)", name, err)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+)
+
+func TestResumeRevision(t *testing.T) {
+	spec := api.BackupSpec{
+		EtcdEndpoints: []string{"https://example-client:2379"},
+		BackupSource:  api.BackupSource{S3: &api.S3BackupSource{Path: "bucket/example"}},
+	}
+	saved := api.BackupStatus{LastSegmentRevision: 120}
+	setLastSegment(&saved, spec, 120)
+
+	moved := *spec.DeepCopy()
+	moved.S3.Path = "bucket/other"
+	otherCluster := *spec.DeepCopy()
+	otherCluster.EtcdEndpoints = []string{"https://other-client:2379"}
+
+	tests := []struct {
+		spec   api.BackupSpec
+		status api.BackupStatus
+		want   int64
+	}{
+		{spec: spec, status: saved, want: 120},
+		// The spec changed since the changes were saved.
+		{spec: moved, status: saved},
+		{spec: otherCluster, status: saved},
+		// Nothing was saved yet.
+		{spec: spec},
+		// Saved without the chain of the changes.
+		{spec: spec, status: api.BackupStatus{LastSegmentRevision: 120}},
+	}
+	for i, tt := range tests {
+		eb := &api.EtcdBackup{Spec: tt.spec, Status: tt.status}
+		if got := resumeRevision(eb); got != tt.want {
+			t.Errorf("#%d: expect resume revision %d, get %d", i, tt.want, got)
+		}
+	}
+}
@@ -29,6 +29,7 @@ import (
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 )
 
@@ -44,19 +45,27 @@ type Backup struct {
 	kubecli     kubernetes.Interface
 	backupCRCli versioned.Interface
 	kubeExtCli  apiextensionsclient.Interface
+	recorder    record.EventRecorder
+
+	// incrementals are the running continuous backups by EtcdBackup key.
+	// They are only touched by the single worker.
+	incrementals map[string]*incrementalBackup
 
 	createCRD bool
 }
 
 // New creates a backup operator.
 func New(createCRD bool) *Backup {
+	kubecli := k8sutil.MustNewKubeClient()
 	return &Backup{
-		logger:      logrus.WithField("pkg", "controller"),
-		namespace:   os.Getenv(constants.EnvOperatorPodNamespace),
-		kubecli:     k8sutil.MustNewKubeClient(),
-		backupCRCli: client.MustNewInCluster(),
-		kubeExtCli:  k8sutil.MustNewKubeExtClient(),
-		createCRD:   createCRD,
+		logger:       logrus.WithField("pkg", "controller"),
+		namespace:    os.Getenv(constants.EnvOperatorPodNamespace),
+		kubecli:      kubecli,
+		backupCRCli:  client.MustNewInCluster(),
+		kubeExtCli:   k8sutil.MustNewKubeExtClient(),
+		recorder:     k8sutil.NewEventRecorder(kubecli, os.Getenv(constants.EnvOperatorPodName)),
+		incrementals: make(map[string]*incrementalBackup),
+		createCRD:    createCRD,
 	}
 }
 
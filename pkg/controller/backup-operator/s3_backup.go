@@ -15,44 +15,122 @@
 package controller
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/backup"
+	"github.com/coreos/etcd-operator/pkg/backup/reader"
 	"github.com/coreos/etcd-operator/pkg/backup/writer"
 	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
 	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 
+	"github.com/aws/aws-sdk-go/service/s3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
 // TODO: replace this with generic backend interface for other options (PV, Azure)
 // handleS3 saves etcd cluster's backup to specificed S3 path.
-func handleS3(kubecli kubernetes.Interface, s *api.S3BackupSource, endpoints []string, clientTLSSecret, namespace string) (*api.BackupStatus, error) {
+// If verify is true, the saved backup is read back and checked to be restorable,
+// after calling onVerify.
+func handleS3(kubecli kubernetes.Interface, s *api.S3BackupSource, endpoints []string, clientTLSSecret, clientCredentialsSecret, namespace string, verify bool, onVerify func()) (*api.BackupStatus, error) {
 	cli, err := s3factory.NewClientFromSecret(kubecli, namespace, s.Endpoint, s.AWSSecret)
 	if err != nil {
 		return nil, err
 	}
 	defer cli.Close()
 
+	tlsConfig, creds, err := getClientSecrets(kubecli, namespace, clientTLSSecret, clientCredentialsSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &sizeRecordingWriter{Writer: writer.NewS3Writer(cli.S3)}
+	bm := backup.NewBackupManagerFromWriter(kubecli, w, tlsConfig, creds, endpoints, namespace)
+	rev, etcdVersion, err := bm.SaveSnap(context.Background(), s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save snapshot (%v)", err)
+	}
+	bs := &api.BackupStatus{EtcdVersion: etcdVersion, EtcdRevision: rev}
+	bs.BytesTransferred = w.size
+	if verify {
+		onVerify()
+		bs.Verification, err = verifyS3Backup(cli.S3, s.Path, rev)
+		if err != nil {
+			return bs, err
+		}
+	}
+	return bs, nil
+}
+
+// verifyS3Backup reads back the snapshot saved at the given S3 path and checks
+// that etcd can restore it to at least revision rev.
+// The returned verification is set even if the check fails.
+func verifyS3Backup(s3cli *s3.S3, p string, rev int64) (*api.BackupVerification, error) {
+	v := &api.BackupVerification{VerifyTime: metav1.Now()}
+	err := func() error {
+		rc, err := reader.NewS3Reader(s3cli).Open(p)
+		if err != nil {
+			return fmt.Errorf("failed to read back snapshot: %v", err)
+		}
+		defer rc.Close()
+
+		st, err := backup.VerifySnapshot(rc)
+		if err != nil {
+			return err
+		}
+		v.Hash, v.Revision, v.TotalKey = st.Hash, st.Revision, st.TotalKey
+		if st.Revision < rev {
+			return fmt.Errorf("snapshot is at revision %d, older than backup revision %d", st.Revision, rev)
+		}
+		return nil
+	}()
+	if err != nil {
+		v.Reason = err.Error()
+		return v, fmt.Errorf("backup verification failed: %v", err)
+	}
+	v.Verified = true
+	return v, nil
+}
+
+// sizeRecordingWriter records the size of the last file written.
+type sizeRecordingWriter struct {
+	writer.Writer
+	size int64
+}
+
+func (w *sizeRecordingWriter) Write(path string, r io.Reader) (int64, error) {
+	n, err := w.Writer.Write(path, r)
+	w.size = n
+	return n, err
+}
+
+// getClientSecrets returns the TLS config and credentials to talk to etcd with,
+// from the given secrets. Either is nil if its secret is not given.
+func getClientSecrets(kubecli kubernetes.Interface, namespace, clientTLSSecret, clientCredentialsSecret string) (*tls.Config, *etcdutil.Credentials, error) {
 	var tlsConfig *tls.Config
 	if len(clientTLSSecret) != 0 {
 		d, err := k8sutil.GetTLSDataFromSecret(kubecli, namespace, clientTLSSecret)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get TLS data from secret (%v): %v", clientTLSSecret, err)
+			return nil, nil, fmt.Errorf("failed to get TLS data from secret (%v): %v", clientTLSSecret, err)
 		}
 		tlsConfig, err = etcdutil.NewTLSConfig(d.CertData, d.KeyData, d.CAData)
 		if err != nil {
-			return nil, fmt.Errorf("failed to constructs tls config: %v", err)
+			return nil, nil, fmt.Errorf("failed to constructs tls config: %v", err)
 		}
 	}
 
-	bm := backup.NewBackupManagerFromWriter(kubecli, writer.NewS3Writer(cli.S3), tlsConfig, endpoints, namespace)
-	rev, etcdVersion, err := bm.SaveSnap(s.Path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to save snapshot (%v)", err)
+	var creds *etcdutil.Credentials
+	if len(clientCredentialsSecret) != 0 {
+		var err error
+		creds, err = k8sutil.GetCredentialsFromSecret(kubecli, namespace, clientCredentialsSecret, "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get credentials from secret (%v): %v", clientCredentialsSecret, err)
+		}
 	}
-	return &api.BackupStatus{EtcdVersion: etcdVersion, EtcdRevision: rev}, nil
+	return tlsConfig, creds, nil
 }
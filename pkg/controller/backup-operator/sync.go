@@ -15,9 +15,15 @@
 package controller
 
 import (
+	"fmt"
+
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -57,34 +63,91 @@ func (b *Backup) processItem(key string) error {
 		return err
 	}
 	if !exists {
+		b.stopIncremental(key)
 		return nil
 	}
 
 	eb := obj.(*api.EtcdBackup)
-	// don't process the CR if it has a status since
-	// having a status means that the backup is either made or failed.
-	if eb.Status.Succeeded || len(eb.Status.Reason) != 0 {
+	if eb.Spec.Incremental != nil {
+		if err := eb.Spec.Validate(); err != nil {
+			b.stopIncremental(key)
+			if eb.Status.Reason != err.Error() {
+				b.reportBackupStatus(nil, err, eb)
+			}
+			return nil
+		}
+		b.syncIncremental(key, eb)
+		return nil
+	}
+	b.stopIncremental(key)
+
+	if !eb.ShouldRun() && !eb.Status.InProgress() {
+		return nil
+	}
+	// The cache may lag behind the status updates of a backup, so decide on the latest CR.
+	eb, err = b.backupCRCli.EtcdV1beta2().EtcdBackups(b.namespace).Get(eb.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if eb.Status.InProgress() {
+		// Only this worker processes the CR, so the backup was interrupted, e.g. by a
+		// restart of the operator. The user can retry it with the retry annotation.
+		b.reportBackupStatus(nil, fmt.Errorf("backup was interrupted in phase %s", eb.Status.Phase), eb)
+		return nil
+	}
+	// Don't process the CR if it has completed, or failed and wasn't asked to retry.
+	if !eb.ShouldRun() {
+		return nil
+	}
+	if len(eb.Status.Reason) != 0 {
+		b.logger.Infof("retrying failed etcd backup (%v): %s=%s", key, api.RetryAnnotation, eb.Annotations[api.RetryAnnotation])
+	}
+	eb.Status = api.BackupStatus{}
+	eb.Status.ObservedRetry = eb.Annotations[api.RetryAnnotation]
+	b.setPhase(eb, api.OperationPhasePending)
+	if err := eb.Spec.Validate(); err != nil {
+		b.reportBackupStatus(nil, err, eb)
 		return nil
 	}
-	bs, err := b.handleBackup(&eb.Spec)
+	b.setPhase(eb, api.BackupPhaseSavingSnapshot)
+	bs, err := b.handleBackup(eb)
 	// Report backup status
 	b.reportBackupStatus(bs, err, eb)
 	return err
 }
 
 func (b *Backup) reportBackupStatus(bs *api.BackupStatus, berr error, eb *api.EtcdBackup) {
+	if bs != nil {
+		eb.Status.Verification = bs.Verification
+		eb.Status.BytesTransferred = bs.BytesTransferred
+	}
 	if berr != nil {
 		eb.Status.Succeeded = false
 		eb.Status.Reason = berr.Error()
+		eb.Status.SetFailed(eb.Status.Reason)
+		b.recorder.Eventf(eb, v1.EventTypeWarning, k8sutil.EventReasonBackupFailed, "Backup failed: %v", berr)
 	} else {
 		eb.Status.Succeeded = true
+		eb.Status.SetCompleted()
 		eb.Status.EtcdRevision = bs.EtcdRevision
 		eb.Status.EtcdVersion = bs.EtcdVersion
+		b.recorder.Eventf(eb, v1.EventTypeNormal, k8sutil.EventReasonBackupSucceeded, "Saved backup at revision %d of etcd %s", bs.EtcdRevision, bs.EtcdVersion)
 	}
-	_, err := b.backupCRCli.EtcdV1beta2().EtcdBackups(b.namespace).Update(eb)
-	if err != nil {
-		b.logger.Warningf("failed to update status of backup CR %v : (%v)", eb.Name, err)
-	}
+	b.saveStatus(eb)
+}
+
+// setPhase moves the one-shot backup to phase p and saves its status.
+func (b *Backup) setPhase(eb *api.EtcdBackup, p api.OperationPhase) {
+	eb.Status.SetPhase(p)
+	b.saveStatus(eb)
+}
+
+// saveStatus saves the status of eb on the latest version of the CR.
+func (b *Backup) saveStatus(eb *api.EtcdBackup) {
+	b.updateBackupStatus(eb.Name, func(bs *api.BackupStatus) { eb.Status.DeepCopyInto(bs) })
 }
 
 func (b *Backup) handleErr(err error, key interface{}) {
@@ -111,14 +174,12 @@ func (b *Backup) handleErr(err error, key interface{}) {
 	b.logger.Infof("Dropping etcd backup (%v) out of the queue: %v", key, err)
 }
 
-func (b *Backup) handleBackup(spec *api.BackupSpec) (*api.BackupStatus, error) {
+func (b *Backup) handleBackup(eb *api.EtcdBackup) (*api.BackupStatus, error) {
+	spec := &eb.Spec
+	onVerify := func() { b.setPhase(eb, api.BackupPhaseVerifying) }
 	switch spec.StorageType {
 	case api.BackupStorageTypeS3:
-		bs, err := handleS3(b.kubecli, spec.S3, spec.EtcdEndpoints, spec.ClientTLSSecret, b.namespace)
-		if err != nil {
-			return nil, err
-		}
-		return bs, nil
+		return handleS3(b.kubecli, spec.S3, spec.EtcdEndpoints, spec.ClientTLSSecret, spec.ClientCredentialsSecret, b.namespace, spec.Verify, onVerify)
 	default:
 		logrus.Fatalf("unknown StorageType: %v", spec.StorageType)
 	}
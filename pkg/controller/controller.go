@@ -27,6 +27,7 @@ import (
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	kwatch "k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 )
 
 var initRetryWaitTime = 30 * time.Second
@@ -50,6 +51,8 @@ type Config struct {
 	KubeExtCli     apiextensionsclient.Interface
 	EtcdCRCli      versioned.Interface
 	CreateCRD      bool
+	// EventRecorder records the events of the clusters.
+	EventRecorder record.EventRecorder
 }
 
 func New(cfg Config) *Controller {
@@ -116,6 +119,7 @@ func (c *Controller) makeClusterConfig() cluster.Config {
 		ServiceAccount: c.Config.ServiceAccount,
 		KubeCli:        c.Config.KubeCli,
 		EtcdCRCli:      c.Config.EtcdCRCli,
+		EventRecorder:  c.Config.EventRecorder,
 	}
 }
 
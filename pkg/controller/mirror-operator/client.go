@@ -0,0 +1,73 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"github.com/coreos/etcd/clientv3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newClient creates an etcd client for the given mirror endpoint. An EtcdCluster
+// is accessed through its client service, with its operator TLS secret and root
+// credentials unless other secrets are given.
+func (m *Mirror) newClient(me api.MirrorEndpoint) (*clientv3.Client, error) {
+	endpoints := me.EtcdEndpoints
+	tlsSecret, credsSecret, defaultUsername := me.ClientTLSSecret, me.ClientCredentialsSecret, ""
+	if len(me.EtcdCluster) != 0 {
+		cl, err := m.etcdCRCli.EtcdV1beta2().EtcdClusters(m.namespace).Get(me.EtcdCluster, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get etcd cluster (%s): %v", me.EtcdCluster, err)
+		}
+		scheme := "http"
+		if cl.Spec.TLS.IsSecureClient() {
+			scheme = "https"
+			if len(tlsSecret) == 0 {
+				tlsSecret = cl.Spec.TLS.Static.OperatorSecret
+			}
+		}
+		endpoints = []string{fmt.Sprintf("%s://%s.%s.svc:%d", scheme, k8sutil.ClientServiceName(cl.Name), cl.Namespace, k8sutil.EtcdClientPort)}
+		if cl.Spec.Auth != nil && cl.Status.AuthEnabled && len(credsSecret) == 0 {
+			credsSecret, defaultUsername = cl.Spec.Auth.RootSecret, api.AuthRootUser
+		}
+	}
+
+	var tc *tls.Config
+	if len(tlsSecret) != 0 {
+		d, err := k8sutil.GetTLSDataFromSecret(m.kubecli, m.namespace, tlsSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TLS data from secret (%v): %v", tlsSecret, err)
+		}
+		tc, err = etcdutil.NewTLSConfig(d.CertData, d.KeyData, d.CAData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to constructs tls config: %v", err)
+		}
+	}
+	var creds *etcdutil.Credentials
+	if len(credsSecret) != 0 {
+		var err error
+		creds, err = k8sutil.GetCredentialsFromSecret(m.kubecli, m.namespace, credsSecret, defaultUsername)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get credentials from secret (%v): %v", credsSecret, err)
+		}
+	}
+	return clientv3.New(etcdutil.NewClientConfig(endpoints, tc, creds))
+}
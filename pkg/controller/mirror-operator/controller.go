@@ -0,0 +1,84 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func (m *Mirror) run(ctx context.Context) {
+	source := cache.NewListWatchFromClient(
+		m.etcdCRCli.EtcdV1beta2().RESTClient(),
+		api.EtcdMirrorResourcePlural,
+		m.namespace,
+		fields.Everything(),
+	)
+
+	m.queue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "etcd-mirror-operator")
+	m.indexer, m.informer = cache.NewIndexerInformer(source, &api.EtcdMirror{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc:    m.onAdd,
+		UpdateFunc: m.onUpdate,
+		DeleteFunc: m.onDelete,
+	}, cache.Indexers{})
+
+	defer m.queue.ShutDown()
+
+	m.logger.Info("starting mirror controller")
+	go m.informer.Run(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), m.informer.HasSynced) {
+		return
+	}
+
+	const numWorkers = 1
+	for i := 0; i < numWorkers; i++ {
+		go wait.Until(m.runWorker, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+	m.logger.Info("stopping mirror controller")
+}
+
+func (m *Mirror) onAdd(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		panic(err)
+	}
+	m.queue.Add(key)
+}
+
+func (m *Mirror) onUpdate(oldObj, newObj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(newObj)
+	if err != nil {
+		panic(err)
+	}
+	m.queue.Add(key)
+}
+
+func (m *Mirror) onDelete(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		panic(err)
+	}
+	m.queue.Add(key)
+}
@@ -0,0 +1,38 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	mirrorRevision = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "mirror",
+		Name:      "revision",
+		Help:      "Last revision of the source applied to the destination",
+	}, []string{"mirror"})
+
+	mirrorLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_operator",
+		Subsystem: "mirror",
+		Name:      "lag_revisions",
+		Help:      "Number of revisions of the source not yet applied to the destination",
+	}, []string{"mirror"})
+)
+
+func init() {
+	prometheus.MustRegister(mirrorRevision)
+	prometheus.MustRegister(mirrorLag)
+}
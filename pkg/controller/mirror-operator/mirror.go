@@ -0,0 +1,305 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// copyBatchSize is the number of keys read from an etcd cluster at a time.
+	copyBatchSize = 1000
+	// statusInterval is how often the revision is saved in the EtcdMirror status.
+	statusInterval = 10 * time.Second
+	// retryInterval is how long a failed mirror waits before it is retried.
+	retryInterval = 10 * time.Second
+)
+
+// runner mirrors the keys of one EtcdMirror until it is stopped.
+type runner struct {
+	m      *Mirror
+	logger *logrus.Entry
+
+	name string
+	spec api.MirrorSpec
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// rev is the last revision of the source applied to the destination.
+	// 0 means the keys of the source must be copied first.
+	rev int64
+}
+
+func (m *Mirror) newRunner(em *api.EtcdMirror, rev int64) *runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &runner{
+		m:      m,
+		logger: m.logger.WithField("etcd-mirror", em.Name),
+		name:   em.Name,
+		spec:   *em.Spec.DeepCopy(),
+		ctx:    ctx,
+		cancel: cancel,
+		rev:    rev,
+	}
+}
+
+// stop stops the runner. It does not wait for the runner to return.
+func (r *runner) stop() {
+	r.cancel()
+	mirrorRevision.DeleteLabelValues(r.name)
+	mirrorLag.DeleteLabelValues(r.name)
+}
+
+func (r *runner) run() {
+	r.logger.Infof("start mirroring from revision %d", r.rev)
+	for {
+		err := r.mirror()
+		if r.ctx.Err() != nil {
+			r.logger.Info("stop mirroring")
+			return
+		}
+		r.logger.Errorf("mirror failed: %v", err)
+		r.reportStatus(api.MirrorPhaseFailed, err.Error())
+
+		select {
+		case <-r.ctx.Done():
+			r.logger.Info("stop mirroring")
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// mirror copies the keys of the source to the destination if needed, and then
+// applies the changes of the source to the destination until it fails.
+func (r *runner) mirror() error {
+	src, err := r.m.newClient(r.spec.Source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := r.m.newClient(r.spec.Destination)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	for {
+		if r.rev == 0 {
+			if err := r.copy(src, dst); err != nil {
+				return err
+			}
+		}
+		err := r.watch(src, dst)
+		if err != rpctypes.ErrCompacted && err != rpctypes.ErrFutureRev {
+			return err
+		}
+		// The changes since r.rev are no longer available from the source,
+		// or the source went back to an older revision.
+		r.logger.Warningf("cannot watch the source from revision %d (%v), copying again", r.rev+1, err)
+		r.rev = 0
+	}
+}
+
+// copy copies all keys of the source at its current revision to the destination,
+// and deletes the keys of the destination that are not in the source.
+func (r *runner) copy(src, dst *clientv3.Client) error {
+	r.reportStatus(api.MirrorPhaseCopying, "")
+
+	var rev int64
+	copied := make(map[string]bool)
+	key, end := keyRange(r.spec.Prefix)
+	for {
+		// All keys are read at the revision of the first read, which the
+		// watch continues from.
+		opts := []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithLimit(copyBatchSize)}
+		if rev != 0 {
+			opts = append(opts, clientv3.WithRev(rev))
+		}
+		ctx, cancel := context.WithTimeout(r.ctx, constants.DefaultRequestTimeout)
+		resp, err := src.Get(ctx, key, opts...)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if rev == 0 {
+			rev = resp.Header.Revision
+		}
+		for _, kv := range resp.Kvs {
+			dk := r.destinationKey(string(kv.Key))
+			ctx, cancel := context.WithTimeout(r.ctx, constants.DefaultRequestTimeout)
+			_, err := dst.Put(ctx, dk, string(kv.Value))
+			cancel()
+			if err != nil {
+				return err
+			}
+			copied[dk] = true
+		}
+		if !resp.More {
+			break
+		}
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+
+	if err := r.deleteStaleKeys(dst, copied); err != nil {
+		return err
+	}
+	r.rev = rev
+	r.logger.Infof("copied %d keys at revision %d", len(copied), rev)
+	r.reportStatus(api.MirrorPhaseMirroring, "")
+	return nil
+}
+
+// deleteStaleKeys deletes the keys of the destination that were not copied from the source.
+func (r *runner) deleteStaleKeys(dst *clientv3.Client, copied map[string]bool) error {
+	var stale []string
+	key, end := keyRange(r.destinationPrefix())
+	for {
+		ctx, cancel := context.WithTimeout(r.ctx, constants.DefaultRequestTimeout)
+		resp, err := dst.Get(ctx, key, clientv3.WithRange(end), clientv3.WithLimit(copyBatchSize), clientv3.WithKeysOnly())
+		cancel()
+		if err != nil {
+			return err
+		}
+		for _, kv := range resp.Kvs {
+			if !copied[string(kv.Key)] {
+				stale = append(stale, string(kv.Key))
+			}
+		}
+		if !resp.More {
+			break
+		}
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+
+	for _, k := range stale {
+		ctx, cancel := context.WithTimeout(r.ctx, constants.DefaultRequestTimeout)
+		_, err := dst.Delete(ctx, k)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watch applies the changes of the source after r.rev to the destination.
+func (r *runner) watch(src, dst *clientv3.Client) error {
+	ctx, cancel := context.WithCancel(clientv3.WithRequireLeader(r.ctx))
+	defer cancel()
+	key, end := keyRange(r.spec.Prefix)
+	wch := src.Watch(ctx, key, clientv3.WithRange(end), clientv3.WithRev(r.rev+1), clientv3.WithProgressNotify())
+
+	ticker := time.NewTicker(statusInterval)
+	defer ticker.Stop()
+	reported := r.rev
+	for {
+		select {
+		case <-ticker.C:
+			if r.rev != reported {
+				r.reportStatus(api.MirrorPhaseMirroring, "")
+				reported = r.rev
+			}
+		case wresp, ok := <-wch:
+			if !ok {
+				if r.ctx.Err() != nil {
+					return r.ctx.Err()
+				}
+				return errors.New("watch channel closed")
+			}
+			if err := wresp.Err(); err != nil {
+				return err
+			}
+			for _, ev := range wresp.Events {
+				if err := r.apply(dst, ev); err != nil {
+					return err
+				}
+				r.rev = ev.Kv.ModRevision
+			}
+			if wresp.IsProgressNotify() {
+				r.rev = wresp.Header.Revision
+			}
+			mirrorRevision.WithLabelValues(r.name).Set(float64(r.rev))
+			mirrorLag.WithLabelValues(r.name).Set(float64(wresp.Header.Revision - r.rev))
+		}
+	}
+}
+
+// apply applies a change of the source to the destination.
+func (r *runner) apply(dst *clientv3.Client, ev *clientv3.Event) error {
+	ctx, cancel := context.WithTimeout(r.ctx, constants.DefaultRequestTimeout)
+	defer cancel()
+	dk := r.destinationKey(string(ev.Kv.Key))
+	var err error
+	switch ev.Type {
+	case clientv3.EventTypePut:
+		_, err = dst.Put(ctx, dk, string(ev.Kv.Value))
+	case clientv3.EventTypeDelete:
+		_, err = dst.Delete(ctx, dk)
+	}
+	return err
+}
+
+// reportStatus saves the phase, the reason and the revision in the EtcdMirror status.
+func (r *runner) reportStatus(phase api.MirrorPhase, reason string) {
+	emCli := r.m.etcdCRCli.EtcdV1beta2().EtcdMirrors(r.m.namespace)
+	em, err := emCli.Get(r.name, metav1.GetOptions{})
+	if err != nil {
+		r.logger.Warningf("failed to get mirror CR: %v", err)
+		return
+	}
+	em.Status.Phase = phase
+	em.Status.Reason = reason
+	if em.Status.Revision != r.rev {
+		em.Status.Revision = r.rev
+		em.Status.LastSyncTime = time.Now().Format(time.RFC3339)
+	}
+	if _, err = emCli.Update(em); err != nil {
+		r.logger.Warningf("failed to update status of mirror CR: %v", err)
+	}
+}
+
+func (r *runner) destinationPrefix() string {
+	if len(r.spec.DestinationPrefix) == 0 {
+		return r.spec.Prefix
+	}
+	return r.spec.DestinationPrefix
+}
+
+// destinationKey returns the key in the destination of the given key of the source.
+func (r *runner) destinationKey(key string) string {
+	return r.destinationPrefix() + strings.TrimPrefix(key, r.spec.Prefix)
+}
+
+// keyRange returns the range of the keys with the given prefix.
+func keyRange(prefix string) (key, end string) {
+	if len(prefix) == 0 {
+		// The range from "\x00" to "\x00" is all keys.
+		return "\x00", "\x00"
+	}
+	return prefix, clientv3.GetPrefixRangeEnd(prefix)
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+)
+
+func TestDestinationKey(t *testing.T) {
+	tests := []struct {
+		prefix, dstPrefix string
+		key               string
+		want              string
+	}{
+		{"", "", "/app/a", "/app/a"},
+		{"/app/", "", "/app/a", "/app/a"},
+		{"/app/", "/replica/", "/app/a", "/replica/a"},
+		{"", "/replica", "/app/a", "/replica/app/a"},
+	}
+	for i, tt := range tests {
+		r := &runner{spec: api.MirrorSpec{Prefix: tt.prefix, DestinationPrefix: tt.dstPrefix}}
+		if got := r.destinationKey(tt.key); got != tt.want {
+			t.Errorf("#%d: destinationKey(%q) = %q, want %q", i, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestKeyRange(t *testing.T) {
+	tests := []struct {
+		prefix string
+		wKey   string
+		wEnd   string
+	}{
+		{"", "\x00", "\x00"},
+		{"/app/", "/app/", "/app0"},
+		{"a\xff", "a\xff", "b"},
+	}
+	for i, tt := range tests {
+		key, end := keyRange(tt.prefix)
+		if key != tt.wKey || end != tt.wEnd {
+			t.Errorf("#%d: keyRange(%q) = (%q, %q), want (%q, %q)", i, tt.prefix, key, end, tt.wKey, tt.wEnd)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/client"
+	"github.com/coreos/etcd-operator/pkg/generated/clientset/versioned"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"github.com/sirupsen/logrus"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+type Mirror struct {
+	logger *logrus.Entry
+
+	namespace string
+	// k8s workqueue pattern
+	indexer  cache.Indexer
+	informer cache.Controller
+	queue    workqueue.RateLimitingInterface
+
+	kubecli    kubernetes.Interface
+	etcdCRCli  versioned.Interface
+	kubeExtCli apiextensionsclient.Interface
+
+	// runners are the running mirrors by EtcdMirror key.
+	runners map[string]*runner
+
+	createCRD bool
+}
+
+// New creates a mirror operator.
+func New(createCRD bool, namespace string) *Mirror {
+	return &Mirror{
+		logger:     logrus.WithField("pkg", "controller"),
+		namespace:  namespace,
+		kubecli:    k8sutil.MustNewKubeClient(),
+		etcdCRCli:  client.MustNewInCluster(),
+		kubeExtCli: k8sutil.MustNewKubeExtClient(),
+		runners:    make(map[string]*runner),
+		createCRD:  createCRD,
+	}
+}
+
+// Start starts the mirror operator.
+func (m *Mirror) Start(ctx context.Context) error {
+	if m.createCRD {
+		if err := m.initCRD(); err != nil {
+			return err
+		}
+	}
+
+	go m.run(ctx)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (m *Mirror) initCRD() error {
+	err := k8sutil.CreateCRD(m.kubeExtCli, api.EtcdMirrorCRDName, api.EtcdMirrorResourceKind, api.EtcdMirrorResourcePlural, "")
+	if err != nil {
+		return fmt.Errorf("failed to create CRD: %v", err)
+	}
+	return k8sutil.WaitCRDReady(m.kubeExtCli, api.EtcdMirrorCRDName)
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"reflect"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+)
+
+const (
+	// Copy from deployment_controller.go:
+	// maxRetries is the number of times an etcd mirror will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// an etcd mirror is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+func (m *Mirror) runWorker() {
+	for m.processNextItem() {
+	}
+}
+
+func (m *Mirror) processNextItem() bool {
+	// Wait until there is a new item in the working queue
+	key, quit := m.queue.Get()
+	if quit {
+		return false
+	}
+	// Tell the queue that we are done with processing this key. This unblocks the key for other workers
+	// This allows safe parallel processing because two pods with the same key are never processed in
+	// parallel.
+	defer m.queue.Done(key)
+	err := m.processItem(key.(string))
+	// Handle the error if something went wrong during the execution of the business logic
+	m.handleErr(err, key)
+	return true
+}
+
+// processItem starts a runner for a new EtcdMirror, restarts it if the spec
+// changed, and stops it when the EtcdMirror is deleted.
+func (m *Mirror) processItem(key string) error {
+	obj, exists, err := m.indexer.GetByKey(key)
+	if err != nil {
+		return err
+	}
+	r := m.runners[key]
+	if !exists {
+		if r != nil {
+			r.stop()
+			delete(m.runners, key)
+		}
+		return nil
+	}
+
+	em := obj.(*api.EtcdMirror)
+	if r != nil {
+		if reflect.DeepEqual(r.spec, em.Spec) {
+			return nil
+		}
+		m.logger.Infof("spec of etcd mirror (%v) changed, mirroring from scratch", key)
+		r.stop()
+		delete(m.runners, key)
+	}
+	if err := em.Spec.Validate(); err != nil {
+		m.reportFailure(em, err)
+		return nil
+	}
+
+	// A new runner resumes from the revision in the status, unless it replaces
+	// a runner of another spec.
+	rev := em.Status.Revision
+	if r != nil {
+		rev = 0
+	}
+	r = m.newRunner(em, rev)
+	m.runners[key] = r
+	go r.run()
+	return nil
+}
+
+func (m *Mirror) reportFailure(em *api.EtcdMirror, ferr error) {
+	if em.Status.Phase == api.MirrorPhaseFailed && em.Status.Reason == ferr.Error() {
+		return
+	}
+	em = em.DeepCopy()
+	em.Status.Phase = api.MirrorPhaseFailed
+	em.Status.Reason = ferr.Error()
+	_, err := m.etcdCRCli.EtcdV1beta2().EtcdMirrors(m.namespace).Update(em)
+	if err != nil {
+		m.logger.Warningf("failed to update status of mirror CR %v : (%v)", em.Name, err)
+	}
+}
+
+func (m *Mirror) handleErr(err error, key interface{}) {
+	if err == nil {
+		// Forget about the #AddRateLimited history of the key on every successful synchronization.
+		// This ensures that future processing of updates for this key is not delayed because of
+		// an outdated error history.
+		m.queue.Forget(key)
+		return
+	}
+
+	// This controller retries maxRetries times if something goes wrong. After that, it stops trying.
+	if m.queue.NumRequeues(key) < maxRetries {
+		m.logger.Errorf("error syncing etcd mirror (%v): %v", key, err)
+
+		// Re-enqueue the key rate limited. Based on the rate limiter on the
+		// queue and the re-enqueue history, the key will be processed later again.
+		m.queue.AddRateLimited(key)
+		return
+	}
+
+	m.queue.Forget(key)
+	// Report that, even after several retries, we could not successfully process this key
+	m.logger.Infof("Dropping etcd mirror (%v) out of the queue: %v", key, err)
+}
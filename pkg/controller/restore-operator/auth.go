@@ -0,0 +1,108 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Keys of the certificate and key in the serving TLS secret.
+	// They are the same as in the member server secret.
+	servingCertKey = "server.crt"
+	servingKeyKey  = "server.key"
+)
+
+// backupTokenSecretName returns the name of the secret holding the token
+// the seed member of the given restore fetches its backup with.
+func backupTokenSecretName(restoreName string) string {
+	return restoreName + "-backup-token"
+}
+
+// createBackupToken generates a new token for the given restore into its token secret,
+// replacing any previous token.
+func (r *Restore) createBackupToken(restoreName string, owner metav1.OwnerReference) error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate backup token: %v", err)
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            backupTokenSecretName(restoreName),
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Data: map[string][]byte{k8sutil.BackupSecretTokenKey: []byte(hex.EncodeToString(b))},
+	}
+	_, err := r.kubecli.CoreV1().Secrets(r.namespace).Create(secret)
+	if apierrors.IsAlreadyExists(err) {
+		_, err = r.kubecli.CoreV1().Secrets(r.namespace).Update(secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create backup token secret: %v", err)
+	}
+	return nil
+}
+
+// useBackupToken checks that the request carries the bearer token of the given restore,
+// and deletes the token so that it can't be used again.
+// Of concurrent requests with the same token, only the one that deletes it is authorized.
+func (r *Restore) useBackupToken(req *http.Request, restoreName string) error {
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return errUnauthorized{"no bearer token in request"}
+	}
+	secret, err := r.kubecli.CoreV1().Secrets(r.namespace).Get(backupTokenSecretName(restoreName), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return errUnauthorized{fmt.Sprintf("no backup token for restore-name (%v), it may have been used", restoreName)}
+		}
+		return fmt.Errorf("failed to get backup token secret: %v", err)
+	}
+	want := secret.Data[k8sutil.BackupSecretTokenKey]
+	if len(want) == 0 || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), want) != 1 {
+		return errUnauthorized{fmt.Sprintf("invalid backup token for restore-name (%v)", restoreName)}
+	}
+	// The precondition keeps a token created since the Get from being deleted.
+	err = r.kubecli.CoreV1().Secrets(r.namespace).Delete(secret.Name, metav1.NewPreconditionDeleteOptions(string(secret.UID)))
+	if err != nil {
+		return errUnauthorized{fmt.Sprintf("failed to use backup token for restore-name (%v), it may have been used: %v", restoreName, err)}
+	}
+	return nil
+}
+
+// servingTLSConfig returns the TLS config of the HTTP server from the serving TLS secret.
+func (r *Restore) servingTLSConfig() (*tls.Config, error) {
+	secret, err := r.kubecli.CoreV1().Secrets(r.namespace).Get(r.servingTLSSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get serving TLS secret (%v): %v", r.servingTLSSecret, err)
+	}
+	cert, err := tls.X509KeyPair(secret.Data[servingCertKey], secret.Data[servingKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid serving TLS secret (%v): %v", r.servingTLSSecret, err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
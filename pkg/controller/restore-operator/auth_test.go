@@ -0,0 +1,118 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestBackupToken(t *testing.T) {
+	r := &Restore{namespace: "default", kubecli: fake.NewSimpleClientset()}
+	token := createTestBackupToken(t, r)
+
+	tests := []struct {
+		auth  string
+		valid bool
+	}{
+		{auth: "Bearer " + token + "0"},
+		{auth: "Bearer "},
+		{auth: token},
+		{auth: ""},
+		{auth: "Bearer " + token, valid: true},
+		// A used token is deleted.
+		{auth: "Bearer " + token},
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest("GET", "/v1/backup/example", nil)
+		if len(tt.auth) != 0 {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		err := r.useBackupToken(req, "example")
+		if tt.valid != (err == nil) {
+			t.Errorf("#%d: expect valid %v, get error %v", i, tt.valid, err)
+		}
+		if _, ok := err.(errUnauthorized); err != nil && !ok {
+			t.Errorf("#%d: expect unauthorized error, get %v", i, err)
+		}
+	}
+
+	// A new token replaces the previous one.
+	createTestBackupToken(t, r)
+	req := httptest.NewRequest("GET", "/v1/backup/example", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if _, ok := r.useBackupToken(req, "example").(errUnauthorized); !ok {
+		t.Error("expect the previous token to be rejected")
+	}
+}
+
+func TestBackupTokenConcurrentUse(t *testing.T) {
+	r := &Restore{namespace: "default", kubecli: fake.NewSimpleClientset()}
+	token := createTestBackupToken(t, r)
+
+	errc := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			req := httptest.NewRequest("GET", "/v1/backup/example", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			errc <- r.useBackupToken(req, "example")
+		}()
+	}
+	var used int
+	for i := 0; i < 2; i++ {
+		err := <-errc
+		if err == nil {
+			used++
+			continue
+		}
+		if _, ok := err.(errUnauthorized); !ok {
+			t.Errorf("expect unauthorized error, get %v", err)
+		}
+	}
+	if used != 1 {
+		t.Errorf("expect the token to be used once, get %d", used)
+	}
+}
+
+func TestServeBackupChecksTokenFirst(t *testing.T) {
+	r := &Restore{
+		namespace: "default",
+		kubecli:   fake.NewSimpleClientset(),
+		indexer:   cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+	}
+	req := httptest.NewRequest("GET", backupHTTPPath+"missing", nil)
+	req.Header.Set("Authorization", "Bearer 0")
+	err := r.serveBackup(httptest.NewRecorder(), req)
+	if _, ok := err.(errUnauthorized); !ok {
+		t.Errorf("expect unauthorized error for a missing restore, get %v", err)
+	}
+}
+
+func createTestBackupToken(t *testing.T, r *Restore) string {
+	if err := r.createBackupToken("example", metav1.OwnerReference{Name: "example"}); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := r.kubecli.CoreV1().Secrets("default").Get(backupTokenSecretName("example"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(secret.Data[k8sutil.BackupSecretTokenKey])
+}
@@ -0,0 +1,203 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+	"github.com/coreos/etcd-operator/pkg/backup/reader"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const backupCatalogHTTPPath = backupapi.APIV1 + "/backups/"
+
+func (r *Restore) handleBackupCatalog(w http.ResponseWriter, req *http.Request) {
+	ctx, span := tracing.Start(req.Context(), "Restore.serveBackupCatalog")
+	span.SetAttribute("http.path", req.URL.Path)
+	err := r.serveBackupCatalog(w, req)
+	span.End(err)
+	if err != nil {
+		logrus.WithFields(tracing.LogFields(ctx)).Error(err)
+		http.Error(w, err.Error(), httpStatusCode(err))
+	}
+}
+
+// serveBackupCatalog parses incoming request url of the form
+// /backups/<namespace>/<cluster-name>[/<backup-name>].
+// Without a backup name, it returns the list of snapshots saved for the cluster.
+// With a backup name, it returns the metadata of that snapshot.
+// The snapshots are looked up under the backup policy of the EtcdCluster.
+func (r *Restore) serveBackupCatalog(w http.ResponseWriter, req *http.Request) error {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil
+	}
+	toks := strings.Split(strings.Trim(req.URL.Path[len(backupCatalogHTTPPath):], "/"), "/")
+	if len(toks) < 2 || len(toks) > 3 || len(toks[0]) == 0 || len(toks[1]) == 0 {
+		return errNotFound{"expect path of the form " + backupCatalogHTTPPath + "<namespace>/<cluster-name>[/<backup-name>]"}
+	}
+	namespace, clusterName := toks[0], toks[1]
+
+	br, prefix, closeReader, err := r.newClusterBackupReader(namespace, clusterName)
+	if err != nil {
+		return err
+	}
+	defer closeReader()
+
+	if len(toks) == 2 {
+		infos, err := listBackupInfos(br, prefix)
+		if err != nil {
+			return fmt.Errorf("failed to list backups of cluster (%s/%s): %v", namespace, clusterName, err)
+		}
+		return writeJSON(w, req, &backupapi.BackupList{Namespace: namespace, ClusterName: clusterName, Items: infos})
+	}
+
+	info, err := findBackupInfo(br, prefix, toks[2])
+	if err != nil {
+		return err
+	}
+	setBackupInfoHeaders(w, info)
+	return writeJSON(w, req, info)
+}
+
+// newClusterBackupReader returns the reader of the backup storage of the given cluster's
+// backup policy, the prefix its snapshots are saved under, and a function to release the reader.
+func (r *Restore) newClusterBackupReader(namespace, clusterName string) (reader.Reader, string, func(), error) {
+	// The restore operator only has access to the secrets of its own namespace.
+	if namespace != r.namespace {
+		return nil, "", nil, errNotFound{fmt.Sprintf("backups of namespace (%s) are not served here", namespace)}
+	}
+	cl, err := r.etcdCRCli.EtcdV1beta2().EtcdClusters(namespace).Get(clusterName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, "", nil, errNotFound{fmt.Sprintf("no etcd cluster found for cluster-name (%s)", clusterName)}
+		}
+		return nil, "", nil, fmt.Errorf("failed to get etcd cluster (%s): %v", clusterName, err)
+	}
+	bp := cl.Spec.BackupPolicy
+	if bp == nil {
+		return nil, "", nil, errNotFound{fmt.Sprintf("etcd cluster (%s) has no backup policy", clusterName)}
+	}
+
+	switch bp.StorageType {
+	case api.BackupStorageTypeS3:
+		if bp.S3 == nil {
+			return nil, "", nil, errors.New("empty s3 backup policy")
+		}
+		s3Cli, err := s3factory.NewClientFromSecret(r.kubecli, namespace, bp.S3.Endpoint, bp.S3.AWSSecret)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to create S3 client: %v", err)
+		}
+		prefix := backupapi.ToS3Prefix(bp.S3.Path, namespace, clusterName) + "/"
+		return reader.NewS3Reader(s3Cli.S3), prefix, s3Cli.Close, nil
+	default:
+		return nil, "", nil, fmt.Errorf("unknown backup storage type (%s)", bp.StorageType)
+	}
+}
+
+// listBackupInfos returns the snapshots saved directly under prefix, oldest revision first.
+// Files not named as snapshots are skipped.
+func listBackupInfos(br reader.Reader, prefix string) ([]backupapi.BackupInfo, error) {
+	objs, err := br.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+	infos := []backupapi.BackupInfo{}
+	for _, o := range objs {
+		if path.Dir(o.Path) != strings.TrimSuffix(prefix, "/") {
+			continue
+		}
+		info, err := toBackupInfo(o)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, *info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Revision < infos[j].Revision })
+	return infos, nil
+}
+
+// findBackupInfo returns the snapshot of the given name saved under prefix.
+func findBackupInfo(br reader.Reader, prefix, name string) (*backupapi.BackupInfo, error) {
+	if _, _, err := util.ParseBackupName(name); err != nil {
+		return nil, errNotFound{err.Error()}
+	}
+	p := prefix + name
+	objs, err := br.List(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up backup (%s): %v", p, err)
+	}
+	for _, o := range objs {
+		if o.Path == p {
+			return toBackupInfo(o)
+		}
+	}
+	return nil, errNotFound{fmt.Sprintf("no backup found at (%s)", p)}
+}
+
+func toBackupInfo(o reader.Object) (*backupapi.BackupInfo, error) {
+	etcdVersion, rev, err := util.ParseBackupName(o.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &backupapi.BackupInfo{
+		Name:        path.Base(o.Path),
+		Path:        o.Path,
+		Size:        o.Size,
+		Revision:    rev,
+		EtcdVersion: etcdVersion,
+		Timestamp:   o.LastModified,
+		Checksum:    o.Checksum,
+	}, nil
+}
+
+// setBackupInfoHeaders describes the snapshot in the response headers, for HEAD requests.
+func setBackupInfoHeaders(w http.ResponseWriter, info *backupapi.BackupInfo) {
+	h := w.Header()
+	h.Set(backupapi.HeaderEtcdRevision, strconv.FormatInt(info.Revision, 10))
+	h.Set(backupapi.HeaderEtcdVersion, info.EtcdVersion)
+	h.Set(backupapi.HeaderBackupSize, strconv.FormatInt(info.Size, 10))
+	if !info.Timestamp.IsZero() {
+		h.Set("Last-Modified", info.Timestamp.UTC().Format(http.TimeFormat))
+	}
+	if len(info.Checksum) != 0 {
+		h.Set("ETag", strconv.Quote(info.Checksum))
+	}
+}
+
+// writeJSON writes v as the JSON body of the response, or only its headers for a HEAD request.
+func writeJSON(w http.ResponseWriter, req *http.Request, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	if req.Method == http.MethodHead {
+		return nil
+	}
+	return json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+	"github.com/coreos/etcd-operator/pkg/backup/reader"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+)
+
+func TestListBackupInfos(t *testing.T) {
+	t0 := time.Date(2018, 3, 5, 14, 0, 0, 0, time.UTC)
+	prefix := "bucket/backups/v1/default/example/"
+	fr := &fakeReader{objs: []reader.Object{
+		{Path: prefix + util.MakeBackupName("3.2.13", 300), LastModified: t0.Add(time.Minute), Size: 4096, Checksum: "c300"},
+		{Path: prefix + util.MakeBackupName("3.2.11", 100), LastModified: t0, Size: 2048, Checksum: "c100"},
+		{Path: prefix + util.MakeSegmentName(301, 400), LastModified: t0.Add(2 * time.Minute)},
+		{Path: prefix + "old/" + util.MakeBackupName("3.2.11", 50), LastModified: t0},
+		{Path: prefix + "notes.txt", LastModified: t0},
+	}}
+
+	infos, err := listBackupInfos(fr, prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fr.listed != prefix {
+		t.Errorf("expect listing %s, get %s", prefix, fr.listed)
+	}
+	want := []backupapi.BackupInfo{
+		{Name: util.MakeBackupName("3.2.11", 100), Path: prefix + util.MakeBackupName("3.2.11", 100), Size: 2048, Revision: 100, EtcdVersion: "3.2.11", Timestamp: t0, Checksum: "c100"},
+		{Name: util.MakeBackupName("3.2.13", 300), Path: prefix + util.MakeBackupName("3.2.13", 300), Size: 4096, Revision: 300, EtcdVersion: "3.2.13", Timestamp: t0.Add(time.Minute), Checksum: "c300"},
+	}
+	if len(infos) != len(want) {
+		t.Fatalf("expect %d backups, get %d: %+v", len(want), len(infos), infos)
+	}
+	for i := range want {
+		if infos[i] != want[i] {
+			t.Errorf("#%d: expect %+v, get %+v", i, want[i], infos[i])
+		}
+	}
+}
+
+func TestFindBackupInfo(t *testing.T) {
+	prefix := "bucket/backups/v1/default/example/"
+	name := util.MakeBackupName("3.2.13", 300)
+	fr := &fakeReader{objs: []reader.Object{
+		{Path: prefix + name + ".tmp"},
+		{Path: prefix + name, Size: 4096, Checksum: "c300"},
+	}}
+
+	info, err := findBackupInfo(fr, prefix, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Path != prefix+name || info.Revision != 300 || info.Size != 4096 {
+		t.Errorf("unexpected backup info: %+v", info)
+	}
+
+	w := httptest.NewRecorder()
+	setBackupInfoHeaders(w, info)
+	if err := writeJSON(w, httptest.NewRequest(http.MethodHead, "/", nil), info); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get(backupapi.HeaderEtcdRevision) != "300" || w.Header().Get("ETag") != `"c300"` {
+		t.Errorf("unexpected headers: %v", w.Header())
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expect no body for HEAD, get %s", w.Body)
+	}
+
+	for _, n := range []string{util.MakeBackupName("3.2.13", 301), "notes.txt"} {
+		_, err := findBackupInfo(fr, prefix, n)
+		if _, ok := err.(errNotFound); !ok {
+			t.Errorf("%s: expect not found error, get %v", n, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"io"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// dryRunRestore checks that the backup of a full restore can be restored by the
+// reference cluster, and reports it along with the resources of the cluster the
+// restore would delete. It changes nothing.
+// The returned report is set as far as the check went, even if it fails.
+func (r *Restore) dryRunRestore(er *api.EtcdRestore) (*api.RestoreDryRunStatus, error) {
+	ec, err := r.etcdCRCli.EtcdV1beta2().EtcdClusters(r.namespace).Get(er.Spec.EtcdCluster.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reference EtcdCluster(%s/%s): %v", r.namespace, er.Spec.EtcdCluster.Name, err)
+	}
+	if err := ec.Spec.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid cluster spec: %v", err)
+	}
+	ec.SetDefaults()
+	report := &api.RestoreDryRunStatus{
+		ClusterVersion: ec.Spec.Version,
+		ClusterSize:    ec.Spec.Size,
+	}
+	report.DeletedResources, err = r.listClusterResources(ec)
+	if err != nil {
+		return report, err
+	}
+
+	br, closeReader, err := r.newBackupReader(&er.Spec)
+	if err != nil {
+		return report, err
+	}
+	defer closeReader()
+
+	if etcdVersion, _, err := util.ParseBackupName(er.Status.BackupPath); err == nil {
+		report.BackupEtcdVersion = etcdVersion
+		if err := api.ValidateRestoreVersion(etcdVersion, ec.Spec.Version); err != nil {
+			return report, err
+		}
+	} else {
+		report.BackupEtcdVersionUnknown = true
+	}
+
+	rc, err := br.Open(er.Status.BackupPath)
+	if err != nil {
+		return report, fmt.Errorf("failed to read backup file(%v): %v", er.Status.BackupPath, err)
+	}
+	defer rc.Close()
+	cr := &countingReader{r: rc}
+	st, err := backup.VerifySnapshot(cr)
+	report.BackupSize = cr.n
+	if err != nil {
+		return report, fmt.Errorf("backup (%v) cannot be restored: %v", er.Status.BackupPath, err)
+	}
+	report.SnapshotRevision, report.SnapshotHash, report.TotalKey = st.Revision, st.Hash, st.TotalKey
+
+	if er.Spec.IsPointInTime() {
+		objs, err := listBackups(br, &er.Spec)
+		if err != nil {
+			return report, err
+		}
+		report.ReplaySegments = len(selectSegments(objs, er.Status.BackupRevision, er.Spec.TargetRevision, er.Spec.TargetTime))
+	}
+	return report, nil
+}
+
+// listClusterResources returns the resources a full restore deletes and recreates
+// for the given cluster, as "<kind>/<name>".
+func (r *Restore) listClusterResources(ec *api.EtcdCluster) ([]string, error) {
+	res := []string{"EtcdCluster/" + ec.Name}
+	opt := k8sutil.ClusterListOpt(ec.Name)
+	pods, err := r.kubecli.CoreV1().Pods(r.namespace).List(opt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster pods: %v", err)
+	}
+	for _, p := range pods.Items {
+		res = append(res, "Pod/"+p.Name)
+	}
+	svcs, err := r.kubecli.CoreV1().Services(r.namespace).List(opt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster services: %v", err)
+	}
+	for _, s := range svcs.Items {
+		res = append(res, "Service/"+s.Name)
+	}
+	// The PVCs are owned by the EtcdCluster and deleted with it.
+	pvcs, err := r.kubecli.CoreV1().PersistentVolumeClaims(r.namespace).List(opt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster PVCs: %v", err)
+	}
+	for _, pvc := range pvcs.Items {
+		res = append(res, "PersistentVolumeClaim/"+pvc.Name)
+	}
+
+	// The other resources of the cluster are found by name, and are also deleted
+	// with it if it is their controller.
+	pdb, err := r.kubecli.PolicyV1beta1().PodDisruptionBudgets(r.namespace).Get(k8sutil.PodDisruptionBudgetName(ec.Name), metav1.GetOptions{})
+	switch {
+	case err == nil:
+		if k8sutil.IsControlledBy(pdb, ec.UID) {
+			res = append(res, "PodDisruptionBudget/"+pdb.Name)
+		}
+	case !apierrors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get cluster PodDisruptionBudget: %v", err)
+	}
+	d, err := r.kubecli.AppsV1beta1().Deployments(r.namespace).Get(k8sutil.ProxyName(ec.Name), metav1.GetOptions{})
+	switch {
+	case err == nil:
+		if k8sutil.IsControlledBy(d, ec.UID) {
+			res = append(res, "Deployment/"+d.Name)
+		}
+	case !apierrors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get cluster proxy Deployment: %v", err)
+	}
+	svc, err := r.kubecli.CoreV1().Services(r.namespace).Get(k8sutil.ProxyName(ec.Name), metav1.GetOptions{})
+	switch {
+	case err == nil:
+		if k8sutil.IsControlledBy(svc, ec.UID) {
+			res = append(res, "Service/"+svc.Name)
+		}
+	case !apierrors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get cluster proxy Service: %v", err)
+	}
+	if ec.Spec.Metrics == nil {
+		return res, nil
+	}
+	ok, err := k8sutil.HasServiceMonitorCRD(r.kubecli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover ServiceMonitor CRD: %v", err)
+	}
+	if !ok {
+		return res, nil
+	}
+	sm, err := k8sutil.GetServiceMonitor(r.kubecli, r.namespace, k8sutil.ServiceMonitorName(ec.Name))
+	switch {
+	case err == nil:
+		if k8sutil.IsControlledBy(sm, ec.UID) {
+			res = append(res, "ServiceMonitor/"+sm.Name)
+		}
+	case !apierrors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get cluster ServiceMonitor: %v", err)
+	}
+	return res, nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
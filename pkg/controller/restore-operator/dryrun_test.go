@@ -0,0 +1,97 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	"k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListClusterResources(t *testing.T) {
+	meta := func(name, cluster string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "default", Labels: k8sutil.LabelsForCluster(cluster)}
+	}
+	ec := &api.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "example", UID: "example-uid"}}
+	owned := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: []metav1.OwnerReference{ec.AsOwner()}}
+	}
+	r := &Restore{namespace: "default", kubecli: fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: meta("example-0000", "example")},
+		&v1.Pod{ObjectMeta: meta("example-0001", "example")},
+		&v1.Pod{ObjectMeta: meta("other-0000", "other")},
+		&v1.Service{ObjectMeta: meta("example-client", "example")},
+		&v1.PersistentVolumeClaim{ObjectMeta: meta("example-0000", "example")},
+		&policyv1beta1.PodDisruptionBudget{ObjectMeta: owned("example")},
+		&appsv1beta1.Deployment{ObjectMeta: owned("example-proxy")},
+		&v1.Service{ObjectMeta: owned("example-proxy")},
+	)}
+
+	res, err := r.listClusterResources(ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"EtcdCluster/example",
+		"Pod/example-0000",
+		"Pod/example-0001",
+		"Service/example-client",
+		"PersistentVolumeClaim/example-0000",
+		"PodDisruptionBudget/example",
+		"Deployment/example-proxy",
+		"Service/example-proxy",
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("expect %v, get %v", want, res)
+	}
+
+	// A PodDisruptionBudget the cluster doesn't control is not deleted with it.
+	r.kubecli = fake.NewSimpleClientset(&policyv1beta1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}})
+	res, err = r.listClusterResources(ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"EtcdCluster/example"}; !reflect.DeepEqual(res, want) {
+		t.Errorf("expect %v, get %v", want, res)
+	}
+}
+
+func TestValidateRestoreVersion(t *testing.T) {
+	tests := []struct {
+		backup, cluster string
+		wErr            bool
+	}{
+		{backup: "3.2.13", cluster: "3.2.13"},
+		{backup: "3.1.9", cluster: "3.2.13"},
+		{backup: "3.2.16", cluster: "3.2.13"},
+		{backup: "3.3.1", cluster: "3.2.13", wErr: true},
+		{backup: "2.3.8", cluster: "3.2.13", wErr: true},
+		{backup: "latest", cluster: "3.2.13", wErr: true},
+	}
+	for i, tt := range tests {
+		err := api.ValidateRestoreVersion(tt.backup, tt.cluster)
+		if tt.wErr != (err != nil) {
+			t.Errorf("#%d: expect error %v, get %v", i, tt.wErr, err)
+		}
+	}
+}
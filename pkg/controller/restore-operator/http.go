@@ -15,6 +15,7 @@
 package controller
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -24,8 +25,11 @@ import (
 	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
 	"github.com/coreos/etcd-operator/pkg/backup/reader"
 	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -34,28 +38,66 @@ const (
 	listenAddr     = "0.0.0.0:19999"
 )
 
-func (r *Restore) startHTTP() {
+// errNotFound is returned by a handler for a request of something that doesn't exist.
+type errNotFound struct{ msg string }
+
+func (e errNotFound) Error() string { return e.msg }
+
+// errUnauthorized is returned by a handler for a request without a valid token.
+type errUnauthorized struct{ msg string }
+
+func (e errUnauthorized) Error() string { return e.msg }
+
+// httpStatusCode returns the HTTP status code to respond to a request that failed with err.
+func httpStatusCode(err error) int {
+	switch err.(type) {
+	case errNotFound:
+		return http.StatusNotFound
+	case errUnauthorized:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// startHTTP serves backups over TLS with tlsConfig, or over plain HTTP if tlsConfig is nil.
+func (r *Restore) startHTTP(tlsConfig *tls.Config) {
 	http.HandleFunc(backupapi.APIV1+"/backup/", r.handleServeBackup)
-	logrus.Infof("listening on %v", listenAddr)
-	panic(http.ListenAndServe(listenAddr, nil))
+	http.HandleFunc(backupCatalogHTTPPath, r.handleBackupCatalog)
+	if tlsConfig == nil {
+		logrus.Infof("listening on %v", listenAddr)
+		panic(http.ListenAndServe(listenAddr, nil))
+	}
+	srv := &http.Server{Addr: listenAddr, TLSConfig: tlsConfig}
+	logrus.Infof("listening on %v with TLS", listenAddr)
+	panic(srv.ListenAndServeTLS("", ""))
 }
 
 func (r *Restore) handleServeBackup(w http.ResponseWriter, req *http.Request) {
+	ctx, span := tracing.Start(req.Context(), "Restore.serveBackup")
+	span.SetAttribute("http.path", req.URL.Path)
 	err := r.serveBackup(w, req)
+	span.End(err)
 	if err != nil {
-		logrus.Error(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logrus.WithFields(tracing.LogFields(ctx)).Error(err)
+		http.Error(w, err.Error(), httpStatusCode(err))
 	}
 }
 
 // serveBackup parses incoming request url of the form /backup/<restore-name>
 // get the etcd restore name.
-// Then it returns the etcd cluster backup snapshot to the caller.
+// Then it returns the etcd cluster backup snapshot to the caller, if the request
+// carries the restore's backup token. The token is used up before the backup is
+// streamed, so if streaming fails the restore has to be retried.
 func (r *Restore) serveBackup(w http.ResponseWriter, req *http.Request) error {
 	restoreName := string(req.URL.Path[len(backupHTTPPath):])
 	if len(restoreName) == 0 {
 		return errors.New("restore name is not specified")
 	}
+	// Check the token first, so that the response doesn't tell whether a restore exists.
+	if err := r.useBackupToken(req, restoreName); err != nil {
+		return err
+	}
 
 	obj := &api.EtcdRestore{
 		ObjectMeta: metav1.ObjectMeta{
@@ -74,32 +116,18 @@ func (r *Restore) serveBackup(w http.ResponseWriter, req *http.Request) error {
 	logrus.Infof("serving backup for restore CR %v", restoreName)
 	cr := v.(*api.EtcdRestore)
 
-	var (
-		backupReader reader.Reader
-		path         string
-	)
-
-	switch cr.Spec.BackupStorageType {
-	case api.BackupStorageTypeS3:
-		restoreSource := cr.Spec.RestoreSource
-		if restoreSource.S3 == nil {
-			return errors.New("empty s3 restore source")
-		}
-		s3RestoreSource := restoreSource.S3
-		if len(s3RestoreSource.AWSSecret) == 0 || len(s3RestoreSource.Path) == 0 {
-			return errors.New("invalid s3 restore source field (spec.s3), must specify all required subfields")
-		}
+	backupReader, closeReader, err := r.newBackupReader(&cr.Spec)
+	if err != nil {
+		return fmt.Errorf("restore CR (%v): %v", restoreName, err)
+	}
+	defer closeReader()
 
-		s3Cli, err := s3factory.NewClientFromSecret(r.kubecli, r.namespace, s3RestoreSource.Endpoint, s3RestoreSource.AWSSecret)
+	path := cr.Status.BackupPath
+	if len(path) == 0 {
+		path, _, err = resolveBackupPath(backupReader, &cr.Spec)
 		if err != nil {
-			return fmt.Errorf("failed to create S3 client: %v", err)
+			return err
 		}
-		defer s3Cli.Close()
-
-		backupReader = reader.NewS3Reader(s3Cli.S3)
-		path = s3RestoreSource.Path
-	default:
-		return fmt.Errorf("unknown backup storage type (%s) for restore CR (%v)", cr.Spec.BackupStorageType, restoreName)
 	}
 
 	rc, err := backupReader.Open(path)
@@ -108,9 +136,57 @@ func (r *Restore) serveBackup(w http.ResponseWriter, req *http.Request) error {
 	}
 	defer rc.Close()
 
-	_, err = io.Copy(w, rc)
+	n, err := io.Copy(w, rc)
 	if err != nil {
+		r.recorder.Eventf(cr, v1.EventTypeWarning, k8sutil.EventReasonRestoreFailed, "Failed to serve backup %s to the seed member: %v", path, err)
 		return fmt.Errorf("failed to write backup to %s: %v", req.RemoteAddr, err)
 	}
+	r.recordServedBytes(restoreName, n)
+	// Let the worker save it to the status, in case the restore already completed.
+	r.queue.Add(r.namespace + "/" + restoreName)
+	r.recorder.Eventf(cr, v1.EventTypeNormal, k8sutil.EventReasonRestoreProgressing, "Served backup %s to the seed member", path)
 	return nil
 }
+
+// recordServedBytes records the size of the backup served for the given restore.
+func (r *Restore) recordServedBytes(restoreName string, n int64) {
+	r.servedMu.Lock()
+	defer r.servedMu.Unlock()
+	if r.servedBytes == nil {
+		r.servedBytes = make(map[string]int64)
+	}
+	r.servedBytes[restoreName] = n
+}
+
+// takeServedBytes returns the size of the backup served for the given restore,
+// or 0 if none was served, and forgets it.
+func (r *Restore) takeServedBytes(restoreName string) int64 {
+	r.servedMu.Lock()
+	defer r.servedMu.Unlock()
+	n := r.servedBytes[restoreName]
+	delete(r.servedBytes, restoreName)
+	return n
+}
+
+// newBackupReader returns the reader of the backup storage of the given restore spec,
+// and a function to release it.
+func (r *Restore) newBackupReader(spec *api.RestoreSpec) (reader.Reader, func(), error) {
+	switch spec.BackupStorageType {
+	case api.BackupStorageTypeS3:
+		s3RestoreSource := spec.RestoreSource.S3
+		if s3RestoreSource == nil {
+			return nil, nil, errors.New("empty s3 restore source")
+		}
+		if len(s3RestoreSource.AWSSecret) == 0 || len(s3RestoreSource.Path) == 0 {
+			return nil, nil, errors.New("invalid s3 restore source field (spec.s3), must specify all required subfields")
+		}
+
+		s3Cli, err := s3factory.NewClientFromSecret(r.kubecli, r.namespace, s3RestoreSource.Endpoint, s3RestoreSource.AWSSecret)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create S3 client: %v", err)
+		}
+		return reader.NewS3Reader(s3Cli.S3), s3Cli.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown backup storage type (%s)", spec.BackupStorageType)
+	}
+}
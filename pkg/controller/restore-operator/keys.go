@@ -0,0 +1,179 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup"
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+
+	"github.com/coreos/etcd/clientv3"
+)
+
+// maxReportedKeyChanges is the number of changed keys listed in the status of a key restore.
+const maxReportedKeyChanges = 100
+
+// restoreKeys copies the keys under the prefixes of a restore of mode "keys" from the
+// backup, with the changes of an incremental backup replayed up to the target, into
+// the running reference cluster. With dryRun, it only reports what it would change.
+// It returns the report and the revision of the last replayed change, or 0 if none was replayed,
+// and sets the size of the backup read in the status.
+func (r *Restore) restoreKeys(er *api.EtcdRestore) (*api.KeyRestoreStatus, int64, error) {
+	prefixes := er.Spec.Keys.Prefixes
+	br, closeReader, err := r.newBackupReader(&er.Spec)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer closeReader()
+
+	rc, err := br.Open(er.Status.BackupPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read backup file(%v): %v", er.Status.BackupPath, err)
+	}
+	cr := &countingReader{r: rc}
+	ks, err := backup.ReadSnapshotKeys(cr, prefixes)
+	er.Status.BytesTransferred = cr.n
+	rc.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var replayedRev int64
+	if er.Spec.IsPointInTime() {
+		objs, err := listBackups(br, &er.Spec)
+		if err != nil {
+			return nil, 0, err
+		}
+		untilRev := int64(math.MaxInt64)
+		if er.Spec.TargetRevision != 0 {
+			untilRev = er.Spec.TargetRevision
+		}
+		lastRev := er.Status.BackupRevision
+		for _, o := range selectSegments(objs, er.Status.BackupRevision, er.Spec.TargetRevision, er.Spec.TargetTime) {
+			changes, err := readSegment(br, o, er.Spec.TargetTime)
+			if err != nil {
+				return nil, 0, err
+			}
+			if rev := ks.Apply(changes, prefixes, lastRev, untilRev); rev != 0 {
+				lastRev = rev
+			}
+		}
+		if lastRev != er.Status.BackupRevision {
+			replayedRev = lastRev
+		}
+	}
+
+	etcdcli, err := r.newClusterClient(er.Spec.EtcdCluster.Name)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer etcdcli.Close()
+
+	current, err := getKeys(etcdcli, prefixes)
+	if err != nil {
+		return nil, 0, err
+	}
+	changes := planKeyRestore(ks, current, er.Spec.Keys.ConflictPolicy)
+	if !er.Spec.DryRun {
+		if err := applyKeyRestore(etcdcli, ks, changes); err != nil {
+			return nil, 0, err
+		}
+	}
+	return newKeyRestoreStatus(changes, len(ks)), replayedRev, nil
+}
+
+// getKeys returns the keys under prefixes in the cluster, with their values.
+func getKeys(kv clientv3.KV, prefixes []string) (backup.KeySet, error) {
+	ks := backup.KeySet{}
+	for _, p := range prefixes {
+		ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+		resp, err := kv.Get(ctx, p, clientv3.WithPrefix())
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get keys under (%s): %v", p, err)
+		}
+		for _, kv := range resp.Kvs {
+			ks[string(kv.Key)] = kv.Value
+		}
+	}
+	return ks, nil
+}
+
+// planKeyRestore returns what restoring the keys of the backup does to each key
+// that differs in the cluster, in key order.
+func planKeyRestore(restored, current backup.KeySet, policy api.KeyConflictPolicy) []api.KeyChange {
+	var changes []api.KeyChange
+	for k, v := range restored {
+		cv, ok := current[k]
+		switch {
+		case !ok:
+			changes = append(changes, api.KeyChange{Key: k, Action: api.KeyActionCreate})
+		case bytes.Equal(cv, v):
+		case policy == api.KeyConflictSkipExisting:
+			changes = append(changes, api.KeyChange{Key: k, Action: api.KeyActionSkip})
+		default:
+			changes = append(changes, api.KeyChange{Key: k, Action: api.KeyActionUpdate})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// applyKeyRestore writes the created and updated keys with their value in the backup.
+// A key is only created if it still doesn't exist, so that a key written in the
+// meantime is left alone.
+func applyKeyRestore(kv clientv3.KV, restored backup.KeySet, changes []api.KeyChange) error {
+	for _, c := range changes {
+		put := clientv3.OpPut(c.Key, string(restored[c.Key]))
+		ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+		var err error
+		switch c.Action {
+		case api.KeyActionCreate:
+			_, err = kv.Txn(ctx).If(clientv3.Compare(clientv3.CreateRevision(c.Key), "=", 0)).Then(put).Commit()
+		case api.KeyActionUpdate:
+			_, err = kv.Do(ctx, put)
+		}
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to restore key (%s): %v", c.Key, err)
+		}
+	}
+	return nil
+}
+
+func newKeyRestoreStatus(changes []api.KeyChange, total int) *api.KeyRestoreStatus {
+	st := &api.KeyRestoreStatus{Unchanged: total - len(changes)}
+	for _, c := range changes {
+		switch c.Action {
+		case api.KeyActionCreate:
+			st.Created++
+		case api.KeyActionUpdate:
+			st.Updated++
+		case api.KeyActionSkip:
+			st.Skipped++
+		}
+	}
+	if len(changes) > maxReportedKeyChanges {
+		changes, st.ChangesTruncated = changes[:maxReportedKeyChanges], true
+	}
+	st.Changes = changes
+	return st
+}
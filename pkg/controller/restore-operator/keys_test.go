@@ -0,0 +1,65 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup"
+)
+
+func TestPlanKeyRestore(t *testing.T) {
+	restored := backup.KeySet{"/a/1": []byte("v1"), "/a/2": []byte("v2"), "/a/3": []byte("v3")}
+	current := backup.KeySet{"/a/2": []byte("v2"), "/a/3": []byte("changed"), "/a/4": []byte("v4")}
+
+	tests := []struct {
+		policy api.KeyConflictPolicy
+		want   []api.KeyChange
+	}{{
+		policy: "",
+		want:   []api.KeyChange{{Key: "/a/1", Action: api.KeyActionCreate}, {Key: "/a/3", Action: api.KeyActionUpdate}},
+	}, {
+		policy: api.KeyConflictOverwrite,
+		want:   []api.KeyChange{{Key: "/a/1", Action: api.KeyActionCreate}, {Key: "/a/3", Action: api.KeyActionUpdate}},
+	}, {
+		policy: api.KeyConflictSkipExisting,
+		want:   []api.KeyChange{{Key: "/a/1", Action: api.KeyActionCreate}, {Key: "/a/3", Action: api.KeyActionSkip}},
+	}}
+	for i, tt := range tests {
+		changes := planKeyRestore(restored, current, tt.policy)
+		if !reflect.DeepEqual(changes, tt.want) {
+			t.Errorf("#%d: expect %v, get %v", i, tt.want, changes)
+		}
+	}
+}
+
+func TestNewKeyRestoreStatus(t *testing.T) {
+	var changes []api.KeyChange
+	for i := 0; i < maxReportedKeyChanges+1; i++ {
+		changes = append(changes, api.KeyChange{Key: fmt.Sprintf("/a/%03d", i), Action: api.KeyActionCreate})
+	}
+	changes = append(changes, api.KeyChange{Key: "/b/1", Action: api.KeyActionSkip})
+
+	st := newKeyRestoreStatus(changes, len(changes)+5)
+	if st.Created != maxReportedKeyChanges+1 || st.Skipped != 1 || st.Updated != 0 || st.Unchanged != 5 {
+		t.Errorf("unexpected counts: %+v", st)
+	}
+	if len(st.Changes) != maxReportedKeyChanges || !st.ChangesTruncated {
+		t.Errorf("expect %d changes listed and truncated, get %d (truncated %v)", maxReportedKeyChanges, len(st.Changes), st.ChangesTruncated)
+	}
+}
@@ -16,17 +16,22 @@ package controller
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"os"
+	"sync"
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/client"
 	"github.com/coreos/etcd-operator/pkg/generated/clientset/versioned"
+	"github.com/coreos/etcd-operator/pkg/util/constants"
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 
 	"github.com/sirupsen/logrus"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 )
 
@@ -43,20 +48,32 @@ type Restore struct {
 	kubecli    kubernetes.Interface
 	etcdCRCli  versioned.Interface
 	kubeExtCli apiextensionsclient.Interface
+	recorder   record.EventRecorder
 
 	createCRD bool
+	// servingTLSSecret is the secret with the certificate the HTTP server serves backups over TLS with.
+	// The server serves plain HTTP if it is empty.
+	servingTLSSecret string
+
+	servedMu sync.Mutex
+	// servedBytes is the size of the backup served to the seed member of each restore, by restore name.
+	servedBytes map[string]int64
 }
 
 // New creates a restore operator.
-func New(createCRD bool, namespace, mySvcAddr string) *Restore {
+func New(createCRD bool, namespace, mySvcAddr, servingTLSSecret string) *Restore {
+	kubecli := k8sutil.MustNewKubeClient()
 	return &Restore{
 		logger:     logrus.WithField("pkg", "controller"),
 		namespace:  namespace,
 		mySvcAddr:  mySvcAddr,
-		kubecli:    k8sutil.MustNewKubeClient(),
+		kubecli:    kubecli,
 		etcdCRCli:  client.MustNewInCluster(),
 		kubeExtCli: k8sutil.MustNewKubeExtClient(),
+		recorder:   k8sutil.NewEventRecorder(kubecli, os.Getenv(constants.EnvOperatorPodName)),
 		createCRD:  createCRD,
+
+		servingTLSSecret: servingTLSSecret,
 	}
 }
 
@@ -68,8 +85,17 @@ func (r *Restore) Start(ctx context.Context) error {
 		}
 	}
 
+	var tlsConfig *tls.Config
+	if len(r.servingTLSSecret) != 0 {
+		var err error
+		tlsConfig, err = r.servingTLSConfig()
+		if err != nil {
+			return err
+		}
+	}
+
 	go r.run(ctx)
-	go r.startHTTP()
+	go r.startHTTP(tlsConfig)
 	<-ctx.Done()
 	return ctx.Err()
 }
@@ -0,0 +1,255 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup"
+	"github.com/coreos/etcd-operator/pkg/backup/reader"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	"github.com/coreos/etcd-operator/pkg/util/retryutil"
+
+	"github.com/coreos/etcd/clientv3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// resolveBackup returns the path of the backup the restore CR restores and,
+// for a point in time restore, the etcd revision of the backup.
+func (r *Restore) resolveBackup(er *api.EtcdRestore) (string, int64, error) {
+	br, closeReader, err := r.newBackupReader(&er.Spec)
+	if err != nil {
+		return "", 0, err
+	}
+	defer closeReader()
+	return resolveBackupPath(br, &er.Spec)
+}
+
+// resolveBackupPath returns the path of the backup to restore. For a point in
+// time restore, it picks the backup under the path of the restore source and
+// also returns the etcd revision of the backup.
+func resolveBackupPath(br reader.Reader, spec *api.RestoreSpec) (string, int64, error) {
+	p := spec.S3.Path
+	if !spec.IsPointInTime() {
+		return p, 0, nil
+	}
+	objs, err := listBackups(br, spec)
+	if err != nil {
+		return "", 0, err
+	}
+	return selectBackup(objs, spec.TargetRevision, spec.TargetTime)
+}
+
+// listBackups lists the backups under the path of the restore source.
+func listBackups(br reader.Reader, spec *api.RestoreSpec) ([]reader.Object, error) {
+	p := spec.S3.Path
+	// The trailing slash keeps backups of clusters whose names share the prefix out.
+	objs, err := br.List(strings.TrimSuffix(p, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups under (%v): %v", p, err)
+	}
+	return objs, nil
+}
+
+// selectBackup picks the backup with the highest etcd revision that is at or before
+// targetRev, or saved at or before targetTime. Objects that are not named by
+// util.MakeBackupName are ignored.
+func selectBackup(objs []reader.Object, targetRev int64, targetTime *metav1.Time) (string, int64, error) {
+	var (
+		path    string
+		bestRev int64 = -1
+	)
+	for _, o := range objs {
+		_, rev, err := util.ParseBackupName(o.Path)
+		if err != nil {
+			continue
+		}
+		if targetRev != 0 && rev > targetRev {
+			continue
+		}
+		if targetTime != nil && o.LastModified.After(targetTime.Time) {
+			continue
+		}
+		if rev > bestRev {
+			path, bestRev = o.Path, rev
+		}
+	}
+	if bestRev < 0 {
+		if targetTime != nil {
+			return "", 0, fmt.Errorf("no backup found saved at or before %v", targetTime.UTC())
+		}
+		return "", 0, fmt.Errorf("no backup found at or before revision %d", targetRev)
+	}
+	return path, bestRev, nil
+}
+
+// selectSegments returns the segments of incremental backups that continue the
+// backup at baseRev without a gap, up to targetRev or targetTime, in order.
+// With targetTime, the last one is the first segment saved after it, which holds
+// the changes made just before targetTime; readSegment cuts it at targetTime.
+func selectSegments(objs []reader.Object, baseRev, targetRev int64, targetTime *metav1.Time) []reader.Object {
+	type segment struct {
+		obj              reader.Object
+		startRev, endRev int64
+	}
+	var segs []segment
+	for _, o := range objs {
+		start, end, err := util.ParseSegmentName(o.Path)
+		if err != nil || end <= baseRev {
+			continue
+		}
+		if targetRev != 0 && start > targetRev {
+			continue
+		}
+		segs = append(segs, segment{obj: o, startRev: start, endRev: end})
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].startRev < segs[j].startRev })
+
+	var selected []reader.Object
+	nextRev := baseRev + 1
+	for _, s := range segs {
+		if s.startRev > nextRev {
+			// The changes up to s.startRev are missing.
+			break
+		}
+		if s.endRev < nextRev {
+			continue
+		}
+		selected = append(selected, s.obj)
+		nextRev = s.endRev + 1
+		if targetTime != nil && s.obj.LastModified.After(targetTime.Time) {
+			break
+		}
+	}
+	return selected
+}
+
+// replaySegments replays the changes saved by an incremental backup after the
+// restored backup, up to the target of the restore, on the restored cluster.
+// It returns the revision of the last replayed change, or 0 if none was replayed.
+func (r *Restore) replaySegments(er *api.EtcdRestore) (int64, error) {
+	br, closeReader, err := r.newBackupReader(&er.Spec)
+	if err != nil {
+		return 0, err
+	}
+	defer closeReader()
+	objs, err := listBackups(br, &er.Spec)
+	if err != nil {
+		return 0, err
+	}
+	segs := selectSegments(objs, er.Status.BackupRevision, er.Spec.TargetRevision, er.Spec.TargetTime)
+	if len(segs) == 0 {
+		return 0, nil
+	}
+
+	etcdcli, err := r.newClusterClient(er.Spec.EtcdCluster.Name)
+	if err != nil {
+		return 0, err
+	}
+	defer etcdcli.Close()
+	// The seed member restores the backup before it serves clients.
+	err = retryutil.Retry(10*time.Second, 30, func() (bool, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+		_, err := etcdcli.Get(ctx, "/", clientv3.WithSerializable())
+		cancel()
+		return err == nil, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restored cluster is not available: %v", err)
+	}
+
+	untilRev := int64(math.MaxInt64)
+	if er.Spec.TargetRevision != 0 {
+		untilRev = er.Spec.TargetRevision
+	}
+	lastRev := er.Status.BackupRevision
+	for _, o := range segs {
+		changes, err := readSegment(br, o, er.Spec.TargetTime)
+		if err != nil {
+			return 0, err
+		}
+		rev, err := backup.ReplayChanges(etcdcli, changes, lastRev, untilRev)
+		if rev != 0 {
+			lastRev = rev
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if lastRev == er.Status.BackupRevision {
+		return 0, nil
+	}
+	return lastRev, nil
+}
+
+// readSegment returns the changes saved in segment o.
+// If o was saved after targetTime, only the changes received up to targetTime are returned.
+func readSegment(br reader.Reader, o reader.Object, targetTime *metav1.Time) ([]backup.Change, error) {
+	rc, err := br.Open(o.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read segment (%v): %v", o.Path, err)
+	}
+	defer rc.Close()
+	changes, err := backup.DecodeSegment(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read segment (%v): %v", o.Path, err)
+	}
+	if targetTime != nil && o.LastModified.After(targetTime.Time) {
+		changes = backup.ChangesUntil(changes, targetTime.Time)
+	}
+	return changes, nil
+}
+
+// newClusterClient creates an etcd client for the client service of the given
+// EtcdCluster, with its operator TLS secret and root credentials.
+func (r *Restore) newClusterClient(clusterName string) (*clientv3.Client, error) {
+	ec, err := r.etcdCRCli.EtcdV1beta2().EtcdClusters(r.namespace).Get(clusterName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get etcd cluster (%s): %v", clusterName, err)
+	}
+	scheme := "http"
+	var tc *tls.Config
+	if ec.Spec.TLS.IsSecureClient() {
+		scheme = "https"
+		d, err := k8sutil.GetTLSDataFromSecret(r.kubecli, r.namespace, ec.Spec.TLS.Static.OperatorSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TLS data from secret (%v): %v", ec.Spec.TLS.Static.OperatorSecret, err)
+		}
+		tc, err = etcdutil.NewTLSConfig(d.CertData, d.KeyData, d.CAData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to constructs tls config: %v", err)
+		}
+	}
+	// The restored data has authentication enabled if the backed up cluster had.
+	var creds *etcdutil.Credentials
+	if ec.Spec.Auth != nil {
+		creds, err = k8sutil.GetCredentialsFromSecret(r.kubecli, r.namespace, ec.Spec.Auth.RootSecret, api.AuthRootUser)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get credentials from secret (%v): %v", ec.Spec.Auth.RootSecret, err)
+		}
+	}
+	endpoint := fmt.Sprintf("%s://%s.%s.svc:%d", scheme, k8sutil.ClientServiceName(clusterName), r.namespace, k8sutil.EtcdClientPort)
+	return clientv3.New(etcdutil.NewClientConfig([]string{endpoint}, tc, creds))
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/reader"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeReader struct {
+	objs []reader.Object
+	// listed is the prefix List was last called with.
+	listed string
+}
+
+func (f *fakeReader) Open(string) (io.ReadCloser, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeReader) List(prefix string) ([]reader.Object, error) {
+	f.listed = prefix
+	return f.objs, nil
+}
+
+func TestSelectBackup(t *testing.T) {
+	t0 := time.Date(2018, 3, 5, 14, 0, 0, 0, time.UTC)
+	prefix := "bucket/backups/v1/default/example/"
+	objs := []reader.Object{
+		{Path: prefix + util.MakeBackupName("3.2.13", 100), LastModified: t0},
+		{Path: prefix + util.MakeBackupName("3.2.13", 300), LastModified: t0.Add(2 * time.Minute)},
+		{Path: prefix + util.MakeBackupName("3.2.13", 200), LastModified: t0.Add(time.Minute)},
+		{Path: prefix + "notes.txt", LastModified: t0.Add(time.Minute)},
+	}
+
+	tests := []struct {
+		targetRev  int64
+		targetTime *metav1.Time
+		wRev       int64
+		wErr       bool
+	}{
+		{targetRev: 250, wRev: 200},
+		{targetRev: 300, wRev: 300},
+		{targetRev: 1000, wRev: 300},
+		{targetRev: 99, wErr: true},
+		{targetTime: &metav1.Time{Time: t0.Add(90 * time.Second)}, wRev: 200},
+		{targetTime: &metav1.Time{Time: t0}, wRev: 100},
+		{targetTime: &metav1.Time{Time: t0.Add(-time.Second)}, wErr: true},
+	}
+	for i, tt := range tests {
+		p, rev, err := selectBackup(objs, tt.targetRev, tt.targetTime)
+		if tt.wErr {
+			if err == nil {
+				t.Errorf("#%d: expect error, get backup %s", i, p)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: expect no error, get %v", i, err)
+			continue
+		}
+		if rev != tt.wRev || p != prefix+util.MakeBackupName("3.2.13", tt.wRev) {
+			t.Errorf("#%d: expect revision %d, get %d (%s)", i, tt.wRev, rev, p)
+		}
+	}
+}
+
+func TestResolveBackupPath(t *testing.T) {
+	fr := &fakeReader{objs: []reader.Object{{Path: "bucket/example/" + util.MakeBackupName("3.2.13", 7)}}}
+
+	spec := &api.RestoreSpec{RestoreSource: api.RestoreSource{S3: &api.S3RestoreSource{Path: "bucket/example/backup"}}}
+	p, rev, err := resolveBackupPath(fr, spec)
+	if err != nil || p != "bucket/example/backup" || rev != 0 {
+		t.Errorf("expect the given path without listing, get (%s, %d, %v)", p, rev, err)
+	}
+	if len(fr.listed) != 0 {
+		t.Errorf("expect no listing, get %s", fr.listed)
+	}
+
+	spec = &api.RestoreSpec{
+		RestoreSource:  api.RestoreSource{S3: &api.S3RestoreSource{Path: "bucket/example"}},
+		TargetRevision: 10,
+	}
+	p, rev, err = resolveBackupPath(fr, spec)
+	if err != nil || rev != 7 {
+		t.Errorf("expect revision 7, get (%s, %d, %v)", p, rev, err)
+	}
+	if fr.listed != "bucket/example/" {
+		t.Errorf("expect listing bucket/example/, get %s", fr.listed)
+	}
+}
+
+func TestSelectSegments(t *testing.T) {
+	t0 := time.Date(2018, 3, 5, 14, 0, 0, 0, time.UTC)
+	prefix := "bucket/example/"
+	seg := func(start, end int64, min int) reader.Object {
+		return reader.Object{Path: prefix + util.MakeSegmentName(start, end), LastModified: t0.Add(time.Duration(min) * time.Minute)}
+	}
+	objs := []reader.Object{
+		{Path: prefix + util.MakeBackupName("3.2.13", 100), LastModified: t0},
+		seg(121, 130, 2),
+		seg(101, 120, 1),
+		seg(131, 140, 3),
+		// a chain of an older full snapshot that overlaps the chain above.
+		seg(91, 125, 1),
+		// after a gap.
+		seg(150, 160, 4),
+	}
+
+	tests := []struct {
+		baseRev    int64
+		targetRev  int64
+		targetTime *metav1.Time
+		wSegs      [][2]int64
+	}{
+		{baseRev: 100, wSegs: [][2]int64{{91, 125}, {121, 130}, {131, 140}}},
+		{baseRev: 100, targetRev: 121, wSegs: [][2]int64{{91, 125}, {121, 130}}},
+		{baseRev: 100, targetRev: 110, wSegs: [][2]int64{{91, 125}}},
+		// the segment saved after the target time holds the changes just before it.
+		{baseRev: 100, targetTime: &metav1.Time{Time: t0.Add(90 * time.Second)}, wSegs: [][2]int64{{91, 125}, {121, 130}}},
+		{baseRev: 100, targetTime: &metav1.Time{Time: t0.Add(3 * time.Minute)}, wSegs: [][2]int64{{91, 125}, {121, 130}, {131, 140}}},
+		{baseRev: 140},
+		{baseRev: 130, wSegs: [][2]int64{{131, 140}}},
+	}
+	for i, tt := range tests {
+		var segs [][2]int64
+		for _, o := range selectSegments(objs, tt.baseRev, tt.targetRev, tt.targetTime) {
+			start, end, err := util.ParseSegmentName(o.Path)
+			if err != nil {
+				t.Fatalf("#%d: %v", i, err)
+			}
+			segs = append(segs, [2]int64{start, end})
+		}
+		if !reflect.DeepEqual(segs, tt.wSegs) {
+			t.Errorf("#%d: expect segments %v, get %v", i, tt.wSegs, segs)
+		}
+	}
+}
@@ -16,6 +16,7 @@ package controller
 
 import (
 	"fmt"
+	"time"
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
@@ -23,6 +24,7 @@ import (
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 	"github.com/coreos/etcd-operator/pkg/util/retryutil"
 
+	"k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -35,6 +37,9 @@ const (
 	//
 	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
 	maxRetries = 15
+
+	// scalingCheckInterval is how often a restore checks whether the restored cluster has scaled.
+	scalingCheckInterval = 10 * time.Second
 )
 
 func (r *Restore) runWorker() {
@@ -66,41 +71,248 @@ func (r *Restore) processItem(key string) error {
 	if !exists {
 		return nil
 	}
-	return r.handleCR(obj.(*api.EtcdRestore), key)
+	er := obj.(*api.EtcdRestore)
+	// The seed member may fetch the backup after the restore completed.
+	served := r.takeServedBytes(er.Name)
+	if !er.ShouldRun() && !er.Status.InProgress() && served == 0 {
+		return nil
+	}
+	// The cache may lag behind the status updates of a restore, so decide on the latest CR.
+	er, err = r.etcdCRCli.EtcdV1beta2().EtcdRestores(r.namespace).Get(er.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !er.ShouldRun() && !er.Status.InProgress() {
+		if served != 0 {
+			er.Status.BytesTransferred = served
+			r.updateStatus(er)
+		}
+		return nil
+	}
+	if er.Status.Phase == api.RestorePhaseScaling {
+		if served != 0 {
+			er.Status.BytesTransferred = served
+			r.updateStatus(er)
+		}
+		return r.waitForScaling(er, key)
+	}
+	return r.handleCR(er, key)
 }
 
 // handleCR takes in EtcdRestore CR and prepares the seed so that etcd operator can take over it later.
 func (r *Restore) handleCR(er *api.EtcdRestore, key string) (err error) {
-	// don't process the CR if it has a status since
-	// having a status means that the restore is either made or failed.
-	if er.Status.Succeeded || len(er.Status.Reason) != 0 {
+	if er.Status.InProgress() {
+		// Only this worker processes the CR, and a scaling restore doesn't get here, so
+		// the restore was interrupted, e.g. by a restart of the operator. It isn't resumed
+		// since the reference cluster may be half deleted; the user can retry it with the
+		// retry annotation.
+		r.reportStatus(fmt.Errorf("restore was interrupted in phase %s", er.Status.Phase), er)
+		return nil
+	}
+	// Don't process the CR if it has completed, or failed and wasn't asked to retry.
+	if !er.ShouldRun() {
 		return nil
 	}
+	if len(er.Status.Reason) != 0 {
+		r.recorder.Eventf(er, v1.EventTypeNormal, k8sutil.EventReasonRestoreStarted, "Retrying failed restore (%s=%s)", api.RetryAnnotation, er.Annotations[api.RetryAnnotation])
+	}
+	// Keep what a previous run restored, so that the retry can resume on its cluster.
+	from := resumePhase(&er.Status)
+	prev := er.Status
+	er.Status = api.RestoreStatus{ClusterUID: prev.ClusterUID}
+	if len(from) != 0 {
+		er.Status.BackupPath, er.Status.BackupRevision = prev.BackupPath, prev.BackupRevision
+	}
+	er.Status.ObservedRetry = er.Annotations[api.RetryAnnotation]
+	r.setPhase(er, api.OperationPhasePending)
 
-	defer r.reportStatus(err, er)
+	defer func() {
+		// A restored cluster completes the restore once it has scaled.
+		if err == nil && er.Status.Phase == api.RestorePhaseScaling {
+			return
+		}
+		r.reportStatus(err, er)
+	}()
 	// NOTE: Since the restore EtcdCluster is created with the same name as the EtcdClusterRef,
 	// the seed member will send a request of the form /backup/<cluster-name> to the backup server.
 	// The EtcdRestore CR name must be the same as the EtcdCluster name in order for the backup server
 	// to successfully lookup the EtcdRestore CR associated with this <cluster-name>.
-	if er.Name != er.Spec.EtcdCluster.Name {
+	// A key restore creates no seed member.
+	if !er.Spec.IsKeyRestore() && er.Name != er.Spec.EtcdCluster.Name {
 		err = fmt.Errorf("failed to handle restore CR: EtcdRestore CR name(%v) must be the same as EtcdCluster name(%v)", er.Name, er.Spec.EtcdCluster.Name)
 		return err
 	}
+	if err = er.Spec.Validate(); err != nil {
+		return err
+	}
+	if len(from) != 0 {
+		r.recorder.Eventf(er, v1.EventTypeNormal, k8sutil.EventReasonRestoreStarted, "Resuming restore of cluster %s from phase %s", er.Spec.EtcdCluster.Name, from)
+		return r.resumeRestore(er, key, from)
+	}
+	// Pick the backup before the reference cluster is deleted, so that a restore
+	// with no matching backup leaves the cluster alone.
+	r.setPhase(er, api.RestorePhaseFetchingSnapshot)
+	er.Status.BackupPath, er.Status.BackupRevision, err = r.resolveBackup(er)
+	if err != nil {
+		return err
+	}
+	if er.Spec.IsKeyRestore() {
+		r.recorder.Eventf(er, v1.EventTypeNormal, k8sutil.EventReasonRestoreStarted, "Restoring keys under %v of cluster %s from backup %s", er.Spec.Keys.Prefixes, er.Spec.EtcdCluster.Name, er.Status.BackupPath)
+		er.Status.Keys, er.Status.ReplayedRevision, err = r.restoreKeys(er)
+		return err
+	}
+	if er.Spec.DryRun {
+		er.Status.DryRun, err = r.dryRunRestore(er)
+		if er.Status.DryRun != nil {
+			er.Status.BytesTransferred = er.Status.DryRun.BackupSize
+		}
+		return err
+	}
+	r.recorder.Eventf(er, v1.EventTypeNormal, k8sutil.EventReasonRestoreStarted, "Restoring cluster %s from backup %s", er.Spec.EtcdCluster.Name, er.Status.BackupPath)
 	err = r.prepareSeed(er)
-	return err
+	if err != nil {
+		return err
+	}
+	return r.replayAndScale(er, key)
+}
+
+// resumePhase returns the phase a retry of the restore with status s resumes from,
+// or "" if the retry restores the cluster again. Only a restore that failed after
+// it seeded the restored cluster is resumed.
+func resumePhase(s *api.RestoreStatus) api.OperationPhase {
+	if len(s.ClusterUID) == 0 {
+		return ""
+	}
+	for _, c := range s.Conditions {
+		if c.Status != v1.ConditionFalse {
+			continue
+		}
+		if c.Type == api.RestorePhaseReplaying || c.Type == api.RestorePhaseScaling {
+			return c.Type
+		}
+	}
+	return ""
+}
+
+// resumeRestore resumes the restore from phase from on the cluster a previous run restored.
+func (r *Restore) resumeRestore(er *api.EtcdRestore, key string, from api.OperationPhase) error {
+	name := er.Spec.EtcdCluster.Name
+	ec, err := r.etcdCRCli.EtcdV1beta2().EtcdClusters(r.namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get restored EtcdCluster (%s/%s): %v", r.namespace, name, err)
+	}
+	if ec.UID != er.Status.ClusterUID {
+		return fmt.Errorf("EtcdCluster (%s/%s) is not the cluster this restore created, recreate the EtcdRestore to restore it again", r.namespace, name)
+	}
+	if from == api.RestorePhaseScaling {
+		r.setPhase(er, api.RestorePhaseScaling)
+		r.queue.AddAfter(key, scalingCheckInterval)
+		return nil
+	}
+	return r.replayAndScale(er, key)
+}
+
+// replayAndScale replays the changes after the backup of a point-in-time restore on
+// the restored cluster, then waits for the etcd operator to scale the cluster.
+func (r *Restore) replayAndScale(er *api.EtcdRestore, key string) (err error) {
+	if er.Spec.IsPointInTime() {
+		r.setPhase(er, api.RestorePhaseReplaying)
+		er.Status.ReplayedRevision, err = r.replaySegments(er)
+		if err != nil {
+			return fmt.Errorf("failed to replay incremental backup: %v", err)
+		}
+		if er.Status.ReplayedRevision != 0 {
+			r.recorder.Eventf(er, v1.EventTypeNormal, k8sutil.EventReasonRestoreProgressing, "Replayed changes up to revision %d", er.Status.ReplayedRevision)
+		}
+	}
+	er.Status.BytesTransferred = r.takeServedBytes(er.Name)
+	r.setPhase(er, api.RestorePhaseScaling)
+	r.queue.AddAfter(key, scalingCheckInterval)
+	return nil
+}
+
+// waitForScaling completes the restore once the restored cluster has as many ready
+// members as its size, and checks it again later otherwise.
+func (r *Restore) waitForScaling(er *api.EtcdRestore, key string) error {
+	name := er.Spec.EtcdCluster.Name
+	ec, err := r.etcdCRCli.EtcdV1beta2().EtcdClusters(r.namespace).Get(name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	switch {
+	case err != nil || ec.UID != er.Status.ClusterUID:
+		r.reportStatus(fmt.Errorf("restored EtcdCluster (%s/%s) was deleted", r.namespace, name), er)
+	case ec.Status.IsFailed():
+		r.reportStatus(fmt.Errorf("restored EtcdCluster (%s/%s) failed: %s", r.namespace, name, ec.Status.Reason), er)
+	case len(ec.Status.Members.Ready) < ec.Spec.Size:
+		r.queue.AddAfter(key, scalingCheckInterval)
+	default:
+		r.reportStatus(nil, er)
+	}
+	return nil
+}
+
+// setPhase moves the restore to phase p and saves its status.
+func (r *Restore) setPhase(er *api.EtcdRestore, p api.OperationPhase) {
+	er.Status.SetPhase(p)
+	r.updateStatus(er)
+}
+
+// updateStatus saves the status of the restore, retrying on conflicts with
+// changes to the rest of the CR.
+func (r *Restore) updateStatus(er *api.EtcdRestore) {
+	cli := r.etcdCRCli.EtcdV1beta2().EtcdRestores(r.namespace)
+	err := retryutil.Retry(time.Second, 3, func() (bool, error) {
+		updated, err := cli.Update(er)
+		if err == nil {
+			er.ResourceVersion = updated.ResourceVersion
+			return true, nil
+		}
+		if !apierrors.IsConflict(err) {
+			return false, err
+		}
+		latest, err := cli.Get(er.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		latest.Status = er.Status
+		*er = *latest
+		return false, nil
+	})
+	if err != nil {
+		r.logger.Warningf("failed to update status of restore CR %v : (%v)", er.Name, err)
+	}
 }
 
 func (r *Restore) reportStatus(rerr error, er *api.EtcdRestore) {
 	if rerr != nil {
 		er.Status.Succeeded = false
 		er.Status.Reason = rerr.Error()
+		er.Status.SetFailed(er.Status.Reason)
+		r.recorder.Eventf(er, v1.EventTypeWarning, k8sutil.EventReasonRestoreFailed, "Restore failed: %v", rerr)
+		r.updateStatus(er)
+		return
+	}
+	er.Status.SetCompleted()
+	if er.Spec.IsKeyRestore() {
+		er.Status.Succeeded = true
+		st := er.Status.Keys
+		verb := "Restored"
+		if er.Spec.DryRun {
+			verb = "Dry run: would restore"
+		}
+		r.recorder.Eventf(er, v1.EventTypeNormal, k8sutil.EventReasonRestoreSucceeded, "%s keys of cluster %s: %d created, %d updated, %d skipped, %d unchanged", verb, er.Spec.EtcdCluster.Name, st.Created, st.Updated, st.Skipped, st.Unchanged)
+	} else if er.Spec.DryRun {
+		er.Status.Succeeded = true
+		r.recorder.Eventf(er, v1.EventTypeNormal, k8sutil.EventReasonRestoreSucceeded, "Dry run: cluster %s can be restored from backup %s at revision %d", er.Spec.EtcdCluster.Name, er.Status.BackupPath, er.Status.DryRun.SnapshotRevision)
 	} else {
 		er.Status.Succeeded = true
+		r.recorder.Eventf(er, v1.EventTypeNormal, k8sutil.EventReasonRestoreSucceeded, "Cluster %s is restored from backup and resumed", er.Spec.EtcdCluster.Name)
 	}
-	_, err := r.etcdCRCli.EtcdV1beta2().EtcdRestores(r.namespace).Update(er)
-	if err != nil {
-		r.logger.Warningf("failed to update status of restore CR %v : (%v)", er.Name, err)
-	}
+	r.updateStatus(er)
 }
 
 func (r *Restore) handleErr(err error, key interface{}) {
@@ -155,14 +367,15 @@ func (r *Restore) prepareSeed(er *api.EtcdRestore) (err error) {
 	if err := ec.Spec.Validate(); err != nil {
 		return fmt.Errorf("invalid cluster spec: %v", err)
 	}
-
 	// Delete reference EtcdCluster
+	r.setPhase(er, api.RestorePhaseDeletingCluster)
 	err = r.etcdCRCli.EtcdV1beta2().EtcdClusters(r.namespace).Delete(ecRef.Name, &metav1.DeleteOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to delete reference EtcdCluster (%s/%s): %v", r.namespace, ecRef.Name, err)
 	}
 	// Need to delete etcd pods, etc. completely before creating new cluster.
 	r.deleteClusterResourcesCompletely(ecRef.Name)
+	r.recorder.Eventf(er, v1.EventTypeNormal, k8sutil.EventReasonRestoreProgressing, "Deleted cluster %s", ecRef.Name)
 
 	// Create the restored EtcdCluster with the same metadata and spec as reference EtcdCluster
 	clusterName := ecRef.Name
@@ -176,17 +389,20 @@ func (r *Restore) prepareSeed(er *api.EtcdRestore) (err error) {
 		Spec: ec.Spec,
 	}
 
+	r.setPhase(er, api.RestorePhaseSeedingMember)
 	ec.Spec.Paused = true
 	ec.Status.Phase = api.ClusterPhaseRunning
 	ec, err = r.etcdCRCli.EtcdV1beta2().EtcdClusters(r.namespace).Create(ec)
 	if err != nil {
 		return fmt.Errorf("failed to create restored EtcdCluster (%s/%s): %v", r.namespace, clusterName, err)
 	}
+	er.Status.ClusterUID = ec.UID
 
 	err = r.createSeedMember(ec, r.mySvcAddr, clusterName, ec.AsOwner())
 	if err != nil {
 		return fmt.Errorf("failed to create seed member for cluster (%s): %v", clusterName, err)
 	}
+	r.recorder.Eventf(er, v1.EventTypeNormal, k8sutil.EventReasonRestoreProgressing, "Created seed member of cluster %s", clusterName)
 
 	// Retry updating the etcdcluster CR spec.paused=false. The etcd-operator will update the CR once so there needs to be a single retry in case of conflict
 	err = retryutil.Retry(2, 1, func() (bool, error) {
@@ -218,9 +434,21 @@ func (r *Restore) createSeedMember(ec *api.EtcdCluster, svcAddr, clusterName str
 		SecureClient: ec.Spec.TLS.IsSecureClient(),
 	}
 	ms := etcdutil.NewMemberSet(m)
-	backupURL := backupapi.BackupURLForRestore("http", svcAddr, clusterName)
+	// The restore CR has the same name as the cluster.
+	if err := r.createBackupToken(clusterName, owner); err != nil {
+		return err
+	}
+	scheme := "http"
+	if len(r.servingTLSSecret) != 0 {
+		scheme = "https"
+	}
+	bs := &k8sutil.BackupSource{
+		URL:         backupapi.BackupURLForRestore(scheme, svcAddr, clusterName),
+		TokenSecret: backupTokenSecretName(clusterName),
+		CASecret:    r.servingTLSSecret,
+	}
 	ec.SetDefaults()
-	pod := k8sutil.NewSeedMemberPod(clusterName, ms, m, ec.Spec, owner, backupURL)
+	pod := k8sutil.NewSeedMemberPod(clusterName, ms, m, ec.Spec, owner, bs)
 	_, err := r.kubecli.Core().Pods(r.namespace).Create(pod)
 	return err
 }
@@ -0,0 +1,250 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/generated/clientset/versioned/fake"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestRestoreShouldRun(t *testing.T) {
+	tests := []struct {
+		status api.RestoreStatus
+		retry  string
+		want   bool
+	}{
+		{want: true},
+		{status: api.RestoreStatus{OperationStatus: api.OperationStatus{Phase: api.RestorePhaseReplaying}}},
+		{status: api.RestoreStatus{OperationStatus: api.OperationStatus{Phase: api.OperationPhaseCompleted}, Succeeded: true}, retry: "1"},
+		{status: api.RestoreStatus{OperationStatus: api.OperationStatus{Phase: api.OperationPhaseFailed}, Reason: "failed"}},
+		{status: api.RestoreStatus{OperationStatus: api.OperationStatus{Phase: api.OperationPhaseFailed}, Reason: "failed"}, retry: "1", want: true},
+		{status: api.RestoreStatus{OperationStatus: api.OperationStatus{Phase: api.OperationPhaseFailed, ObservedRetry: "1"}, Reason: "failed"}, retry: "1"},
+		// statuses without a phase
+		{status: api.RestoreStatus{Succeeded: true}, retry: "1"},
+		{status: api.RestoreStatus{Reason: "failed"}},
+		{status: api.RestoreStatus{Reason: "failed"}, retry: "1", want: true},
+	}
+	for i, tt := range tests {
+		er := &api.EtcdRestore{Status: tt.status}
+		if len(tt.retry) != 0 {
+			er.Annotations = map[string]string{api.RetryAnnotation: tt.retry}
+		}
+		if got := er.ShouldRun(); got != tt.want {
+			t.Errorf("#%d: expect %v, get %v", i, tt.want, got)
+		}
+	}
+}
+
+func TestSetPhase(t *testing.T) {
+	var s api.OperationStatus
+	s.SetPhase(api.OperationPhasePending)
+	s.SetPhase(api.RestorePhaseFetchingSnapshot)
+	if !s.InProgress() || s.StartTime == nil || s.CompletionTime != nil {
+		t.Fatalf("unexpected status in progress: %+v", s)
+	}
+	s.SetFailed("no backup")
+
+	if s.Phase != api.OperationPhaseFailed || s.InProgress() || s.CompletionTime == nil {
+		t.Fatalf("unexpected failed status: %+v", s)
+	}
+	want := []struct {
+		phase  api.OperationPhase
+		status v1.ConditionStatus
+		msg    string
+	}{
+		{api.OperationPhasePending, v1.ConditionTrue, ""},
+		{api.RestorePhaseFetchingSnapshot, v1.ConditionFalse, "no backup"},
+	}
+	if len(s.Conditions) != len(want) {
+		t.Fatalf("expect %d conditions, get %+v", len(want), s.Conditions)
+	}
+	for i, w := range want {
+		c := s.Conditions[i]
+		if c.Type != w.phase || c.Status != w.status || c.Message != w.msg {
+			t.Errorf("#%d: expect condition %v=%v (%q), get %+v", i, w.phase, w.status, w.msg, c)
+		}
+	}
+}
+
+func TestHandleCRInterrupted(t *testing.T) {
+	er := &api.EtcdRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec:       api.RestoreSpec{EtcdCluster: api.EtcdClusterRef{Name: "example"}},
+	}
+	er.Status.SetPhase(api.RestorePhaseSeedingMember)
+	cli := fake.NewSimpleClientset(er)
+	r := &Restore{
+		logger:    logrus.WithField("pkg", "test"),
+		namespace: "default",
+		etcdCRCli: cli,
+		recorder:  record.NewFakeRecorder(10),
+	}
+
+	if err := r.handleCR(er.DeepCopy(), "default/example"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cli.EtcdV1beta2().EtcdRestores("default").Get("example", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.Phase != api.OperationPhaseFailed || got.Status.Succeeded {
+		t.Errorf("expect failed restore, get %+v", got.Status)
+	}
+	if got.ShouldRun() {
+		t.Error("expect interrupted restore not to run without a retry")
+	}
+	got.Annotations = map[string]string{api.RetryAnnotation: "1"}
+	if !got.ShouldRun() {
+		t.Error("expect interrupted restore to run after a retry")
+	}
+}
+
+func TestHandleCRResumesScaling(t *testing.T) {
+	ec := &api.EtcdCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", UID: "restored-uid"},
+		Spec:       api.ClusterSpec{Size: 3},
+	}
+	er := &api.EtcdRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "example",
+			Namespace:   "default",
+			Annotations: map[string]string{api.RetryAnnotation: "1"},
+		},
+		Spec: api.RestoreSpec{
+			BackupStorageType: api.BackupStorageTypeS3,
+			RestoreSource:     api.RestoreSource{S3: &api.S3RestoreSource{Path: "bucket/example", AWSSecret: "aws"}},
+			EtcdCluster:       api.EtcdClusterRef{Name: "example"},
+		},
+		Status: api.RestoreStatus{ClusterUID: "restored-uid", BackupPath: "bucket/example", Reason: "restored EtcdCluster (default/example) failed"},
+	}
+	er.Status.SetPhase(api.RestorePhaseScaling)
+	er.Status.SetFailed(er.Status.Reason)
+	cli := fake.NewSimpleClientset(ec, er)
+	r := &Restore{
+		logger:    logrus.WithField("pkg", "test"),
+		namespace: "default",
+		etcdCRCli: cli,
+		queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		recorder:  record.NewFakeRecorder(10),
+	}
+	defer r.queue.ShutDown()
+
+	if err := r.handleCR(er.DeepCopy(), "default/example"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cli.EtcdV1beta2().EtcdRestores("default").Get("example", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.Phase != api.RestorePhaseScaling || got.Status.BackupPath != "bucket/example" {
+		t.Errorf("expect the retry to resume scaling, get %+v", got.Status)
+	}
+	if _, err := cli.EtcdV1beta2().EtcdClusters("default").Get("example", metav1.GetOptions{}); err != nil {
+		t.Errorf("expect the restored cluster to be kept, get %v", err)
+	}
+}
+
+func TestWaitForScaling(t *testing.T) {
+	tests := []struct {
+		uid       types.UID
+		ready     []string
+		wantPhase api.OperationPhase
+	}{
+		{uid: "restored-uid", ready: []string{"example-0000"}, wantPhase: api.RestorePhaseScaling},
+		{uid: "restored-uid", ready: []string{"example-0000", "example-0001", "example-0002"}, wantPhase: api.OperationPhaseCompleted},
+		// the restored cluster was replaced
+		{uid: "other-uid", wantPhase: api.OperationPhaseFailed},
+	}
+	for i, tt := range tests {
+		ec := &api.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", UID: tt.uid},
+			Spec:       api.ClusterSpec{Size: 3},
+			Status:     api.ClusterStatus{Members: api.MembersStatus{Ready: tt.ready}},
+		}
+		er := &api.EtcdRestore{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec:       api.RestoreSpec{EtcdCluster: api.EtcdClusterRef{Name: "example"}},
+			Status:     api.RestoreStatus{ClusterUID: "restored-uid"},
+		}
+		er.Status.SetPhase(api.RestorePhaseScaling)
+		cli := fake.NewSimpleClientset(ec, er)
+		r := &Restore{
+			logger:    logrus.WithField("pkg", "test"),
+			namespace: "default",
+			etcdCRCli: cli,
+			queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+			recorder:  record.NewFakeRecorder(10),
+		}
+
+		if err := r.waitForScaling(er.DeepCopy(), "default/example"); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		r.queue.ShutDown()
+		got, err := cli.EtcdV1beta2().EtcdRestores("default").Get("example", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status.Phase != tt.wantPhase {
+			t.Errorf("#%d: expect phase %s, get %s", i, tt.wantPhase, got.Status.Phase)
+		}
+		if succeeded := tt.wantPhase == api.OperationPhaseCompleted; got.Status.Succeeded != succeeded {
+			t.Errorf("#%d: expect succeeded %v, get %v", i, succeeded, got.Status.Succeeded)
+		}
+	}
+}
+
+func TestProcessItemSavesServedBytes(t *testing.T) {
+	er := &api.EtcdRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec:       api.RestoreSpec{EtcdCluster: api.EtcdClusterRef{Name: "example"}},
+	}
+	er.Status.SetCompleted()
+	er.Status.Succeeded = true
+	cli := fake.NewSimpleClientset(er)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(er); err != nil {
+		t.Fatal(err)
+	}
+	r := &Restore{
+		logger:    logrus.WithField("pkg", "test"),
+		namespace: "default",
+		etcdCRCli: cli,
+		indexer:   indexer,
+		recorder:  record.NewFakeRecorder(10),
+	}
+
+	// The seed member fetched the backup after the restore completed.
+	r.recordServedBytes("example", 2048)
+	if err := r.processItem("default/example"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cli.EtcdV1beta2().EtcdRestores("default").Get("example", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.BytesTransferred != 2048 || got.Status.Phase != api.OperationPhaseCompleted {
+		t.Errorf("expect completed restore with 2048 bytes transferred, get %+v", got.Status)
+	}
+}
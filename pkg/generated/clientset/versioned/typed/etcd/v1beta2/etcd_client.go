@@ -26,6 +26,7 @@ type EtcdV1beta2Interface interface {
 	RESTClient() rest.Interface
 	EtcdBackupsGetter
 	EtcdClustersGetter
+	EtcdMirrorsGetter
 	EtcdRestoresGetter
 }
 
@@ -42,6 +43,10 @@ func (c *EtcdV1beta2Client) EtcdClusters(namespace string) EtcdClusterInterface
 	return newEtcdClusters(c, namespace)
 }
 
+func (c *EtcdV1beta2Client) EtcdMirrors(namespace string) EtcdMirrorInterface {
+	return newEtcdMirrors(c, namespace)
+}
+
 func (c *EtcdV1beta2Client) EtcdRestores(namespace string) EtcdRestoreInterface {
 	return newEtcdRestores(c, namespace)
 }
@@ -0,0 +1,171 @@
+/*
+Copyright 2018 The etcd-operator Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta2
+
+import (
+	v1beta2 "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	scheme "github.com/coreos/etcd-operator/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// EtcdMirrorsGetter has a method to return a EtcdMirrorInterface.
+// A group's client should implement this interface.
+type EtcdMirrorsGetter interface {
+	EtcdMirrors(namespace string) EtcdMirrorInterface
+}
+
+// EtcdMirrorInterface has methods to work with EtcdMirror resources.
+type EtcdMirrorInterface interface {
+	Create(*v1beta2.EtcdMirror) (*v1beta2.EtcdMirror, error)
+	Update(*v1beta2.EtcdMirror) (*v1beta2.EtcdMirror, error)
+	UpdateStatus(*v1beta2.EtcdMirror) (*v1beta2.EtcdMirror, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1beta2.EtcdMirror, error)
+	List(opts v1.ListOptions) (*v1beta2.EtcdMirrorList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta2.EtcdMirror, err error)
+	EtcdMirrorExpansion
+}
+
+// etcdMirrors implements EtcdMirrorInterface
+type etcdMirrors struct {
+	client rest.Interface
+	ns     string
+}
+
+// newEtcdMirrors returns a EtcdMirrors
+func newEtcdMirrors(c *EtcdV1beta2Client, namespace string) *etcdMirrors {
+	return &etcdMirrors{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the etcdMirror, and returns the corresponding etcdMirror object, and an error if there is any.
+func (c *etcdMirrors) Get(name string, options v1.GetOptions) (result *v1beta2.EtcdMirror, err error) {
+	result = &v1beta2.EtcdMirror{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("etcdmirrors").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of EtcdMirrors that match those selectors.
+func (c *etcdMirrors) List(opts v1.ListOptions) (result *v1beta2.EtcdMirrorList, err error) {
+	result = &v1beta2.EtcdMirrorList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("etcdmirrors").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested etcdMirrors.
+func (c *etcdMirrors) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("etcdmirrors").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+// Create takes the representation of a etcdMirror and creates it.  Returns the server's representation of the etcdMirror, and an error, if there is any.
+func (c *etcdMirrors) Create(etcdMirror *v1beta2.EtcdMirror) (result *v1beta2.EtcdMirror, err error) {
+	result = &v1beta2.EtcdMirror{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("etcdmirrors").
+		Body(etcdMirror).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a etcdMirror and updates it. Returns the server's representation of the etcdMirror, and an error, if there is any.
+func (c *etcdMirrors) Update(etcdMirror *v1beta2.EtcdMirror) (result *v1beta2.EtcdMirror, err error) {
+	result = &v1beta2.EtcdMirror{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("etcdmirrors").
+		Name(etcdMirror.Name).
+		Body(etcdMirror).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *etcdMirrors) UpdateStatus(etcdMirror *v1beta2.EtcdMirror) (result *v1beta2.EtcdMirror, err error) {
+	result = &v1beta2.EtcdMirror{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("etcdmirrors").
+		Name(etcdMirror.Name).
+		SubResource("status").
+		Body(etcdMirror).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the etcdMirror and deletes it. Returns an error if one occurs.
+func (c *etcdMirrors) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("etcdmirrors").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *etcdMirrors) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("etcdmirrors").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched etcdMirror.
+func (c *etcdMirrors) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta2.EtcdMirror, err error) {
+	result = &v1beta2.EtcdMirror{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("etcdmirrors").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
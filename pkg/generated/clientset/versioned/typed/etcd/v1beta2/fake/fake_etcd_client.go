@@ -33,6 +33,10 @@ func (c *FakeEtcdV1beta2) EtcdClusters(namespace string) v1beta2.EtcdClusterInte
 	return &FakeEtcdClusters{c, namespace}
 }
 
+func (c *FakeEtcdV1beta2) EtcdMirrors(namespace string) v1beta2.EtcdMirrorInterface {
+	return &FakeEtcdMirrors{c, namespace}
+}
+
 func (c *FakeEtcdV1beta2) EtcdRestores(namespace string) v1beta2.EtcdRestoreInterface {
 	return &FakeEtcdRestores{c, namespace}
 }
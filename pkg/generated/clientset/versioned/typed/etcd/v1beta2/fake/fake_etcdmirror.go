@@ -0,0 +1,137 @@
+/*
+Copyright 2018 The etcd-operator Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fake
+
+import (
+	v1beta2 "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeEtcdMirrors implements EtcdMirrorInterface
+type FakeEtcdMirrors struct {
+	Fake *FakeEtcdV1beta2
+	ns   string
+}
+
+var etcdmirrorsResource = schema.GroupVersionResource{Group: "etcd.database.coreos.com", Version: "v1beta2", Resource: "etcdmirrors"}
+
+var etcdmirrorsKind = schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdMirror"}
+
+// Get takes name of the etcdMirror, and returns the corresponding etcdMirror object, and an error if there is any.
+func (c *FakeEtcdMirrors) Get(name string, options v1.GetOptions) (result *v1beta2.EtcdMirror, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(etcdmirrorsResource, c.ns, name), &v1beta2.EtcdMirror{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta2.EtcdMirror), err
+}
+
+// List takes label and field selectors, and returns the list of EtcdMirrors that match those selectors.
+func (c *FakeEtcdMirrors) List(opts v1.ListOptions) (result *v1beta2.EtcdMirrorList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(etcdmirrorsResource, etcdmirrorsKind, c.ns, opts), &v1beta2.EtcdMirrorList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1beta2.EtcdMirrorList{}
+	for _, item := range obj.(*v1beta2.EtcdMirrorList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested etcdMirrors.
+func (c *FakeEtcdMirrors) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(etcdmirrorsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a etcdMirror and creates it.  Returns the server's representation of the etcdMirror, and an error, if there is any.
+func (c *FakeEtcdMirrors) Create(etcdMirror *v1beta2.EtcdMirror) (result *v1beta2.EtcdMirror, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(etcdmirrorsResource, c.ns, etcdMirror), &v1beta2.EtcdMirror{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta2.EtcdMirror), err
+}
+
+// Update takes the representation of a etcdMirror and updates it. Returns the server's representation of the etcdMirror, and an error, if there is any.
+func (c *FakeEtcdMirrors) Update(etcdMirror *v1beta2.EtcdMirror) (result *v1beta2.EtcdMirror, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(etcdmirrorsResource, c.ns, etcdMirror), &v1beta2.EtcdMirror{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta2.EtcdMirror), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeEtcdMirrors) UpdateStatus(etcdMirror *v1beta2.EtcdMirror) (*v1beta2.EtcdMirror, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(etcdmirrorsResource, "status", c.ns, etcdMirror), &v1beta2.EtcdMirror{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta2.EtcdMirror), err
+}
+
+// Delete takes name of the etcdMirror and deletes it. Returns an error if one occurs.
+func (c *FakeEtcdMirrors) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(etcdmirrorsResource, c.ns, name), &v1beta2.EtcdMirror{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeEtcdMirrors) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(etcdmirrorsResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1beta2.EtcdMirrorList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched etcdMirror.
+func (c *FakeEtcdMirrors) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta2.EtcdMirror, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(etcdmirrorsResource, c.ns, name, data, subresources...), &v1beta2.EtcdMirror{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta2.EtcdMirror), err
+}
@@ -19,4 +19,6 @@ type EtcdBackupExpansion interface{}
 
 type EtcdClusterExpansion interface{}
 
+type EtcdMirrorExpansion interface{}
+
 type EtcdRestoreExpansion interface{}
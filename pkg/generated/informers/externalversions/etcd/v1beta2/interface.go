@@ -28,6 +28,8 @@ type Interface interface {
 	EtcdBackups() EtcdBackupInformer
 	// EtcdClusters returns a EtcdClusterInformer.
 	EtcdClusters() EtcdClusterInformer
+	// EtcdMirrors returns a EtcdMirrorInformer.
+	EtcdMirrors() EtcdMirrorInformer
 	// EtcdRestores returns a EtcdRestoreInformer.
 	EtcdRestores() EtcdRestoreInformer
 }
@@ -51,6 +53,11 @@ func (v *version) EtcdClusters() EtcdClusterInformer {
 	return &etcdClusterInformer{factory: v.SharedInformerFactory}
 }
 
+// EtcdMirrors returns a EtcdMirrorInformer.
+func (v *version) EtcdMirrors() EtcdMirrorInformer {
+	return &etcdMirrorInformer{factory: v.SharedInformerFactory}
+}
+
 // EtcdRestores returns a EtcdRestoreInformer.
 func (v *version) EtcdRestores() EtcdRestoreInformer {
 	return &etcdRestoreInformer{factory: v.SharedInformerFactory}
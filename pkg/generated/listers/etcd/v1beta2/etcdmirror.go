@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The etcd-operator Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file was automatically generated by lister-gen
+
+package v1beta2
+
+import (
+	v1beta2 "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// EtcdMirrorLister helps list EtcdMirrors.
+type EtcdMirrorLister interface {
+	// List lists all EtcdMirrors in the indexer.
+	List(selector labels.Selector) (ret []*v1beta2.EtcdMirror, err error)
+	// EtcdMirrors returns an object that can list and get EtcdMirrors.
+	EtcdMirrors(namespace string) EtcdMirrorNamespaceLister
+	EtcdMirrorListerExpansion
+}
+
+// etcdMirrorLister implements the EtcdMirrorLister interface.
+type etcdMirrorLister struct {
+	indexer cache.Indexer
+}
+
+// NewEtcdMirrorLister returns a new EtcdMirrorLister.
+func NewEtcdMirrorLister(indexer cache.Indexer) EtcdMirrorLister {
+	return &etcdMirrorLister{indexer: indexer}
+}
+
+// List lists all EtcdMirrors in the indexer.
+func (s *etcdMirrorLister) List(selector labels.Selector) (ret []*v1beta2.EtcdMirror, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta2.EtcdMirror))
+	})
+	return ret, err
+}
+
+// EtcdMirrors returns an object that can list and get EtcdMirrors.
+func (s *etcdMirrorLister) EtcdMirrors(namespace string) EtcdMirrorNamespaceLister {
+	return etcdMirrorNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// EtcdMirrorNamespaceLister helps list and get EtcdMirrors.
+type EtcdMirrorNamespaceLister interface {
+	// List lists all EtcdMirrors in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1beta2.EtcdMirror, err error)
+	// Get retrieves the EtcdMirror from the indexer for a given namespace and name.
+	Get(name string) (*v1beta2.EtcdMirror, error)
+	EtcdMirrorNamespaceListerExpansion
+}
+
+// etcdMirrorNamespaceLister implements the EtcdMirrorNamespaceLister
+// interface.
+type etcdMirrorNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all EtcdMirrors in the indexer for a given namespace.
+func (s etcdMirrorNamespaceLister) List(selector labels.Selector) (ret []*v1beta2.EtcdMirror, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta2.EtcdMirror))
+	})
+	return ret, err
+}
+
+// Get retrieves the EtcdMirror from the indexer for a given namespace and name.
+func (s etcdMirrorNamespaceLister) Get(name string) (*v1beta2.EtcdMirror, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1beta2.Resource("etcdmirror"), name)
+	}
+	return obj.(*v1beta2.EtcdMirror), nil
+}
@@ -34,6 +34,14 @@ type EtcdClusterListerExpansion interface{}
 // EtcdClusterNamespaceLister.
 type EtcdClusterNamespaceListerExpansion interface{}
 
+// EtcdMirrorListerExpansion allows custom methods to be added to
+// EtcdMirrorLister.
+type EtcdMirrorListerExpansion interface{}
+
+// EtcdMirrorNamespaceListerExpansion allows custom methods to be added to
+// EtcdMirrorNamespaceLister.
+type EtcdMirrorNamespaceListerExpansion interface{}
+
 // EtcdRestoreListerExpansion allows custom methods to be added to
 // EtcdRestoreLister.
 type EtcdRestoreListerExpansion interface{}
@@ -20,41 +20,88 @@ import (
 	"fmt"
 
 	"github.com/coreos/etcd-operator/pkg/util/constants"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
+
 	"github.com/coreos/etcd/clientv3"
 )
 
-func ListMembers(clientURLs []string, tc *tls.Config) (*clientv3.MemberListResponse, error) {
+// Credentials are the user name and password of an etcd user.
+// A nil *Credentials means the etcd cluster has no authentication enabled.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// NewClientConfig returns the etcd client config for the given endpoints,
+// TLS config and credentials.
+func NewClientConfig(endpoints []string, tc *tls.Config, creds *Credentials) clientv3.Config {
 	cfg := clientv3.Config{
-		Endpoints:   clientURLs,
+		Endpoints:   endpoints,
 		DialTimeout: constants.DefaultDialTimeout,
 		TLS:         tc,
 	}
+	if creds != nil {
+		cfg.Username = creds.Username
+		cfg.Password = creds.Password
+	}
+	return cfg
+}
+
+// ListMembers lists the members of the etcd cluster serving at clientURLs.
+// The call is traced as a child of the span in ctx.
+func ListMembers(ctx context.Context, clientURLs []string, tc *tls.Config, creds *Credentials) (resp *clientv3.MemberListResponse, err error) {
+	_, span := tracing.Start(ctx, "etcdutil.ListMembers")
+	defer func() { span.End(err) }()
+
+	cfg := NewClientConfig(clientURLs, tc, creds)
 	etcdcli, err := clientv3.New(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("list members failed: creating etcd client failed: %v", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
-	resp, err := etcdcli.MemberList(ctx)
+	rctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	resp, err = etcdcli.MemberList(rctx)
 	cancel()
 	etcdcli.Close()
 	return resp, err
 }
 
-func RemoveMember(clientURLs []string, tc *tls.Config, id uint64) error {
-	cfg := clientv3.Config{
-		Endpoints:   clientURLs,
-		DialTimeout: constants.DefaultDialTimeout,
-		TLS:         tc,
-	}
+// RemoveMember removes the member with the given ID from the etcd cluster serving at clientURLs.
+// The call is traced as a child of the span in ctx.
+func RemoveMember(ctx context.Context, clientURLs []string, tc *tls.Config, creds *Credentials, id uint64) (err error) {
+	_, span := tracing.Start(ctx, "etcdutil.RemoveMember")
+	span.SetAttribute("etcd.member_id", fmt.Sprintf("%x", id))
+	defer func() { span.End(err) }()
+
+	cfg := NewClientConfig(clientURLs, tc, creds)
 	etcdcli, err := clientv3.New(cfg)
 	if err != nil {
 		return err
 	}
 	defer etcdcli.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
-	_, err = etcdcli.Cluster.MemberRemove(ctx, id)
+	rctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	_, err = etcdcli.Cluster.MemberRemove(rctx, id)
 	cancel()
 	return err
 }
+
+// MemberStatus returns the status of the etcd member serving at clientURL.
+// The call is traced as a child of the span in ctx.
+func MemberStatus(ctx context.Context, clientURL string, tc *tls.Config, creds *Credentials) (resp *clientv3.StatusResponse, err error) {
+	_, span := tracing.Start(ctx, "etcdutil.MemberStatus")
+	span.SetAttribute("etcd.endpoint", clientURL)
+	defer func() { span.End(err) }()
+
+	cfg := NewClientConfig([]string{clientURL}, tc, creds)
+	etcdcli, err := clientv3.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("get member status failed: creating etcd client failed: %v", err)
+	}
+	defer etcdcli.Close()
+
+	rctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	resp, err = etcdcli.Status(rctx, clientURL)
+	cancel()
+	return resp, err
+}
@@ -0,0 +1,73 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutil
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
+)
+
+// versions is the response of etcd's /version endpoint.
+type versions struct {
+	Server  string `json:"etcdserver"`
+	Cluster string `json:"etcdcluster"`
+}
+
+// ClusterVersion returns the cluster version agreed on by the etcd members.
+// It is the lowest "major.minor.0" version among the members, or "not_decided"
+// while the cluster is bootstrapping.
+// The call is traced as a child of the span in ctx.
+func ClusterVersion(ctx context.Context, clientURLs []string, tc *tls.Config) (cv string, err error) {
+	_, span := tracing.Start(ctx, "etcdutil.ClusterVersion")
+	defer func() { span.End(err) }()
+
+	cli := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tc},
+		Timeout:   constants.DefaultRequestTimeout,
+	}
+	lastErr := errors.New("no client URL given")
+	for _, u := range clientURLs {
+		v, err := getVersions(cli, u)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return v.Cluster, nil
+	}
+	return "", fmt.Errorf("failed to get cluster version: %v", lastErr)
+}
+
+func getVersions(cli *http.Client, clientURL string) (*versions, error) {
+	resp, err := cli.Get(clientURL + "/version")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code (%d) from %s", resp.StatusCode, clientURL)
+	}
+	v := &versions{}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("failed to decode version of %s: %v", clientURL, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coreos/etcd-operator/pkg/util/tracing"
+)
+
+func TestClusterVersion(t *testing.T) {
+	e := &tracing.MemoryExporter{}
+	tracing.SetExporter(e)
+	defer tracing.SetExporter(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"etcdserver":"3.2.16","etcdcluster":"3.2.0"}`))
+	}))
+	defer srv.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+
+	ctx, span := tracing.Start(context.Background(), "Cluster.reconcile")
+	cv, err := ClusterVersion(ctx, []string{down.URL, srv.URL}, nil)
+	span.End(nil)
+	if err != nil || cv != "3.2.0" {
+		t.Errorf("expect cluster version 3.2.0, get (%s, %v)", cv, err)
+	}
+
+	spans := e.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expect 2 spans, get %d", len(spans))
+	}
+	if spans[0].Name != "etcdutil.ClusterVersion" || spans[0].ParentID != spans[1].SpanID {
+		t.Errorf("expect an etcdutil.ClusterVersion child span, get %+v", spans[0])
+	}
+}
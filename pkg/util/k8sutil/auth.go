@@ -0,0 +1,45 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sutil
+
+import (
+	"fmt"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// GetCredentialsFromSecret retrieves the kubernetes secret that contains the
+// credentials of an etcd user. If the secret has no user name, defaultUsername is used.
+func GetCredentialsFromSecret(kubecli kubernetes.Interface, ns, se, defaultUsername string) (*etcdutil.Credentials, error) {
+	secret, err := kubecli.CoreV1().Secrets(ns).Get(se, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	creds := &etcdutil.Credentials{
+		Username: string(secret.Data[api.AuthSecretUsernameKey]),
+		Password: string(secret.Data[api.AuthSecretPasswordKey]),
+	}
+	if len(creds.Username) == 0 {
+		creds.Username = defaultUsername
+	}
+	if len(creds.Username) == 0 || len(creds.Password) == 0 {
+		return nil, fmt.Errorf("secret (%s) has no %q or %q", se, api.AuthSecretUsernameKey, api.AuthSecretPasswordKey)
+	}
+	return creds, nil
+}
@@ -15,70 +15,49 @@
 package k8sutil
 
 import (
-	"fmt"
-	"os"
-	"time"
-
-	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
-	"github.com/coreos/etcd-operator/pkg/util/constants"
+	etcdscheme "github.com/coreos/etcd-operator/pkg/generated/clientset/versioned/scheme"
 
+	"github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
-func NewMemberAddEvent(memberName string, cl *api.EtcdCluster) *v1.Event {
-	event := newClusterEvent(cl)
-	event.Type = v1.EventTypeNormal
-	event.Reason = "New Member Added"
-	event.Message = fmt.Sprintf("New member %s added to cluster", memberName)
-	return event
-}
-
-func MemberRemoveEvent(memberName string, cl *api.EtcdCluster) *v1.Event {
-	event := newClusterEvent(cl)
-	event.Type = v1.EventTypeNormal
-	event.Reason = "Member Removed"
-	event.Message = fmt.Sprintf("Existing member %s removed from the cluster", memberName)
-	return event
-}
-
-func ReplacingDeadMemberEvent(memberName string, cl *api.EtcdCluster) *v1.Event {
-	event := newClusterEvent(cl)
-	event.Type = v1.EventTypeNormal
-	event.Reason = "Replacing Dead Member"
-	event.Message = fmt.Sprintf("The dead member %s is being replaced", memberName)
-	return event
-}
-
-func MemberUpgradedEvent(memberName, oldVersion, newVersion string, cl *api.EtcdCluster) *v1.Event {
-	event := newClusterEvent(cl)
-	event.Type = v1.EventTypeNormal
-	event.Reason = "Member Upgraded"
-	event.Message = fmt.Sprintf("Member %s upgraded from %s to %s ", memberName, oldVersion, newVersion)
-	return event
-}
+// Reasons of the events the operators record on etcd custom resources.
+const (
+	EventReasonMemberAdded            = "MemberAdded"
+	EventReasonMemberRemoved          = "MemberRemoved"
+	EventReasonReplacingDeadMember    = "ReplacingDeadMember"
+	EventReasonRecoveringDeadMember   = "RecoveringDeadMember"
+	EventReasonMemberUpgraded         = "MemberUpgraded"
+	EventReasonServerCertMissingNames = "ServerCertMissingNames"
+	EventReasonQuorumLost             = "QuorumLost"
+	EventReasonPodCreationFailed      = "PodCreationFailed"
+	EventReasonTLSConfigFailed        = "TLSConfigFailed"
+	EventReasonPhaseChanged           = "PhaseChanged"
+	EventReasonPaused                 = "Paused"
+	EventReasonResumed                = "Resumed"
+	EventReasonBackupSucceeded        = "BackupSucceeded"
+	EventReasonBackupFailed           = "BackupFailed"
+	EventReasonRestoreStarted         = "RestoreStarted"
+	EventReasonRestoreProgressing     = "RestoreProgressing"
+	EventReasonRestoreSucceeded       = "RestoreSucceeded"
+	EventReasonRestoreFailed          = "RestoreFailed"
+)
 
-func newClusterEvent(cl *api.EtcdCluster) *v1.Event {
-	t := time.Now()
-	return &v1.Event{
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: cl.Name + "-",
-			Namespace:    cl.Namespace,
-		},
-		InvolvedObject: v1.ObjectReference{
-			APIVersion:      api.SchemeGroupVersion.String(),
-			Kind:            api.EtcdClusterResourceKind,
-			Name:            cl.Name,
-			Namespace:       cl.Namespace,
-			UID:             cl.UID,
-			ResourceVersion: cl.ResourceVersion,
-		},
-		Source: v1.EventSource{
-			Component: os.Getenv(constants.EnvOperatorPodName),
-		},
-		// Each cluster event is unique so it should not be collapsed with other events
-		FirstTimestamp: metav1.Time{Time: t},
-		LastTimestamp:  metav1.Time{Time: t},
-		Count:          int32(1),
-	}
+// NewEventRecorder returns an EventRecorder that records events of component
+// on Kubernetes objects and etcd custom resources, in the namespace of each object.
+// The recorder aggregates similar events.
+func NewEventRecorder(kubecli kubernetes.Interface, component string) record.EventRecorder {
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	etcdscheme.AddToScheme(s)
+
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(logrus.Infof)
+	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: kubecli.CoreV1().Events("")})
+	return eventBroadcaster.NewRecorder(s, v1.EventSource{Component: component})
 }
@@ -20,6 +20,8 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,6 +32,7 @@ import (
 
 	appsv1beta1 "k8s.io/api/apps/v1beta1"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -54,11 +57,19 @@ const (
 	serverTLSDir             = "/etc/etcdtls/member/server-tls"
 	serverTLSVolume          = "member-server-tls"
 	operatorEtcdTLSDir       = "/etc/etcdtls/operator/etcd-tls"
+	backupTokenDir           = "/etc/etcd-operator/backup-token"
+	backupTokenVolume        = "backup-token"
+	backupCADir              = "/etc/etcd-operator/backup-ca"
+	backupCAVolume           = "backup-ca"
 	operatorEtcdTLSVolume    = "etcd-client-tls"
 )
 
 const TolerateUnreadyEndpointsAnnotation = "service.alpha.kubernetes.io/tolerate-unready-endpoints"
 
+// managedAnnotationsAnnotation lists the annotations the operator set on a resource,
+// so that it can remove those it no longer sets without touching the others.
+const managedAnnotationsAnnotation = "etcd.database.coreos.com/managed-annotations"
+
 func GetEtcdVersion(pod *v1.Pod) string {
 	return pod.Annotations[etcdVersionAnnotationKey]
 }
@@ -83,7 +94,33 @@ func PVCNameFromMember(memberName string) string {
 	return memberName
 }
 
-func makeRestoreInitContainers(backupURL *url.URL, token, repo, version string, m *etcdutil.Member) []v1.Container {
+// BackupSecretTokenKey is the key of the bearer token in the secret a seed member
+// fetches its backup with.
+const BackupSecretTokenKey = "token"
+
+// BackupSecretCAKey is the key of the CA certificate in the secret a seed member
+// verifies the backup server with. It is the same key as in the member server secret.
+const BackupSecretCAKey = "server-ca.crt"
+
+// BackupSource tells a seed member where to fetch the backup it restores from.
+type BackupSource struct {
+	// URL is the URL of the backup.
+	URL *url.URL
+	// TokenSecret is the secret holding the bearer token to fetch the backup with,
+	// under BackupSecretTokenKey.
+	TokenSecret string
+	// CASecret is the secret holding the CA certificate of the backup server, under
+	// BackupSecretCAKey. It is only used for an https URL.
+	CASecret string
+}
+
+func makeRestoreInitContainers(bs *BackupSource, token, repo, version string, m *etcdutil.Member) []v1.Container {
+	curlFlags := fmt.Sprintf(`-H "Authorization: Bearer $(cat %s/%s)"`, backupTokenDir, BackupSecretTokenKey)
+	mounts := append(etcdVolumeMounts(), v1.VolumeMount{Name: backupTokenVolume, MountPath: backupTokenDir, ReadOnly: true})
+	if bs.URL.Scheme == "https" {
+		curlFlags += fmt.Sprintf(" --cacert %s/%s", backupCADir, BackupSecretCAKey)
+		mounts = append(mounts, v1.VolumeMount{Name: backupCAVolume, MountPath: backupCADir, ReadOnly: true})
+	}
 	return []v1.Container{
 		{
 			Name:  "fetch-backup",
@@ -91,15 +128,15 @@ func makeRestoreInitContainers(backupURL *url.URL, token, repo, version string,
 			Command: []string{
 				"/bin/bash", "-ec",
 				fmt.Sprintf(`
-httpcode=$(curl --write-out %%\{http_code\} --silent --output %[1]s %[2]s)
+httpcode=$(curl --write-out %%\{http_code\} --silent %[3]s --output %[1]s %[2]s)
 if [[ "$httpcode" != "200" ]]; then
 	echo "http status code: ${httpcode}" >> /dev/termination-log
 	cat %[1]s >> /dev/termination-log
 	exit 1
 fi
-					`, backupFile, backupURL.String()),
+					`, backupFile, bs.URL.String(), curlFlags),
 			},
-			VolumeMounts: etcdVolumeMounts(),
+			VolumeMounts: mounts,
 		},
 		{
 			Name:  "restore-datadir",
@@ -127,14 +164,105 @@ func PodWithNodeSelector(p *v1.Pod, ns map[string]string) *v1.Pod {
 	return p
 }
 
-func CreateClientService(kubecli kubernetes.Interface, clusterName, ns string, owner metav1.OwnerReference) error {
+// SyncClientService creates the client Service of an etcd cluster as defined by policy,
+// or updates the existing one to match it. It returns the Service.
+// With metrics, the Service has a metrics port, and scrapeAnnotations adds the
+// prometheus.io scrape annotations for it.
+func SyncClientService(kubecli kubernetes.Interface, clusterName, ns string, policy *api.ClientServicePolicy, metrics *api.MetricsPolicy, scrapeAnnotations bool, owner metav1.OwnerReference) (*v1.Service, error) {
 	ports := []v1.ServicePort{{
 		Name:       "client",
 		Port:       EtcdClientPort,
 		TargetPort: intstr.FromInt(EtcdClientPort),
 		Protocol:   v1.ProtocolTCP,
 	}}
-	return createService(kubecli, ClientServiceName(clusterName), clusterName, ns, "", ports, owner)
+	if metrics != nil {
+		ports = append(ports, v1.ServicePort{
+			Name:       MetricsPortName,
+			Port:       int32(metrics.Port),
+			TargetPort: intstr.FromInt(metrics.Port),
+			Protocol:   v1.ProtocolTCP,
+		})
+	}
+	svc := newEtcdServiceManifest(ClientServiceName(clusterName), clusterName, "", ports)
+	applyClientServicePolicy(svc, policy)
+	if metrics != nil && scrapeAnnotations {
+		svc.Annotations[prometheusScrapeAnnotation] = "true"
+		svc.Annotations[prometheusPortAnnotation] = strconv.Itoa(metrics.Port)
+		svc.Annotations[prometheusPathAnnotation] = "/metrics"
+	}
+	addOwnerRefToObject(svc.GetObjectMeta(), owner)
+	annotations := svc.Annotations
+	svc.Annotations = nil
+	setManagedAnnotations(&svc.ObjectMeta, annotations)
+
+	old, err := kubecli.CoreV1().Services(ns).Get(svc.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return kubecli.CoreV1().Services(ns).Create(svc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updated := old.DeepCopy()
+	setManagedAnnotations(&updated.ObjectMeta, annotations)
+	updated.Spec.Type = svc.Spec.Type
+	updated.Spec.LoadBalancerSourceRanges = svc.Spec.LoadBalancerSourceRanges
+	updated.Spec.ExternalTrafficPolicy = svc.Spec.ExternalTrafficPolicy
+	if svc.Spec.Type == v1.ServiceTypeClusterIP {
+		// A ClusterIP Service must not keep the node ports it had as another type.
+		for i := range updated.Spec.Ports {
+			updated.Spec.Ports[i].NodePort = 0
+		}
+	}
+	if equality.Semantic.DeepEqual(old, updated) {
+		return old, nil
+	}
+	return kubecli.CoreV1().Services(ns).Update(updated)
+}
+
+// setManagedAnnotations sets the given annotations on the object, and removes the
+// annotations the operator set before but no longer sets. Other annotations are kept.
+func setManagedAnnotations(om *metav1.ObjectMeta, annotations map[string]string) {
+	if om.Annotations == nil {
+		om.Annotations = make(map[string]string)
+	}
+	if prev, ok := om.Annotations[managedAnnotationsAnnotation]; ok {
+		for _, k := range strings.Split(prev, ",") {
+			if _, ok := annotations[k]; !ok {
+				delete(om.Annotations, k)
+			}
+		}
+	}
+	keys := make([]string, 0, len(annotations))
+	for k, v := range annotations {
+		om.Annotations[k] = v
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	om.Annotations[managedAnnotationsAnnotation] = strings.Join(keys, ",")
+}
+
+func applyClientServicePolicy(svc *v1.Service, policy *api.ClientServicePolicy) {
+	svc.Spec.Type = v1.ServiceTypeClusterIP
+	if policy == nil {
+		return
+	}
+	if len(policy.Type) != 0 {
+		svc.Spec.Type = policy.Type
+	}
+	for k, v := range policy.Annotations {
+		if _, ok := svc.Annotations[k]; !ok {
+			svc.Annotations[k] = v
+		}
+	}
+	svc.Spec.LoadBalancerSourceRanges = policy.LoadBalancerSourceRanges
+	if svc.Spec.Type != v1.ServiceTypeClusterIP {
+		// Kubernetes defaults the external traffic policy of NodePort and LoadBalancer Services to Cluster.
+		svc.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeCluster
+		if len(policy.ExternalTrafficPolicy) != 0 {
+			svc.Spec.ExternalTrafficPolicy = policy.ExternalTrafficPolicy
+		}
+	}
 }
 
 func ClientServiceName(clusterName string) string {
@@ -234,9 +362,28 @@ func AddEtcdVolumeToPod(pod *v1.Pod, pvc *v1.PersistentVolumeClaim) {
 	pod.Spec.Volumes = append(pod.Spec.Volumes, vol)
 }
 
-func addRecoveryToPod(pod *v1.Pod, token string, m *etcdutil.Member, cs api.ClusterSpec, backupURL *url.URL) {
+func addRecoveryToPod(pod *v1.Pod, token string, m *etcdutil.Member, cs api.ClusterSpec, bs *BackupSource) {
 	pod.Spec.InitContainers = append(pod.Spec.InitContainers,
-		makeRestoreInitContainers(backupURL, token, cs.Repository, cs.Version, m)...)
+		makeRestoreInitContainers(bs, token, cs.Repository, cs.Version, m)...)
+	// The token secret is deleted once the backup is fetched.
+	optional := true
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name: backupTokenVolume,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{SecretName: bs.TokenSecret, Optional: &optional},
+		},
+	})
+	if bs.URL.Scheme == "https" {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+			Name: backupCAVolume,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: bs.CASecret,
+					Items:      []v1.KeyToPath{{Key: BackupSecretCAKey, Path: BackupSecretCAKey}},
+				},
+			},
+		})
+	}
 }
 
 func addOwnerRefToObject(o metav1.Object, r metav1.OwnerReference) {
@@ -245,13 +392,14 @@ func addOwnerRefToObject(o metav1.Object, r metav1.OwnerReference) {
 
 // NewSeedMemberPod returns a Pod manifest for a seed member.
 // It's special that it has new token, and might need recovery init containers
-func NewSeedMemberPod(clusterName string, ms etcdutil.MemberSet, m *etcdutil.Member, cs api.ClusterSpec, owner metav1.OwnerReference, backupURL *url.URL) *v1.Pod {
+// to fetch the backup from bs.
+func NewSeedMemberPod(clusterName string, ms etcdutil.MemberSet, m *etcdutil.Member, cs api.ClusterSpec, owner metav1.OwnerReference, bs *BackupSource) *v1.Pod {
 	token := uuid.New()
 	pod := newEtcdPod(m, ms.PeerURLPairs(), clusterName, "new", token, cs)
 	// TODO: PVC datadir support for restore process
 	AddEtcdVolumeToPod(pod, nil)
-	if backupURL != nil {
-		addRecoveryToPod(pod, token, m, cs, backupURL)
+	if bs != nil {
+		addRecoveryToPod(pod, token, m, cs, bs)
 	}
 	applyPodPolicy(clusterName, pod, cs.Pod)
 	addOwnerRefToObject(pod.GetObjectMeta(), owner)
@@ -283,6 +431,9 @@ func newEtcdPod(m *etcdutil.Member, initialCluster []string, clusterName, state,
 	if m.SecureClient {
 		commands += fmt.Sprintf(" --client-cert-auth=true --trusted-ca-file=%[1]s/server-ca.crt --cert-file=%[1]s/server.crt --key-file=%[1]s/server.key", serverTLSDir)
 	}
+	if cs.Metrics != nil {
+		commands += fmt.Sprintf(" --listen-metrics-urls=http://0.0.0.0:%d", cs.Metrics.Port)
+	}
 	if state == "new" {
 		commands = fmt.Sprintf("%s --initial-cluster-token=%s", commands, token)
 	}
@@ -293,8 +444,8 @@ func newEtcdPod(m *etcdutil.Member, initialCluster []string, clusterName, state,
 		"etcd_cluster": clusterName,
 	}
 
-	livenessProbe := newEtcdProbe(cs.TLS.IsSecureClient())
-	readinessProbe := newEtcdProbe(cs.TLS.IsSecureClient())
+	livenessProbe := newEtcdProbe(cs.TLS.IsSecureClient(), cs.Auth != nil)
+	readinessProbe := newEtcdProbe(cs.TLS.IsSecureClient(), cs.Auth != nil)
 	readinessProbe.InitialDelaySeconds = 1
 	readinessProbe.TimeoutSeconds = 5
 	readinessProbe.PeriodSeconds = 5
@@ -304,6 +455,24 @@ func newEtcdPod(m *etcdutil.Member, initialCluster []string, clusterName, state,
 		etcdContainer(strings.Split(commands, " "), cs.Repository, cs.Version),
 		livenessProbe,
 		readinessProbe)
+	if cs.Metrics != nil {
+		container.Ports = append(container.Ports, v1.ContainerPort{
+			Name:          MetricsPortName,
+			ContainerPort: int32(cs.Metrics.Port),
+			Protocol:      v1.ProtocolTCP,
+		})
+	}
+	if cs.Auth != nil {
+		container.Env = append(container.Env, v1.EnvVar{
+			Name: probeRootPasswordEnv,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: cs.Auth.RootSecret},
+					Key:                  api.AuthSecretPasswordKey,
+				},
+			},
+		})
+	}
 
 	volumes := []v1.Volume{}
 
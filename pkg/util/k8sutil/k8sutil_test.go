@@ -0,0 +1,102 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sutil
+
+import (
+	"strings"
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewEtcdPodMetrics(t *testing.T) {
+	m := &etcdutil.Member{Name: "test-0000", Namespace: metav1.NamespaceDefault}
+	tests := []struct {
+		metrics *api.MetricsPolicy
+		wFlag   string
+		wPort   int32
+	}{
+		{},
+		{metrics: &api.MetricsPolicy{Port: 2381}, wFlag: "--listen-metrics-urls=http://0.0.0.0:2381", wPort: 2381},
+	}
+	for i, tt := range tests {
+		pod := newEtcdPod(m, nil, "test", "new", "token", api.ClusterSpec{Metrics: tt.metrics})
+		c := pod.Spec.Containers[0]
+		cmd := strings.Join(c.Command, " ")
+		if hasFlag := strings.Contains(cmd, "--listen-metrics-urls"); hasFlag != (tt.wFlag != "") || !strings.Contains(cmd, tt.wFlag) {
+			t.Errorf("#%d: expect metrics flag %q, get command %q", i, tt.wFlag, cmd)
+		}
+		var port int32
+		for _, p := range c.Ports {
+			if p.Name == MetricsPortName {
+				port = p.ContainerPort
+			}
+		}
+		if port != tt.wPort {
+			t.Errorf("#%d: expect metrics port %d, get %d", i, tt.wPort, port)
+		}
+	}
+}
+
+func TestSyncClientServiceMetrics(t *testing.T) {
+	tests := []struct {
+		metrics           *api.MetricsPolicy
+		scrapeAnnotations bool
+		wPort             int32
+		wScrape           bool
+	}{
+		{},
+		{metrics: &api.MetricsPolicy{Port: 2381}, wPort: 2381},
+		{metrics: &api.MetricsPolicy{Port: 2381}, scrapeAnnotations: true, wPort: 2381, wScrape: true},
+	}
+	for i, tt := range tests {
+		kubecli := fake.NewSimpleClientset()
+		svc, err := SyncClientService(kubecli, "test", metav1.NamespaceDefault, nil, tt.metrics, tt.scrapeAnnotations, metav1.OwnerReference{})
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		var port int32
+		for _, p := range svc.Spec.Ports {
+			if p.Name == MetricsPortName {
+				port = p.Port
+			}
+		}
+		if port != tt.wPort {
+			t.Errorf("#%d: expect metrics port %d, get %d", i, tt.wPort, port)
+		}
+		wAnnotations := map[string]string{}
+		if tt.wScrape {
+			wAnnotations = map[string]string{"prometheus.io/scrape": "true", "prometheus.io/port": "2381", "prometheus.io/path": "/metrics"}
+		}
+		for _, k := range []string{prometheusScrapeAnnotation, prometheusPortAnnotation, prometheusPathAnnotation} {
+			if svc.Annotations[k] != wAnnotations[k] {
+				t.Errorf("#%d: expect annotation %s=%q, get %q", i, k, wAnnotations[k], svc.Annotations[k])
+			}
+		}
+
+		// The annotations are removed once they are no longer wanted.
+		svc, err = SyncClientService(kubecli, "test", metav1.NamespaceDefault, nil, tt.metrics, false, metav1.OwnerReference{})
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if _, ok := svc.Annotations[prometheusScrapeAnnotation]; ok {
+			t.Errorf("#%d: expect scrape annotation to be removed, get %v", i, svc.Annotations)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sutil
+
+import (
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodDisruptionBudgetName returns the name of the PodDisruptionBudget of the given etcd cluster.
+func PodDisruptionBudgetName(clusterName string) string {
+	return clusterName
+}
+
+// NewEtcdPodDisruptionBudget returns a PodDisruptionBudget for the etcd pods of the given
+// cluster that allows maxUnavailable pods to be disrupted at the same time.
+func NewEtcdPodDisruptionBudget(clusterName string, maxUnavailable int, owner metav1.OwnerReference) *policyv1beta1.PodDisruptionBudget {
+	mu := intstr.FromInt(maxUnavailable)
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   PodDisruptionBudgetName(clusterName),
+			Labels: LabelsForCluster(clusterName),
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &mu,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: LabelsForCluster(clusterName),
+			},
+		},
+	}
+	addOwnerRefToObject(pdb.GetObjectMeta(), owner)
+	return pdb
+}
+
+// SyncPodDisruptionBudget creates the given PodDisruptionBudget, or replaces the existing
+// one if its spec is different. The spec of a PodDisruptionBudget cannot be updated.
+// An existing PodDisruptionBudget not controlled by the owner of the given one is left alone.
+func SyncPodDisruptionBudget(kubecli kubernetes.Interface, ns string, pdb *policyv1beta1.PodDisruptionBudget) error {
+	owner := metav1.GetControllerOf(pdb)
+	old, err := kubecli.PolicyV1beta1().PodDisruptionBudgets(ns).Get(pdb.Name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		if owner == nil || !IsControlledBy(old, owner.UID) {
+			return nil
+		}
+		if equality.Semantic.DeepEqual(old.Spec, pdb.Spec) {
+			return nil
+		}
+		if err := deletePodDisruptionBudget(kubecli, old); err != nil {
+			return err
+		}
+	case !apierrors.IsNotFound(err):
+		return err
+	}
+
+	_, err = kubecli.PolicyV1beta1().PodDisruptionBudgets(ns).Create(pdb)
+	return err
+}
+
+// DeletePodDisruptionBudget deletes the given PodDisruptionBudget if it exists and
+// is controlled by the owner of the given UID.
+func DeletePodDisruptionBudget(kubecli kubernetes.Interface, ns, name string, ownerUID types.UID) error {
+	pdb, err := kubecli.PolicyV1beta1().PodDisruptionBudgets(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !IsControlledBy(pdb, ownerUID) {
+		return nil
+	}
+	return deletePodDisruptionBudget(kubecli, pdb)
+}
+
+// deletePodDisruptionBudget deletes the given PodDisruptionBudget, unless it was
+// replaced by another one of the same name since it was read.
+func deletePodDisruptionBudget(kubecli kubernetes.Interface, pdb *policyv1beta1.PodDisruptionBudget) error {
+	err := kubecli.PolicyV1beta1().PodDisruptionBudgets(pdb.Namespace).Delete(pdb.Name, &metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &pdb.UID},
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// IsControlledBy returns true if the controller of o is the object with the given UID.
+func IsControlledBy(o metav1.Object, uid types.UID) bool {
+	ref := metav1.GetControllerOf(o)
+	return ref != nil && ref.UID == uid
+}
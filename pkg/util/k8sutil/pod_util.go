@@ -26,6 +26,10 @@ import (
 
 const (
 	etcdVolumeName = "etcd-data"
+
+	// probeRootPasswordEnv holds the etcd root password for the probes.
+	// It must not start with ETCD_, which etcd reads as flags.
+	probeRootPasswordEnv = "OPERATOR_ETCD_ROOT_PASSWORD"
 )
 
 func etcdVolumeMounts() []v1.VolumeMount {
@@ -68,13 +72,30 @@ func containerWithRequirements(c v1.Container, r v1.ResourceRequirements) v1.Con
 	return c
 }
 
-func newEtcdProbe(isSecure bool) *v1.Probe {
+// HasProbeRootPassword returns true if the probes of the etcd pod can authenticate
+// as the root user, i.e. the pod was created with spec.auth.
+func HasProbeRootPassword(pod *v1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		for _, e := range c.Env {
+			if e.Name == probeRootPasswordEnv {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newEtcdProbe(isSecure, auth bool) *v1.Probe {
 	// etcd pod is alive only if a linearizable get succeeds.
 	cmd := "ETCDCTL_API=3 etcdctl get foo"
 	if isSecure {
 		tlsFlags := fmt.Sprintf("--cert=%[1]s/%[2]s --key=%[1]s/%[3]s --cacert=%[1]s/%[4]s", operatorEtcdTLSDir, etcdutil.CliCertFile, etcdutil.CliKeyFile, etcdutil.CliCAFile)
 		cmd = fmt.Sprintf("ETCDCTL_API=3 etcdctl --endpoints=https://localhost:%d %s get foo", EtcdClientPort, tlsFlags)
 	}
+	if auth {
+		// The get is retried without credentials until the operator enables authentication.
+		cmd = fmt.Sprintf(`%[1]s --user="%[2]s:$%[3]s" || %[1]s`, cmd, api.AuthRootUser, probeRootPasswordEnv)
+	}
 	return &v1.Probe{
 		Handler: v1.Handler{
 			Exec: &v1.ExecAction{
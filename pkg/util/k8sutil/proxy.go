@@ -0,0 +1,237 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sutil
+
+import (
+	"fmt"
+	"strings"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ProxyName returns the name of the Deployment and the Service of the etcd gRPC
+// proxies of the given etcd cluster.
+func ProxyName(clusterName string) string {
+	return clusterName + "-proxy"
+}
+
+// labelsForProxy returns the labels of the proxy pods. They must not match the
+// selector of the etcd pods.
+func labelsForProxy(clusterName string) map[string]string {
+	return map[string]string{
+		"etcd_cluster": clusterName,
+		"app":          "etcd-proxy",
+	}
+}
+
+// NewEtcdProxyDeployment returns a Deployment running etcd gRPC proxies in front of
+// the given member endpoints.
+func NewEtcdProxyDeployment(clusterName string, endpoints []string, cs api.ClusterSpec, owner metav1.OwnerReference) *appsv1beta1.Deployment {
+	policy := cs.Proxy
+	commands := fmt.Sprintf("/usr/local/bin/etcd grpc-proxy start --listen-addr=0.0.0.0:%d --endpoints=%s",
+		EtcdClientPort, strings.Join(endpoints, ","))
+	if len(policy.NamespacePrefix) != 0 {
+		commands += fmt.Sprintf(" --namespace=%s", policy.NamespacePrefix)
+	}
+
+	container := v1.Container{
+		Name:  "etcd-proxy",
+		Image: ImageName(cs.Repository, cs.Version),
+		Ports: []v1.ContainerPort{{
+			Name:          "client",
+			ContainerPort: int32(EtcdClientPort),
+			Protocol:      v1.ProtocolTCP,
+		}},
+		Resources: policy.Resources,
+	}
+	var volumes []v1.Volume
+	if cs.TLS.IsSecureClient() {
+		// The proxies connect to the members as the operator does, and serve
+		// clients with the members' server certificate.
+		commands += fmt.Sprintf(" --cert=%[1]s/%[2]s --key=%[1]s/%[3]s --cacert=%[1]s/%[4]s",
+			operatorEtcdTLSDir, etcdutil.CliCertFile, etcdutil.CliKeyFile, etcdutil.CliCAFile)
+		commands += fmt.Sprintf(" --trusted-ca-file=%[1]s/server-ca.crt --cert-file=%[1]s/server.crt --key-file=%[1]s/server.key", serverTLSDir)
+		container.VolumeMounts = []v1.VolumeMount{{
+			MountPath: serverTLSDir,
+			Name:      serverTLSVolume,
+		}, {
+			MountPath: operatorEtcdTLSDir,
+			Name:      operatorEtcdTLSVolume,
+		}}
+		volumes = []v1.Volume{{Name: serverTLSVolume, VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{SecretName: cs.TLS.Static.Member.ServerSecret},
+		}}, {Name: operatorEtcdTLSVolume, VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{SecretName: cs.TLS.Static.OperatorSecret},
+		}}}
+	}
+	container.Command = strings.Split(commands, " ")
+
+	replicas := int32(1)
+	if policy.Replicas > 0 {
+		replicas = int32(policy.Replicas)
+	}
+	runAsNonRoot := true
+	podUID := int64(9000)
+	labels := labelsForProxy(clusterName)
+	d := &appsv1beta1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   ProxyName(clusterName),
+			Labels: labels,
+		},
+		Spec: appsv1beta1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					Containers:                   []v1.Container{container},
+					Volumes:                      volumes,
+					AutomountServiceAccountToken: func(b bool) *bool { return &b }(false),
+					SecurityContext: &v1.PodSecurityContext{
+						RunAsUser:    &podUID,
+						RunAsNonRoot: &runAsNonRoot,
+					},
+				},
+			},
+		},
+	}
+	addOwnerRefToObject(d.GetObjectMeta(), owner)
+	return d
+}
+
+// NewEtcdProxyService returns the Service of the etcd gRPC proxies of the given cluster.
+func NewEtcdProxyService(clusterName string, owner metav1.OwnerReference) *v1.Service {
+	labels := labelsForProxy(clusterName)
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   ProxyName(clusterName),
+			Labels: labels,
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "client",
+				Port:       EtcdClientPort,
+				TargetPort: intstr.FromInt(EtcdClientPort),
+				Protocol:   v1.ProtocolTCP,
+			}},
+			Selector: labels,
+		},
+	}
+	addOwnerRefToObject(svc.GetObjectMeta(), owner)
+	return svc
+}
+
+// SyncProxy creates the given proxy Deployment and Service, or brings the existing
+// ones in line with them. An existing Deployment or Service not controlled by the
+// owner of the given ones is left alone.
+func SyncProxy(kubecli kubernetes.Interface, ns string, d *appsv1beta1.Deployment, svc *v1.Service) error {
+	owner := metav1.GetControllerOf(d)
+	old, err := kubecli.AppsV1beta1().Deployments(ns).Get(d.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		if _, err = kubecli.AppsV1beta1().Deployments(ns).Create(d); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case owner == nil || !IsControlledBy(old, owner.UID):
+		// The Deployment is not the cluster's.
+	case !isProxyDeploymentEqual(old, d):
+		updated := old.DeepCopy()
+		updated.Spec.Replicas = d.Spec.Replicas
+		updated.Spec.Template = d.Spec.Template
+		if _, err = kubecli.AppsV1beta1().Deployments(ns).Update(updated); err != nil {
+			return err
+		}
+	}
+
+	oldSvc, err := kubecli.CoreV1().Services(ns).Get(svc.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = kubecli.CoreV1().Services(ns).Create(svc)
+		return err
+	case err != nil:
+		return err
+	case owner == nil || !IsControlledBy(oldSvc, owner.UID):
+		return nil
+	}
+	updated := oldSvc.DeepCopy()
+	updated.Labels = svc.Labels
+	updated.Spec.Selector = svc.Spec.Selector
+	updated.Spec.Ports = svc.Spec.Ports
+	if equality.Semantic.DeepEqual(oldSvc, updated) {
+		return nil
+	}
+	_, err = kubecli.CoreV1().Services(ns).Update(updated)
+	return err
+}
+
+// isProxyDeploymentEqual compares the fields of the proxy Deployments that the
+// operator sets and Kubernetes does not default.
+func isProxyDeploymentEqual(old, d *appsv1beta1.Deployment) bool {
+	if *old.Spec.Replicas != *d.Spec.Replicas || len(old.Spec.Template.Spec.Containers) != 1 {
+		return false
+	}
+	oc, c := old.Spec.Template.Spec.Containers[0], d.Spec.Template.Spec.Containers[0]
+	return oc.Image == c.Image &&
+		equality.Semantic.DeepEqual(oc.Command, c.Command) &&
+		equality.Semantic.DeepEqual(oc.Resources, c.Resources)
+}
+
+// DeleteProxy deletes the proxy Deployment and Service of the given cluster if they
+// exist and are controlled by the owner of the given UID.
+func DeleteProxy(kubecli kubernetes.Interface, clusterName, ns string, ownerUID types.UID) error {
+	name := ProxyName(clusterName)
+	d, err := kubecli.AppsV1beta1().Deployments(ns).Get(name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		if IsControlledBy(d, ownerUID) {
+			opts := CascadeDeleteOptions(0)
+			opts.Preconditions = &metav1.Preconditions{UID: &d.UID}
+			err = kubecli.AppsV1beta1().Deployments(ns).Delete(name, opts)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	case !apierrors.IsNotFound(err):
+		return err
+	}
+
+	svc, err := kubecli.CoreV1().Services(ns).Get(name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		if IsControlledBy(svc, ownerUID) {
+			err = kubecli.CoreV1().Services(ns).Delete(name, &metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{UID: &svc.UID},
+			})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	case !apierrors.IsNotFound(err):
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sutil
+
+import (
+	"encoding/json"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// MetricsPortName is the name of the metrics port of the etcd pods and the client Service.
+	MetricsPortName = "metrics"
+
+	prometheusScrapeAnnotation = "prometheus.io/scrape"
+	prometheusPortAnnotation   = "prometheus.io/port"
+	prometheusPathAnnotation   = "prometheus.io/path"
+
+	serviceMonitorGroupVersion = "monitoring.coreos.com/v1"
+	serviceMonitorResource     = "servicemonitors"
+)
+
+// ServiceMonitor is the subset of the Prometheus Operator's ServiceMonitor
+// that the etcd operator sets.
+type ServiceMonitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ServiceMonitorSpec `json:"spec"`
+}
+
+// ServiceMonitorSpec selects the Services whose endpoints Prometheus scrapes.
+type ServiceMonitorSpec struct {
+	Selector  metav1.LabelSelector     `json:"selector"`
+	Endpoints []ServiceMonitorEndpoint `json:"endpoints"`
+}
+
+// ServiceMonitorEndpoint is a port of the selected Services to scrape.
+type ServiceMonitorEndpoint struct {
+	Port string `json:"port"`
+	Path string `json:"path,omitempty"`
+}
+
+// ServiceMonitorName returns the name of the ServiceMonitor of the given etcd cluster.
+func ServiceMonitorName(clusterName string) string {
+	return clusterName
+}
+
+// HasServiceMonitorCRD returns true if the ServiceMonitor CRD of the Prometheus Operator is installed.
+func HasServiceMonitorCRD(kubecli kubernetes.Interface) (bool, error) {
+	rl, err := kubecli.Discovery().ServerResourcesForGroupVersion(serviceMonitorGroupVersion)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, r := range rl.APIResources {
+		if r.Name == serviceMonitorResource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// NewEtcdServiceMonitor returns a ServiceMonitor that scrapes the metrics port
+// of the client Service of the given cluster.
+func NewEtcdServiceMonitor(clusterName string, labels map[string]string, owner metav1.OwnerReference) *ServiceMonitor {
+	sm := &ServiceMonitor{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: serviceMonitorGroupVersion,
+			Kind:       "ServiceMonitor",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   ServiceMonitorName(clusterName),
+			Labels: LabelsForCluster(clusterName),
+		},
+		Spec: ServiceMonitorSpec{
+			// The peer Service has the same labels but no metrics port.
+			Selector: metav1.LabelSelector{MatchLabels: LabelsForCluster(clusterName)},
+			Endpoints: []ServiceMonitorEndpoint{{
+				Port: MetricsPortName,
+				Path: "/metrics",
+			}},
+		},
+	}
+	for k, v := range labels {
+		if _, ok := sm.Labels[k]; !ok {
+			sm.Labels[k] = v
+		}
+	}
+	addOwnerRefToObject(sm.GetObjectMeta(), owner)
+	return sm
+}
+
+// GetServiceMonitor returns the ServiceMonitor of the given name.
+func GetServiceMonitor(kubecli kubernetes.Interface, ns, name string) (*ServiceMonitor, error) {
+	path := fmt.Sprintf("/apis/%s/namespaces/%s/%s/%s", serviceMonitorGroupVersion, ns, serviceMonitorResource, name)
+	data, err := kubecli.CoreV1().RESTClient().Get().AbsPath(path).Do().Raw()
+	if err != nil {
+		return nil, err
+	}
+	sm := &ServiceMonitor{}
+	if err := json.Unmarshal(data, sm); err != nil {
+		return nil, err
+	}
+	return sm, nil
+}
+
+// CreateServiceMonitor creates the given ServiceMonitor unless it exists.
+// ServiceMonitors are not part of the Kubernetes API, so it goes through the
+// REST client of the core API with the path of the monitoring API group.
+func CreateServiceMonitor(kubecli kubernetes.Interface, ns string, sm *ServiceMonitor) error {
+	data, err := json.Marshal(sm)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/apis/%s/namespaces/%s/%s", serviceMonitorGroupVersion, ns, serviceMonitorResource)
+	err = kubecli.CoreV1().RESTClient().Post().
+		AbsPath(path).
+		SetHeader("Content-Type", "application/json").
+		Body(data).
+		Do().
+		Error()
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sutil
+
+import (
+	"reflect"
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewEtcdServiceMonitor(t *testing.T) {
+	ec := &api.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", UID: "cluster-uid"}}
+	sm := NewEtcdServiceMonitor("test", map[string]string{"prometheus": "main", "app": "other"}, ec.AsOwner())
+
+	if sm.Name != "test" || sm.Kind != "ServiceMonitor" || sm.APIVersion != "monitoring.coreos.com/v1" {
+		t.Errorf("unexpected ServiceMonitor %s %s/%s", sm.APIVersion, sm.Kind, sm.Name)
+	}
+	// The cluster labels are kept over the given ones.
+	wLabels := map[string]string{"etcd_cluster": "test", "app": "etcd", "prometheus": "main"}
+	if !reflect.DeepEqual(sm.Labels, wLabels) {
+		t.Errorf("expect labels %v, get %v", wLabels, sm.Labels)
+	}
+	if !reflect.DeepEqual(sm.Spec.Selector.MatchLabels, LabelsForCluster("test")) {
+		t.Errorf("expect selector %v, get %v", LabelsForCluster("test"), sm.Spec.Selector.MatchLabels)
+	}
+	wEndpoints := []ServiceMonitorEndpoint{{Port: MetricsPortName, Path: "/metrics"}}
+	if !reflect.DeepEqual(sm.Spec.Endpoints, wEndpoints) {
+		t.Errorf("expect endpoints %v, get %v", wEndpoints, sm.Spec.Endpoints)
+	}
+	if !IsControlledBy(sm, ec.UID) {
+		t.Errorf("expect ServiceMonitor to be controlled by the cluster, get %v", sm.OwnerReferences)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	otlpTracesPath = "/v1/traces"
+
+	defaultFlushInterval = 5 * time.Second
+	maxBatchSize         = 512
+	// spans are dropped once this many are waiting to be exported.
+	maxQueueSize = 4096
+
+	// See opentelemetry-proto trace/v1 Span.SpanKind and Status.StatusCode.
+	spanKindInternal = 1
+	statusCodeOK     = 1
+	statusCodeError  = 2
+)
+
+// OTLPExporter exports spans in batches to an OTLP collector using
+// OTLP/HTTP with JSON encoding.
+type OTLPExporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+
+	flushInterval time.Duration
+	queue         chan SpanData
+}
+
+// NewOTLPExporter returns an exporter that sends spans to the OTLP/HTTP
+// collector at endpoint, e.g. "http://otel-collector:4318".
+// serviceName is reported as the service.name resource attribute.
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	e := newOTLPExporter(endpoint, serviceName, defaultFlushInterval)
+	go e.run()
+	return e
+}
+
+func newOTLPExporter(endpoint, serviceName string, flushInterval time.Duration) *OTLPExporter {
+	return &OTLPExporter{
+		url:           strings.TrimSuffix(endpoint, "/") + otlpTracesPath,
+		serviceName:   serviceName,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		flushInterval: flushInterval,
+		queue:         make(chan SpanData, maxQueueSize),
+	}
+}
+
+// ExportSpan implements Exporter. The span is dropped if the export queue is full.
+func (e *OTLPExporter) ExportSpan(s SpanData) {
+	select {
+	case e.queue <- s:
+	default:
+	}
+}
+
+func (e *OTLPExporter) run() {
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	var batch []SpanData
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.export(batch); err != nil {
+			logrus.Warningf("failed to export %d spans: %v", len(batch), err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (e *OTLPExporter) export(spans []SpanData) error {
+	b, err := json.Marshal(newExportRequest(e.serviceName, spans))
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code from %s: %d", e.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// The types below are the JSON mapping of the OTLP ExportTraceServiceRequest.
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func newExportRequest(serviceName string, spans []SpanData) *exportRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		out = append(out, toOTLPSpan(s))
+	}
+	return &exportRequest{
+		ResourceSpans: []resourceSpans{{
+			Resource: resource{Attributes: []keyValue{stringAttribute("service.name", serviceName)}},
+			ScopeSpans: []scopeSpans{{
+				Scope: scope{Name: "github.com/coreos/etcd-operator"},
+				Spans: out,
+			}},
+		}},
+	}
+}
+
+func toOTLPSpan(s SpanData) otlpSpan {
+	o := otlpSpan{
+		TraceID:           hex.EncodeToString(s.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.SpanID[:]),
+		Name:              s.Name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+		Status:            status{Code: statusCodeOK},
+	}
+	if s.HasParent() {
+		o.ParentSpanID = hex.EncodeToString(s.ParentID[:])
+	}
+	if len(s.Error) != 0 {
+		o.Status = status{Code: statusCodeError, Message: s.Error}
+	}
+	keys := make([]string, 0, len(s.Attributes))
+	for k := range s.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		o.Attributes = append(o.Attributes, stringAttribute(k, s.Attributes[k]))
+	}
+	return o
+}
+
+func stringAttribute(key, value string) keyValue {
+	return keyValue{Key: key, Value: anyValue{StringValue: value}}
+}
+
+// Init enables tracing with an OTLP exporter if endpoint is not empty.
+func Init(endpoint, serviceName string) {
+	if len(endpoint) == 0 {
+		return
+	}
+	SetExporter(NewOTLPExporter(endpoint, serviceName))
+	logrus.Infof("exporting traces to %s", endpoint)
+}
@@ -0,0 +1,202 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing records spans of the operator's reconcile, backup and restore
+// operations and exports them to an OTLP collector.
+//
+// Tracing is disabled until an exporter is set with SetExporter. While disabled,
+// Start returns a nil *Span whose methods are no-ops, so callers never need to
+// check whether tracing is enabled.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Exporter receives the spans that have ended.
+// ExportSpan must not block the caller for long.
+type Exporter interface {
+	ExportSpan(s SpanData)
+}
+
+// SpanData is the recorded state of an ended span.
+type SpanData struct {
+	TraceID  [16]byte
+	SpanID   [8]byte
+	ParentID [8]byte
+
+	Name       string
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes map[string]string
+	// Error is the error the span ended with. It is empty if the operation succeeded.
+	Error string
+}
+
+// HasParent reports whether the span has a parent span.
+func (s SpanData) HasParent() bool {
+	return s.ParentID != [8]byte{}
+}
+
+// Span is an operation being traced.
+// A nil *Span is valid and does nothing.
+type Span struct {
+	exporter Exporter
+	data     SpanData
+}
+
+type spanKey struct{}
+
+var (
+	mu       sync.RWMutex
+	exporter Exporter
+)
+
+// SetExporter sets the exporter that spans are sent to.
+// A nil exporter disables tracing.
+func SetExporter(e Exporter) {
+	mu.Lock()
+	exporter = e
+	mu.Unlock()
+}
+
+func getExporter() Exporter {
+	mu.RLock()
+	defer mu.RUnlock()
+	return exporter
+}
+
+// Start starts a span with the given name. The span is a child of the span in ctx, if any.
+// The returned context carries the new span.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	e := getExporter()
+	if e == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s := &Span{
+		exporter: e,
+		data: SpanData{
+			Name:      name,
+			StartTime: time.Now(),
+			SpanID:    newSpanID(),
+		},
+	}
+	if p := FromContext(ctx); p != nil {
+		s.data.TraceID = p.data.TraceID
+		s.data.ParentID = p.data.SpanID
+	} else {
+		s.data.TraceID = newTraceID()
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// FromContext returns the span carried by ctx, or nil if there is none.
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// SetAttribute records a key value pair on the span.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]string)
+	}
+	s.data.Attributes[key] = value
+}
+
+// End ends the span and hands it to the exporter. err is the result of the traced operation.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.data.EndTime = time.Now()
+	if err != nil {
+		s.data.Error = err.Error()
+	}
+	s.exporter.ExportSpan(s.data)
+}
+
+// TraceID returns the hex encoded trace ID of the span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.data.TraceID[:])
+}
+
+// SpanID returns the hex encoded ID of the span.
+func (s *Span) SpanID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.data.SpanID[:])
+}
+
+// LogFields returns the logrus fields that identify the span in ctx.
+// It returns empty fields if ctx carries no span.
+func LogFields(ctx context.Context) logrus.Fields {
+	s := FromContext(ctx)
+	if s == nil {
+		return logrus.Fields{}
+	}
+	return logrus.Fields{
+		"trace_id": s.TraceID(),
+		"span_id":  s.SpanID(),
+	}
+}
+
+func newTraceID() (id [16]byte) {
+	rand.Read(id[:])
+	return id
+}
+
+func newSpanID() (id [8]byte) {
+	rand.Read(id[:])
+	return id
+}
+
+// MemoryExporter keeps the exported spans in memory. It is meant for tests.
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// ExportSpan implements Exporter.
+func (e *MemoryExporter) ExportSpan(s SpanData) {
+	e.mu.Lock()
+	e.spans = append(e.spans, s)
+	e.mu.Unlock()
+}
+
+// Spans returns the spans exported so far, in the order they ended.
+func (e *MemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
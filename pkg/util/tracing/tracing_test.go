@@ -0,0 +1,133 @@
+// Copyright 2018 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStartWithoutExporter(t *testing.T) {
+	SetExporter(nil)
+	ctx, span := Start(context.Background(), "noop")
+	if span != nil {
+		t.Fatalf("expect nil span when tracing is disabled, got %v", span)
+	}
+	// methods of a nil span must not panic.
+	span.SetAttribute("k", "v")
+	span.End(errors.New("ignored"))
+	if f := LogFields(ctx); len(f) != 0 {
+		t.Errorf("expect no log fields, got %v", f)
+	}
+}
+
+func TestSpanParenting(t *testing.T) {
+	e := &MemoryExporter{}
+	SetExporter(e)
+	defer SetExporter(nil)
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	child.SetAttribute("member", "example-0000")
+	child.End(errors.New("boom"))
+	parent.End(nil)
+
+	spans := e.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expect 2 spans, got %d", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.Name != "child" || p.Name != "parent" {
+		t.Fatalf("unexpected span order: %s, %s", c.Name, p.Name)
+	}
+	if c.TraceID != p.TraceID {
+		t.Errorf("expect child to share the parent's trace ID")
+	}
+	if c.ParentID != p.SpanID {
+		t.Errorf("expect child's parent ID %x, got %x", p.SpanID, c.ParentID)
+	}
+	if p.HasParent() {
+		t.Errorf("expect root span to have no parent")
+	}
+	if c.Error != "boom" || p.Error != "" {
+		t.Errorf("unexpected span errors: child %q, parent %q", c.Error, p.Error)
+	}
+	if c.Attributes["member"] != "example-0000" {
+		t.Errorf("expect member attribute, got %v", c.Attributes)
+	}
+
+	f := LogFields(ctx)
+	if f["trace_id"] != hex.EncodeToString(p.TraceID[:]) || f["span_id"] != hex.EncodeToString(p.SpanID[:]) {
+		t.Errorf("unexpected log fields: %v", f)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	reqs := make(chan exportRequest, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != otlpTracesPath {
+			t.Errorf("expect path %s, got %s", otlpTracesPath, r.URL.Path)
+		}
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		reqs <- req
+	}))
+	defer ts.Close()
+
+	e := newOTLPExporter(ts.URL+"/", "etcd-operator", 10*time.Millisecond)
+	go e.run()
+	SetExporter(e)
+	defer SetExporter(nil)
+
+	ctx, parent := Start(context.Background(), "Cluster.reconcile")
+	_, child := Start(ctx, "etcdutil.ListMembers")
+	child.End(errors.New("context deadline exceeded"))
+	parent.End(nil)
+
+	var req exportRequest
+	select {
+	case req = <-reqs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the export request")
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected request layout: %+v", req)
+	}
+	if attrs := req.ResourceSpans[0].Resource.Attributes; len(attrs) != 1 || attrs[0].Value.StringValue != "etcd-operator" {
+		t.Errorf("unexpected resource attributes: %+v", attrs)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expect 2 spans, got %d", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.ParentSpanID != p.SpanID || c.TraceID != p.TraceID {
+		t.Errorf("child span is not linked to its parent: %+v, %+v", c, p)
+	}
+	if c.Status.Code != statusCodeError || p.Status.Code != statusCodeOK {
+		t.Errorf("unexpected status codes: child %d, parent %d", c.Status.Code, p.Status.Code)
+	}
+	if len(p.TraceID) != 32 || len(p.SpanID) != 16 {
+		t.Errorf("unexpected ID lengths: trace %q, span %q", p.TraceID, p.SpanID)
+	}
+}